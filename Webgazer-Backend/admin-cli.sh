@@ -4,6 +4,7 @@
 # Usage: ./admin-cli.sh
 
 API_URL="${API_URL:-http://localhost:8080}"
+ADMIN_TOKEN="${ADMIN_TOKEN:-}"
 
 # Colors
 RED='\033[0;31m'
@@ -33,7 +34,8 @@ show_info() {
 
 # API functions
 api_get() {
-    local response=$(curl -s -w "\n%{http_code}" "$API_URL$1")
+    local response=$(curl -s -w "\n%{http_code}" \
+        -H "Authorization: Bearer $ADMIN_TOKEN" "$API_URL$1")
     local http_code=$(echo "$response" | tail -n1)
     local body=$(echo "$response" | sed '$d')
     
@@ -50,6 +52,7 @@ api_get() {
 api_post() {
     local response=$(curl -s -w "\n%{http_code}" -X POST "$API_URL$1" \
         -H "Content-Type: application/json" \
+        -H "Authorization: Bearer $ADMIN_TOKEN" \
         -d "$2")
     local http_code=$(echo "$response" | tail -n1)
     local body=$(echo "$response" | sed '$d')
@@ -67,6 +70,7 @@ api_post() {
 api_put() {
     local response=$(curl -s -w "\n%{http_code}" -X PUT "$API_URL$1" \
         -H "Content-Type: application/json" \
+        -H "Authorization: Bearer $ADMIN_TOKEN" \
         -d "$2")
     local http_code=$(echo "$response" | tail -n1)
     local body=$(echo "$response" | sed '$d')
@@ -82,7 +86,8 @@ api_put() {
 }
 
 api_delete() {
-    local response=$(curl -s -w "\n%{http_code}" -X DELETE "$API_URL$1")
+    local response=$(curl -s -w "\n%{http_code}" -X DELETE \
+        -H "Authorization: Bearer $ADMIN_TOKEN" "$API_URL$1")
     local http_code=$(echo "$response" | tail -n1)
     local body=$(echo "$response" | sed '$d')
     
@@ -96,6 +101,35 @@ api_delete() {
     fi
 }
 
+# Log in and store the admin token (skipped if ADMIN_TOKEN is already set)
+admin_login() {
+    if [[ -n "$ADMIN_TOKEN" ]]; then
+        return 0
+    fi
+
+    local username="${ADMIN_USERNAME:-}"
+    local password="${ADMIN_PASSWORD:-}"
+    if [[ -z "$username" ]]; then
+        read -p "Admin username: " username
+    fi
+    if [[ -z "$password" ]]; then
+        read -s -p "Admin password: " password
+        echo ""
+    fi
+
+    local body=$(jq -n --arg u "$username" --arg p "$password" '{username: $u, password: $p}')
+    local response=$(curl -s -X POST "$API_URL/api/admin/login" \
+        -H "Content-Type: application/json" \
+        -d "$body")
+    ADMIN_TOKEN=$(echo "$response" | jq -r '.token // empty')
+
+    if [[ -z "$ADMIN_TOKEN" ]]; then
+        show_error "Login failed: $(echo "$response" | jq -r '.error // "unknown error"')"
+        return 1
+    fi
+    show_success "Logged in as $username ($(echo "$response" | jq -r '.role'))"
+}
+
 # Check API connection
 check_api_connection() {
     local health=$(curl -s "$API_URL/api/health" 2>&1)
@@ -122,7 +156,7 @@ list_study_texts() {
     local exit_code=$?
     
     if [[ $exit_code -eq 0 ]] && echo "$response" | jq -e '.success' > /dev/null 2>&1; then
-        echo "$response" | jq -r '.data[] | "ID: \(.id) | Version: \(.version) | Fonts: \(.font_left)/\(.font_right) | Active: \(.active) | Content: \(.content[0:50])..."'
+        echo "$response" | jq -r '.data[] | "ID: \(.id) | Version: \(.version) | Status: \(.status) | Fonts: \(.font_left)/\(.font_right) | Active: \(.active) | Content: \(.content[0:50])..."'
     else
         show_error "Failed to load study texts"
         if echo "$response" | grep -q "404"; then
@@ -149,25 +183,30 @@ create_study_text() {
     read -p "Font for right panel (default: sans): " font_right
     font_right=${font_right:-sans}
     
-    read -p "Set as active? (y/n): " active_choice
-    active=false
-    if [[ "$active_choice" == "y" || "$active_choice" == "Y" ]]; then
-        active=true
-    fi
+    read -p "Publish and activate now? (y/n): " publish_choice
     
     data=$(jq -n \
         --arg version "$version" \
         --arg content "$content" \
         --arg font_left "$font_left" \
         --arg font_right "$font_right" \
-        --argjson active "$active" \
-        '{version: $version, content: $content, font_left: $font_left, font_right: $font_right, active: $active}')
+        '{version: $version, content: $content, font_left: $font_left, font_right: $font_right}')
     
     response=$(api_post "/api/admin/study-text" "$data")
     
     if echo "$response" | jq -e '.success' > /dev/null 2>&1; then
-        show_success "Study text created!"
+        show_success "Study text created as a draft!"
         echo "$response" | jq '.'
+        if [[ "$publish_choice" == "y" || "$publish_choice" == "Y" ]]; then
+            id=$(echo "$response" | jq -r '.id')
+            response=$(api_post "/api/admin/study-texts/$id/publish" "{}")
+            if echo "$response" | jq -e '.success' > /dev/null 2>&1; then
+                show_success "Study text published!"
+            else
+                show_error "Failed to publish study text"
+                echo "$response"
+            fi
+        fi
     else
         show_error "Failed to create study text"
         echo "$response"
@@ -632,6 +671,10 @@ if ! check_api_connection; then
     fi
 fi
 
+if ! admin_login; then
+    exit 1
+fi
+
 # Start
 show_menu
 
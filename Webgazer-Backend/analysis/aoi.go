@@ -0,0 +1,38 @@
+package analysis
+
+import "math"
+
+// Box is an axis-aligned area of interest in screen pixels
+type Box struct {
+	X      float64 // left edge
+	Y      float64 // top edge
+	Width  float64
+	Height float64
+}
+
+// Contains reports whether (x, y) lies inside the box grown by margin on every side
+func (b Box) Contains(x, y, margin float64) bool {
+	return x >= b.X-margin && x < b.X+b.Width+margin &&
+		y >= b.Y-margin && y < b.Y+b.Height+margin
+}
+
+// centerDistance returns the distance from (x, y) to the center of the box
+func (b Box) centerDistance(x, y float64) float64 {
+	return math.Hypot(x-(b.X+b.Width/2), y-(b.Y+b.Height/2))
+}
+
+// FindBox returns the index of the box containing (x, y), or -1 if there is none.
+// Boxes are grown by margin to absorb tracker noise; if several then overlap the
+// point, the box whose center is closest wins.
+func FindBox(boxes []Box, x, y, margin float64) int {
+	best, bestDistance := -1, math.Inf(1)
+	for i, b := range boxes {
+		if !b.Contains(x, y, margin) {
+			continue
+		}
+		if d := b.centerDistance(x, y); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,39 @@
+package analysis
+
+import "testing"
+
+func TestFindBox(t *testing.T) {
+	// Two adjacent words on one line
+	boxes := []Box{
+		{X: 0, Y: 0, Width: 50, Height: 20},
+		{X: 50, Y: 0, Width: 50, Height: 20},
+	}
+
+	tests := []struct {
+		name   string
+		x, y   float64
+		margin float64
+		want   int
+	}{
+		{"inside the first box", 10, 10, 0, 0},
+		{"inside the second box", 90, 10, 0, 1},
+		{"shared edge belongs to the right box", 50, 10, 0, 1},
+		{"right edge is outside", 100, 10, 0, -1},
+		{"below the line", 10, 30, 0, -1},
+		{"within the margin", -3, 10, 5, 0},
+		{"outside the margin", -6, 10, 5, -1},
+		{"overlap goes to the closer center", 52, 10, 5, 1},
+		{"overlap on the other side", 48, 10, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindBox(boxes, tt.x, tt.y, tt.margin); got != tt.want {
+				t.Errorf("FindBox(%v, %v, %v) = %d, want %d", tt.x, tt.y, tt.margin, got, tt.want)
+			}
+		})
+	}
+
+	if got := FindBox(nil, 0, 0, 0); got != -1 {
+		t.Errorf("FindBox without boxes = %d, want -1", got)
+	}
+}
@@ -0,0 +1,187 @@
+package analysis
+
+import "math"
+
+// BradleyTerryConfig configures a Bradley–Terry fit
+type BradleyTerryConfig struct {
+	// Prior adds this many virtual wins to every ordered pair of items, which keeps
+	// estimates finite for unbeaten or never-compared items and shrinks them
+	// towards equal strength
+	Prior         float64
+	MaxIterations int
+	Tolerance     float64 // stop when no log-strength changes by more than this
+}
+
+// DefaultBradleyTerryConfig returns a weak prior of half a win per ordered pair
+func DefaultBradleyTerryConfig() BradleyTerryConfig {
+	return BradleyTerryConfig{Prior: 0.5, MaxIterations: 10000, Tolerance: 1e-9}
+}
+
+// BradleyTerryResult holds fitted log-strengths, centered to sum to zero
+type BradleyTerryResult struct {
+	Scores     []float64 // log-strength of each item
+	StdErrors  []float64 // standard error of each score
+	Iterations int
+	Converged  bool
+}
+
+// FitBradleyTerry estimates item strengths from a win matrix, where wins[i][j]
+// is how often item i was preferred over item j, using Hunter's MM algorithm.
+// start, if it has one score per item, warm-starts the fit from a previous result.
+func FitBradleyTerry(wins [][]float64, start []float64, cfg BradleyTerryConfig) BradleyTerryResult {
+	n := len(wins)
+	result := BradleyTerryResult{Scores: make([]float64, n), StdErrors: make([]float64, n)}
+	if n == 0 {
+		result.Converged = true
+		return result
+	}
+
+	// Add the prior and precompute total wins and comparisons per pair
+	w := make([][]float64, n)
+	for i := range w {
+		w[i] = make([]float64, n)
+		for j := range w[i] {
+			if i != j {
+				w[i][j] = wins[i][j] + cfg.Prior
+			}
+		}
+	}
+	totalWins := make([]float64, n)
+	games := make([][]float64, n)
+	for i := range games {
+		games[i] = make([]float64, n)
+		for j := range games[i] {
+			games[i][j] = w[i][j] + w[j][i]
+			totalWins[i] += w[i][j]
+		}
+	}
+
+	strength := make([]float64, n)
+	for i := range strength {
+		strength[i] = 1
+		if len(start) == n {
+			strength[i] = math.Exp(start[i])
+		}
+	}
+
+	for result.Iterations < cfg.MaxIterations {
+		result.Iterations++
+		next := make([]float64, n)
+		for i := range next {
+			var denominator float64
+			for j := range next {
+				if i != j && games[i][j] > 0 {
+					denominator += games[i][j] / (strength[i] + strength[j])
+				}
+			}
+			if denominator == 0 || totalWins[i] == 0 {
+				next[i] = strength[i]
+				continue
+			}
+			next[i] = totalWins[i] / denominator
+		}
+		normalizeStrengths(next)
+
+		var maxChange float64
+		for i := range next {
+			maxChange = math.Max(maxChange, math.Abs(math.Log(next[i])-math.Log(strength[i])))
+		}
+		strength = next
+		if maxChange < cfg.Tolerance {
+			result.Converged = true
+			break
+		}
+	}
+
+	for i, s := range strength {
+		result.Scores[i] = math.Log(s)
+	}
+
+	// Standard errors from the inverse Fisher information under the sum-to-zero
+	// constraint: the information matrix is a weighted graph Laplacian, so its
+	// pseudo-inverse is (L + J/n)^-1 - J/n
+	info := make([][]float64, n)
+	for i := range info {
+		info[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j || games[i][j] == 0 {
+				continue
+			}
+			p := strength[i] / (strength[i] + strength[j])
+			weight := games[i][j] * p * (1 - p)
+			info[i][j] -= weight
+			info[i][i] += weight
+		}
+	}
+	for i := range info {
+		for j := range info[i] {
+			info[i][j] += 1 / float64(n)
+		}
+	}
+	if inverse, ok := invertMatrix(info); ok {
+		for i := range result.StdErrors {
+			if v := inverse[i][i] - 1/float64(n); v > 0 {
+				result.StdErrors[i] = math.Sqrt(v)
+			}
+		}
+	}
+	return result
+}
+
+// normalizeStrengths scales strengths so their geometric mean is 1
+func normalizeStrengths(strength []float64) {
+	var logSum float64
+	for _, s := range strength {
+		logSum += math.Log(s)
+	}
+	scale := math.Exp(logSum / float64(len(strength)))
+	for i := range strength {
+		strength[i] /= scale
+	}
+}
+
+// invertMatrix inverts a square matrix by Gauss–Jordan elimination with partial pivoting
+func invertMatrix(m [][]float64) ([][]float64, bool) {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, 2*n)
+		copy(a[i], m[i])
+		a[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+
+		p := a[col][col]
+		for j := range a[col] {
+			a[col][j] /= p
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col]
+			for j := range a[row] {
+				a[row][j] -= factor * a[col][j]
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range inverse {
+		inverse[i] = a[i][n:]
+	}
+	return inverse, true
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitBradleyTerry(t *testing.T) {
+	noPrior := DefaultBradleyTerryConfig()
+	noPrior.Prior = 0
+
+	tests := []struct {
+		name       string
+		wins       [][]float64
+		cfg        BradleyTerryConfig
+		wantScores []float64
+		wantErrors []float64 // checked when set
+	}{
+		{
+			name: "no items",
+			cfg:  noPrior,
+		},
+		{
+			name:       "even split",
+			wins:       [][]float64{{0, 5}, {5, 0}},
+			cfg:        noPrior,
+			wantScores: []float64{0, 0},
+		},
+		{
+			// p = 0.9, so the log-odds are ln 9 and each score is half of that;
+			// the variance of the difference is 1/(10 p (1-p)), split over both scores
+			name:       "nine to one",
+			wins:       [][]float64{{0, 9}, {1, 0}},
+			cfg:        noPrior,
+			wantScores: []float64{math.Log(9) / 2, -math.Log(9) / 2},
+			wantErrors: []float64{math.Sqrt(1 / 3.6), math.Sqrt(1 / 3.6)},
+		},
+		{
+			name:       "prior keeps an unbeaten item finite",
+			wins:       [][]float64{{0, 3}, {0, 0}},
+			cfg:        DefaultBradleyTerryConfig(),
+			wantScores: []float64{math.Log(7) / 2, -math.Log(7) / 2},
+		},
+		{
+			// Item 2 was never compared, so only the prior places it in the middle
+			name:       "never compared",
+			wins:       [][]float64{{0, 4, 0}, {4, 0, 0}, {0, 0, 0}},
+			cfg:        DefaultBradleyTerryConfig(),
+			wantScores: []float64{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FitBradleyTerry(tt.wins, nil, tt.cfg)
+			if !result.Converged {
+				t.Fatalf("did not converge in %d iterations", result.Iterations)
+			}
+			if len(result.Scores) != len(tt.wantScores) {
+				t.Fatalf("scores = %v, want %v", result.Scores, tt.wantScores)
+			}
+			for i, want := range tt.wantScores {
+				if math.Abs(result.Scores[i]-want) > 1e-6 {
+					t.Errorf("scores = %v, want %v", result.Scores, tt.wantScores)
+					break
+				}
+			}
+			for i, want := range tt.wantErrors {
+				if math.Abs(result.StdErrors[i]-want) > 1e-6 {
+					t.Errorf("standard errors = %v, want %v", result.StdErrors, tt.wantErrors)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFitBradleyTerryWarmStart(t *testing.T) {
+	wins := [][]float64{{0, 6, 8}, {4, 0, 7}, {2, 3, 0}}
+	cfg := DefaultBradleyTerryConfig()
+	cold := FitBradleyTerry(wins, nil, cfg)
+	warm := FitBradleyTerry(wins, cold.Scores, cfg)
+
+	if !(cold.Scores[0] > cold.Scores[1] && cold.Scores[1] > cold.Scores[2]) {
+		t.Errorf("scores %v are not ordered by wins", cold.Scores)
+	}
+	if warm.Iterations >= cold.Iterations {
+		t.Errorf("warm start took %d iterations, cold start %d", warm.Iterations, cold.Iterations)
+	}
+	for i := range cold.Scores {
+		if math.Abs(warm.Scores[i]-cold.Scores[i]) > 1e-6 {
+			t.Errorf("warm start scores %v, cold start %v", warm.Scores, cold.Scores)
+			break
+		}
+	}
+}
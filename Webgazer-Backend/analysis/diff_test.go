@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []DiffChunk
+	}{
+		{name: "both empty", from: "", to: "", want: nil},
+		{
+			name: "unchanged",
+			from: "the quick fox",
+			to:   "the  quick\nfox ",
+			want: []DiffChunk{{DiffEqual, "the quick fox"}},
+		},
+		{
+			name: "insertion",
+			from: "the quick fox",
+			to:   "the quick brown fox",
+			want: []DiffChunk{{DiffEqual, "the quick"}, {DiffInsert, "brown"}, {DiffEqual, "fox"}},
+		},
+		{
+			name: "deletion",
+			from: "the quick brown fox",
+			to:   "the fox",
+			want: []DiffChunk{{DiffEqual, "the"}, {DiffDelete, "quick brown"}, {DiffEqual, "fox"}},
+		},
+		{
+			name: "replacement deletes first",
+			from: "a red fox",
+			to:   "a grey fox",
+			want: []DiffChunk{{DiffEqual, "a"}, {DiffDelete, "red"}, {DiffInsert, "grey"}, {DiffEqual, "fox"}},
+		},
+		{
+			name: "new text",
+			from: "",
+			to:   "hello world",
+			want: []DiffChunk{{DiffInsert, "hello world"}},
+		},
+		{
+			name: "removed text",
+			from: "hello world",
+			to:   "",
+			want: []DiffChunk{{DiffDelete, "hello world"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffWords(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffWords(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
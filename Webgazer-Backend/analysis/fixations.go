@@ -0,0 +1,314 @@
+// Package analysis contains eye-movement analysis algorithms that operate on
+// raw gaze samples, independent of how the samples are stored.
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample is a single raw gaze sample in screen pixels
+type Sample struct {
+	X         float64
+	Y         float64
+	Panel     string
+	Phase     string
+	Timestamp time.Time
+}
+
+// Fixation is a period where gaze stayed on roughly the same spot
+type Fixation struct {
+	Start       time.Time
+	End         time.Time
+	Duration    time.Duration
+	X           float64 // centroid
+	Y           float64 // centroid
+	Panel       string  // most common panel among the fixation's samples
+	SampleCount int
+}
+
+// Saccade is the rapid movement between two consecutive fixations
+type Saccade struct {
+	Start     time.Time
+	End       time.Time
+	Duration  time.Duration
+	StartX    float64
+	StartY    float64
+	EndX      float64
+	EndY      float64
+	Amplitude float64 // pixels between the two fixation centroids
+	Velocity  float64 // mean velocity in pixels per second
+	FromPanel string
+	ToPanel   string
+}
+
+// IVTConfig configures velocity-threshold (I-VT) fixation detection
+type IVTConfig struct {
+	VelocityThreshold   float64       // pixels per second; slower samples belong to fixations
+	MinFixationDuration time.Duration // shorter fixation candidates are discarded
+	MaxGap              time.Duration // longer pauses between samples are tracking loss; 0 for no limit
+}
+
+// IDTConfig configures dispersion-threshold (I-DT) fixation detection
+type IDTConfig struct {
+	DispersionThreshold float64       // max (maxX-minX)+(maxY-minY) in pixels within a fixation
+	MinFixationDuration time.Duration // minimum window duration for a fixation
+	MaxGap              time.Duration // longer pauses between samples are tracking loss; 0 for no limit
+}
+
+// defaultMaxGap is a few sample intervals at webcam rates
+const defaultMaxGap = 300 * time.Millisecond
+
+// DefaultIVTConfig returns thresholds suited to webcam gaze data sampled at roughly 10-30 Hz
+func DefaultIVTConfig() IVTConfig {
+	return IVTConfig{VelocityThreshold: 1000, MinFixationDuration: 100 * time.Millisecond, MaxGap: defaultMaxGap}
+}
+
+// DefaultIDTConfig returns thresholds suited to webcam gaze data sampled at roughly 10-30 Hz
+func DefaultIDTConfig() IDTConfig {
+	return IDTConfig{DispersionThreshold: 100, MinFixationDuration: 100 * time.Millisecond, MaxGap: defaultMaxGap}
+}
+
+// SortSamples orders samples by timestamp, which both detectors require
+func SortSamples(samples []Sample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+}
+
+// segment is a run of consecutive samples without tracking loss or a change
+// of phase or panel, within which samples can be grouped into fixations
+type segment struct {
+	samples []Sample
+	// joined is set when the segment directly follows the previous one on
+	// another panel, so the eyes moved from one to the other in a saccade
+	joined bool
+}
+
+// splitSamples cuts samples into segments wherever the time between two
+// samples exceeds maxGap (if positive) or the phase or panel changes
+func splitSamples(samples []Sample, maxGap time.Duration) []segment {
+	if len(samples) == 0 {
+		return nil
+	}
+	segments := []segment{{}}
+	start := 0
+	for i := 1; i < len(samples); i++ {
+		prev, next := samples[i-1], samples[i]
+		gap := maxGap > 0 && next.Timestamp.Sub(prev.Timestamp) > maxGap
+		if gap || next.Phase != prev.Phase || next.Panel != prev.Panel {
+			segments[len(segments)-1].samples = samples[start:i]
+			segments = append(segments, segment{joined: !gap && next.Phase == prev.Phase})
+			start = i
+		}
+	}
+	segments[len(segments)-1].samples = samples[start:]
+	return segments
+}
+
+// detectSegments runs a fixation detector over each segment of samples.
+// Saccades connect consecutive fixations, but not across tracking loss or
+// a phase change, where the eye movement in between wasn't observed.
+func detectSegments(samples []Sample, maxGap time.Duration, detect func([]Sample) []Fixation) ([]Fixation, []Saccade) {
+	var fixations []Fixation
+	var saccades []Saccade
+	var connected []Fixation
+	for _, seg := range splitSamples(samples, maxGap) {
+		if !seg.joined {
+			saccades = append(saccades, saccadesBetween(connected)...)
+			connected = nil
+		}
+		found := detect(seg.samples)
+		fixations = append(fixations, found...)
+		connected = append(connected, found...)
+	}
+	saccades = append(saccades, saccadesBetween(connected)...)
+	return fixations, saccades
+}
+
+// DetectIVT labels each sample as fixation or saccade by its point-to-point
+// velocity and merges consecutive fixation samples into fixations.
+// Samples must be sorted by timestamp.
+func DetectIVT(samples []Sample, cfg IVTConfig) ([]Fixation, []Saccade) {
+	return detectSegments(samples, cfg.MaxGap, func(samples []Sample) []Fixation {
+		return ivtFixations(samples, cfg)
+	})
+}
+
+func ivtFixations(samples []Sample, cfg IVTConfig) []Fixation {
+	if len(samples) < 2 {
+		return nil
+	}
+
+	// A sample is part of a fixation if the movement into it was slow enough;
+	// the first sample takes the label of the second
+	slow := make([]bool, len(samples))
+	for i := 1; i < len(samples); i++ {
+		slow[i] = velocity(samples[i-1], samples[i]) < cfg.VelocityThreshold
+	}
+	slow[0] = slow[1]
+
+	var fixations []Fixation
+	start := -1
+	for i := 0; i <= len(samples); i++ {
+		if i < len(samples) && slow[i] {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			group := samples[start:i]
+			if group[len(group)-1].Timestamp.Sub(group[0].Timestamp) >= cfg.MinFixationDuration {
+				fixations = append(fixations, newFixation(group))
+			}
+			start = -1
+		}
+	}
+	return fixations
+}
+
+// DetectIDT grows a window over the samples while their dispersion stays under
+// the threshold; windows lasting at least the minimum duration become fixations.
+// Samples must be sorted by timestamp.
+func DetectIDT(samples []Sample, cfg IDTConfig) ([]Fixation, []Saccade) {
+	return detectSegments(samples, cfg.MaxGap, func(samples []Sample) []Fixation {
+		return idtFixations(samples, cfg)
+	})
+}
+
+func idtFixations(samples []Sample, cfg IDTConfig) []Fixation {
+	var fixations []Fixation
+
+	i := 0
+	for i < len(samples) {
+		// Initial window must cover the minimum fixation duration
+		j := i
+		for j < len(samples) && samples[j].Timestamp.Sub(samples[i].Timestamp) < cfg.MinFixationDuration {
+			j++
+		}
+		if j >= len(samples) {
+			break
+		}
+
+		box := newBounds(samples[i : j+1])
+		if box.dispersion() > cfg.DispersionThreshold {
+			i++
+			continue
+		}
+
+		// Extend the window until dispersion exceeds the threshold
+		for j+1 < len(samples) {
+			next := box
+			next.add(samples[j+1])
+			if next.dispersion() > cfg.DispersionThreshold {
+				break
+			}
+			box = next
+			j++
+		}
+
+		fixations = append(fixations, newFixation(samples[i:j+1]))
+		i = j + 1
+	}
+	return fixations
+}
+
+// velocity returns the speed in pixels per second between two samples
+func velocity(a, b Sample) float64 {
+	distance := math.Hypot(b.X-a.X, b.Y-a.Y)
+	dt := b.Timestamp.Sub(a.Timestamp).Seconds()
+	if dt <= 0 {
+		if distance == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return distance / dt
+}
+
+func newFixation(group []Sample) Fixation {
+	var sumX, sumY float64
+	panels := make(map[string]int)
+	for _, s := range group {
+		sumX += s.X
+		sumY += s.Y
+		panels[s.Panel]++
+	}
+
+	start := group[0].Timestamp
+	end := group[len(group)-1].Timestamp
+	return Fixation{
+		Start:       start,
+		End:         end,
+		Duration:    end.Sub(start),
+		X:           sumX / float64(len(group)),
+		Y:           sumY / float64(len(group)),
+		Panel:       majorityPanel(panels),
+		SampleCount: len(group),
+	}
+}
+
+// majorityPanel picks the most frequent panel, breaking ties alphabetically
+// so results are deterministic
+func majorityPanel(counts map[string]int) string {
+	best, bestCount := "", -1
+	for panel, count := range counts {
+		if count > bestCount || (count == bestCount && panel < best) {
+			best, bestCount = panel, count
+		}
+	}
+	return best
+}
+
+func saccadesBetween(fixations []Fixation) []Saccade {
+	var saccades []Saccade
+	for i := 1; i < len(fixations); i++ {
+		from, to := fixations[i-1], fixations[i]
+		amplitude := math.Hypot(to.X-from.X, to.Y-from.Y)
+		duration := to.Start.Sub(from.End)
+
+		saccade := Saccade{
+			Start:     from.End,
+			End:       to.Start,
+			Duration:  duration,
+			StartX:    from.X,
+			StartY:    from.Y,
+			EndX:      to.X,
+			EndY:      to.Y,
+			Amplitude: amplitude,
+			FromPanel: from.Panel,
+			ToPanel:   to.Panel,
+		}
+		if duration > 0 {
+			saccade.Velocity = amplitude / duration.Seconds()
+		}
+		saccades = append(saccades, saccade)
+	}
+	return saccades
+}
+
+// bounds is the bounding box of a window of samples
+type bounds struct {
+	minX, maxX, minY, maxY float64
+}
+
+func newBounds(samples []Sample) bounds {
+	b := bounds{minX: math.Inf(1), maxX: math.Inf(-1), minY: math.Inf(1), maxY: math.Inf(-1)}
+	for _, s := range samples {
+		b.add(s)
+	}
+	return b
+}
+
+func (b *bounds) add(s Sample) {
+	b.minX = math.Min(b.minX, s.X)
+	b.maxX = math.Max(b.maxX, s.X)
+	b.minY = math.Min(b.minY, s.Y)
+	b.maxY = math.Max(b.maxY, s.Y)
+}
+
+func (b bounds) dispersion() float64 {
+	return (b.maxX - b.minX) + (b.maxY - b.minY)
+}
@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// steady returns count samples 50 ms apart at (x, y), starting at ms
+func steady(x, y float64, panel, phase string, ms, count int) []Sample {
+	samples := make([]Sample, count)
+	for i := range samples {
+		samples[i] = Sample{X: x, Y: y, Panel: panel, Phase: phase, Timestamp: testStart.Add(time.Duration(ms+50*i) * time.Millisecond)}
+	}
+	return samples
+}
+
+func concat(groups ...[]Sample) []Sample {
+	var samples []Sample
+	for _, g := range groups {
+		samples = append(samples, g...)
+	}
+	return samples
+}
+
+func TestDetectFixations(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   []Sample
+		maxGap    time.Duration
+		fixations []string // panel of each fixation
+		saccades  []string // "from>to" panels of each saccade
+	}{
+		{
+			name:      "one steady fixation",
+			samples:   steady(100, 100, "left", "middle", 0, 20),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left"},
+		},
+		{
+			name:      "too short",
+			samples:   steady(100, 100, "left", "middle", 0, 2),
+			maxGap:    300 * time.Millisecond,
+			fixations: nil,
+		},
+		{
+			name:      "two fixations and a saccade",
+			samples:   concat(steady(100, 100, "left", "middle", 0, 10), steady(600, 100, "left", "middle", 500, 10)),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left", "left"},
+			saccades:  []string{"left>left"},
+		},
+		{
+			name:      "tracking loss splits a fixation",
+			samples:   concat(steady(100, 100, "left", "middle", 0, 10), steady(100, 100, "left", "middle", 1000, 10)),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left", "left"},
+		},
+		{
+			name:      "no gap limit",
+			samples:   concat(steady(100, 100, "left", "middle", 0, 10), steady(100, 100, "left", "middle", 1000, 10)),
+			fixations: []string{"left"},
+		},
+		{
+			name:      "no saccade across tracking loss",
+			samples:   concat(steady(100, 100, "left", "middle", 0, 10), steady(600, 100, "left", "middle", 1000, 10)),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left", "left"},
+		},
+		{
+			name:      "panel change",
+			samples:   concat(steady(100, 100, "left", "middle", 0, 10), steady(100, 100, "right", "middle", 500, 10)),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left", "right"},
+			saccades:  []string{"left>right"},
+		},
+		{
+			name:      "phase change",
+			samples:   concat(steady(100, 100, "left", "start", 0, 10), steady(100, 100, "left", "middle", 500, 10)),
+			maxGap:    300 * time.Millisecond,
+			fixations: []string{"left", "left"},
+		},
+	}
+
+	detectors := map[string]func([]Sample, time.Duration) ([]Fixation, []Saccade){
+		"ivt": func(samples []Sample, maxGap time.Duration) ([]Fixation, []Saccade) {
+			cfg := DefaultIVTConfig()
+			cfg.MaxGap = maxGap
+			return DetectIVT(samples, cfg)
+		},
+		"idt": func(samples []Sample, maxGap time.Duration) ([]Fixation, []Saccade) {
+			cfg := DefaultIDTConfig()
+			cfg.MaxGap = maxGap
+			return DetectIDT(samples, cfg)
+		},
+	}
+	for algorithm, detect := range detectors {
+		for _, tt := range tests {
+			t.Run(algorithm+"/"+tt.name, func(t *testing.T) {
+				fixations, saccades := detect(tt.samples, tt.maxGap)
+				var gotFixations, gotSaccades []string
+				for _, f := range fixations {
+					gotFixations = append(gotFixations, f.Panel)
+				}
+				for _, s := range saccades {
+					gotSaccades = append(gotSaccades, s.FromPanel+">"+s.ToPanel)
+				}
+				if fmt.Sprint(gotFixations) != fmt.Sprint(tt.fixations) {
+					t.Errorf("fixations on %v, want %v", gotFixations, tt.fixations)
+				}
+				if fmt.Sprint(gotSaccades) != fmt.Sprint(tt.saccades) {
+					t.Errorf("saccades %v, want %v", gotSaccades, tt.saccades)
+				}
+			})
+		}
+	}
+}
+
+func TestFixationMeasures(t *testing.T) {
+	samples := concat(steady(100, 100, "left", "middle", 0, 10), steady(400, 500, "left", "middle", 500, 10))
+	fixations, saccades := DetectIDT(samples, DefaultIDTConfig())
+	if len(fixations) != 2 || len(saccades) != 1 {
+		t.Fatalf("got %d fixations and %d saccades, want 2 and 1", len(fixations), len(saccades))
+	}
+
+	first := fixations[0]
+	if first.SampleCount != 10 || first.Duration != 450*time.Millisecond || first.X != 100 || first.Y != 100 {
+		t.Errorf("first fixation = %+v", first)
+	}
+	saccade := saccades[0]
+	if saccade.Amplitude != 500 || saccade.Duration != 50*time.Millisecond || saccade.Velocity != 10000 {
+		t.Errorf("saccade = %+v", saccade)
+	}
+}
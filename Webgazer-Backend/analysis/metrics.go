@@ -0,0 +1,65 @@
+package analysis
+
+import "time"
+
+// WordFixation is a fixation that landed on a word of the text being read
+type WordFixation struct {
+	WordIndex int // position of the word in the passage
+	Start     time.Time
+	Duration  time.Duration
+}
+
+// ReadingMetrics describes how one passage was read in one panel
+type ReadingMetrics struct {
+	Words          int
+	ReadingTime    time.Duration
+	WordsPerMinute float64
+	TotalDwell     time.Duration // sum of all fixation durations on words
+	FirstPass      time.Duration // fixation time before the reader moved past each word for the first time
+	Regressions    int           // fixations that moved back to an earlier word
+	Transitions    int           // moves between consecutive word fixations
+	RegressionRate float64       // Regressions / Transitions
+	RereadingRatio float64       // share of TotalDwell spent after the first pass
+}
+
+// ComputeReadingMetrics derives reading measures from the word fixations of a
+// single reading, which must be sorted by start time. When readingTime is not
+// known it is taken as the span from the first to the last fixation.
+func ComputeReadingMetrics(fixations []WordFixation, words int, readingTime time.Duration) ReadingMetrics {
+	m := ReadingMetrics{Words: words, ReadingTime: readingTime}
+	if len(fixations) == 0 {
+		return m
+	}
+
+	if m.ReadingTime <= 0 {
+		last := fixations[len(fixations)-1]
+		m.ReadingTime = last.Start.Add(last.Duration).Sub(fixations[0].Start)
+	}
+	if m.ReadingTime > 0 {
+		m.WordsPerMinute = float64(words) / m.ReadingTime.Minutes()
+	}
+
+	// A fixation is first-pass while it is on the furthest word reached so far
+	furthest := -1
+	for i, f := range fixations {
+		m.TotalDwell += f.Duration
+		if f.WordIndex >= furthest {
+			furthest = f.WordIndex
+			m.FirstPass += f.Duration
+		}
+		if i > 0 {
+			m.Transitions++
+			if f.WordIndex < fixations[i-1].WordIndex {
+				m.Regressions++
+			}
+		}
+	}
+
+	if m.Transitions > 0 {
+		m.RegressionRate = float64(m.Regressions) / float64(m.Transitions)
+	}
+	if m.TotalDwell > 0 {
+		m.RereadingRatio = float64(m.TotalDwell-m.FirstPass) / float64(m.TotalDwell)
+	}
+	return m
+}
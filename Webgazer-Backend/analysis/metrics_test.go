@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestComputeReadingMetrics(t *testing.T) {
+	// fixate returns 200 ms fixations on the given words, 250 ms apart
+	fixate := func(words ...int) []WordFixation {
+		fixations := make([]WordFixation, len(words))
+		for i, word := range words {
+			fixations[i] = WordFixation{WordIndex: word, Start: testStart.Add(time.Duration(250*i) * time.Millisecond), Duration: 200 * time.Millisecond}
+		}
+		return fixations
+	}
+
+	tests := []struct {
+		name        string
+		fixations   []WordFixation
+		words       int
+		readingTime time.Duration
+		want        ReadingMetrics
+	}{
+		{
+			name:        "no fixations",
+			words:       10,
+			readingTime: time.Minute,
+			want:        ReadingMetrics{Words: 10, ReadingTime: time.Minute},
+		},
+		{
+			// Reading time falls back to the 950 ms from the first fixation's start to the last one's end
+			name:      "straight through",
+			fixations: fixate(0, 1, 2, 3),
+			words:     4,
+			want: ReadingMetrics{
+				Words: 4, ReadingTime: 950 * time.Millisecond, WordsPerMinute: 4 / (0.95 / 60),
+				TotalDwell: 800 * time.Millisecond, FirstPass: 800 * time.Millisecond, Transitions: 3,
+			},
+		},
+		{
+			name:        "one regression",
+			fixations:   fixate(0, 1, 2, 1, 3),
+			words:       100,
+			readingTime: time.Minute,
+			want: ReadingMetrics{
+				Words: 100, ReadingTime: time.Minute, WordsPerMinute: 100,
+				TotalDwell: time.Second, FirstPass: 800 * time.Millisecond,
+				Regressions: 1, Transitions: 4, RegressionRate: 0.25, RereadingRatio: 0.2,
+			},
+		},
+		{
+			name:        "refixating a word is first pass",
+			fixations:   fixate(0, 0, 1),
+			words:       2,
+			readingTime: 30 * time.Second,
+			want: ReadingMetrics{
+				Words: 2, ReadingTime: 30 * time.Second, WordsPerMinute: 4,
+				TotalDwell: 600 * time.Millisecond, FirstPass: 600 * time.Millisecond, Transitions: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeReadingMetrics(tt.fixations, tt.words, tt.readingTime)
+			if !near(got.WordsPerMinute, tt.want.WordsPerMinute, 1e-9) ||
+				!near(got.RegressionRate, tt.want.RegressionRate, 1e-9) ||
+				!near(got.RereadingRatio, tt.want.RereadingRatio, 1e-9) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			got.WordsPerMinute, got.RegressionRate, got.RereadingRatio = tt.want.WordsPerMinute, tt.want.RegressionRate, tt.want.RereadingRatio
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Quality check names, in the order they are reported
+const (
+	CheckCalibration = "calibration"
+	CheckSampleRate  = "sample_rate"
+	CheckOffscreen   = "offscreen"
+	CheckReadingTime = "reading_time"
+	CheckQuiz        = "quiz"
+)
+
+// Quality check outcomes
+const (
+	QualityPass    = "pass"
+	QualityFail    = "fail"
+	QualitySkipped = "skipped" // not enough data to judge, does not affect inclusion
+)
+
+// QualityThresholds are the limits a session must stay within to be included
+type QualityThresholds struct {
+	MinCalibrationAccuracy float64       // percent, from the latest accuracy measurement
+	MinSampleRate          float64       // Hz, from the median interval between gaze samples
+	MaxOffscreenShare      float64       // share of gaze samples outside the screen
+	MinReadingTime         time.Duration // shorter panel readings are implausible
+	MaxReadingTime         time.Duration // longer panel readings suggest the participant left
+	MinQuizResponses       int           // fewer graded answers skip the quiz check
+}
+
+// DefaultQualityThresholds returns limits suited to webcam gaze data sampled at roughly 10-30 Hz
+func DefaultQualityThresholds() QualityThresholds {
+	return QualityThresholds{
+		MinCalibrationAccuracy: 50,
+		MinSampleRate:          5,
+		MaxOffscreenShare:      0.25,
+		MinReadingTime:         5 * time.Second,
+		MaxReadingTime:         10 * time.Minute,
+		MinQuizResponses:       3,
+	}
+}
+
+// SessionQualityData is everything the quality checks look at for one session
+type SessionQualityData struct {
+	CalibrationAccuracy *float64 // latest accuracy measurement in percent, nil if none was taken
+	CalibrationPassed   bool     // whether the client reported that measurement as passed
+	Samples             []Sample
+	ScreenWidth         float64 // 0 when unknown; only negative coordinates count as off-screen then
+	ScreenHeight        float64
+	ReadingTimes        []time.Duration // one per panel reading
+	QuizCorrect         int
+	QuizTotal           int     // graded answers
+	QuizChance          float64 // expected accuracy from guessing, e.g. 0.25 for four choices
+}
+
+// QualityCheck is the outcome of one check
+type QualityCheck struct {
+	Name   string
+	Status string
+	Value  float64 // measured value, in the unit of the matching threshold
+	Limit  float64 // threshold the value was compared against
+	Reason string  // why the check failed or was skipped
+}
+
+// QualityReport scores a session and decides whether it should be included
+type QualityReport struct {
+	Checks   []QualityCheck
+	Score    float64 // share of applicable checks that passed, 1 when none apply
+	Included bool
+	Reasons  []string // one per failed check
+}
+
+// EvaluateQuality runs every check against a session's data. A session is
+// included only if no check fails; missing calibration, gaze or reading time
+// data counts as a failure, while too few quiz answers only skips that check.
+func EvaluateQuality(data SessionQualityData, t QualityThresholds) QualityReport {
+	checks := []QualityCheck{
+		checkCalibration(data, t),
+		checkSampleRate(data, t),
+		checkOffscreen(data, t),
+		checkReadingTimes(data, t),
+		checkQuiz(data, t),
+	}
+
+	report := QualityReport{Checks: checks, Score: 1, Included: true}
+	var applicable, passed int
+	for _, check := range checks {
+		switch check.Status {
+		case QualityPass:
+			applicable++
+			passed++
+		case QualityFail:
+			applicable++
+			report.Included = false
+			report.Reasons = append(report.Reasons, check.Reason)
+		}
+	}
+	if applicable > 0 {
+		report.Score = float64(passed) / float64(applicable)
+	}
+	return report
+}
+
+func checkCalibration(data SessionQualityData, t QualityThresholds) QualityCheck {
+	check := QualityCheck{Name: CheckCalibration, Limit: t.MinCalibrationAccuracy}
+	switch {
+	case data.CalibrationAccuracy == nil:
+		check.Status = QualityFail
+		check.Reason = "no calibration accuracy measurement"
+	case *data.CalibrationAccuracy < t.MinCalibrationAccuracy:
+		check.Value = *data.CalibrationAccuracy
+		check.Status = QualityFail
+		check.Reason = fmt.Sprintf("calibration accuracy %.1f%% is below %.1f%%", check.Value, t.MinCalibrationAccuracy)
+	case !data.CalibrationPassed:
+		check.Value = *data.CalibrationAccuracy
+		check.Status = QualityFail
+		check.Reason = "calibration accuracy check was not passed"
+	default:
+		check.Value = *data.CalibrationAccuracy
+		check.Status = QualityPass
+	}
+	return check
+}
+
+// checkSampleRate uses the median interval between samples, so pauses between
+// passages (when no gaze data is sent) do not lower the rate
+func checkSampleRate(data SessionQualityData, t QualityThresholds) QualityCheck {
+	check := QualityCheck{Name: CheckSampleRate, Limit: t.MinSampleRate}
+	intervals := make([]float64, 0, len(data.Samples))
+	for i := 1; i < len(data.Samples); i++ {
+		if dt := data.Samples[i].Timestamp.Sub(data.Samples[i-1].Timestamp); dt > 0 {
+			intervals = append(intervals, dt.Seconds())
+		}
+	}
+	if len(intervals) == 0 {
+		check.Status = QualityFail
+		check.Reason = "no gaze data"
+		return check
+	}
+
+	sort.Float64s(intervals)
+	median := intervals[len(intervals)/2]
+	if len(intervals)%2 == 0 {
+		median = (intervals[len(intervals)/2-1] + median) / 2
+	}
+	check.Value = 1 / median
+	check.Status = QualityPass
+	if check.Value < t.MinSampleRate {
+		check.Status = QualityFail
+		check.Reason = fmt.Sprintf("gaze sample rate %.1f Hz is below %.1f Hz", check.Value, t.MinSampleRate)
+	}
+	return check
+}
+
+func checkOffscreen(data SessionQualityData, t QualityThresholds) QualityCheck {
+	check := QualityCheck{Name: CheckOffscreen, Limit: t.MaxOffscreenShare}
+	if len(data.Samples) == 0 {
+		check.Status = QualitySkipped
+		check.Reason = "no gaze data"
+		return check
+	}
+
+	offscreen := 0
+	for _, s := range data.Samples {
+		if s.X < 0 || s.Y < 0 ||
+			(data.ScreenWidth > 0 && s.X > data.ScreenWidth) ||
+			(data.ScreenHeight > 0 && s.Y > data.ScreenHeight) {
+			offscreen++
+		}
+	}
+	check.Value = float64(offscreen) / float64(len(data.Samples))
+	check.Status = QualityPass
+	if check.Value > t.MaxOffscreenShare {
+		check.Status = QualityFail
+		check.Reason = fmt.Sprintf("%.0f%% of gaze samples are off-screen (limit %.0f%%)", check.Value*100, t.MaxOffscreenShare*100)
+	}
+	return check
+}
+
+// checkReadingTimes reports the reading time furthest outside the plausible
+// range, or the shortest one when all are plausible, in milliseconds
+func checkReadingTimes(data SessionQualityData, t QualityThresholds) QualityCheck {
+	check := QualityCheck{Name: CheckReadingTime}
+	if len(data.ReadingTimes) == 0 {
+		check.Status = QualityFail
+		check.Reason = "no reading times recorded"
+		return check
+	}
+
+	shortest, longest := data.ReadingTimes[0], data.ReadingTimes[0]
+	for _, d := range data.ReadingTimes[1:] {
+		if d < shortest {
+			shortest = d
+		}
+		if d > longest {
+			longest = d
+		}
+	}
+
+	check.Status = QualityPass
+	check.Value = float64(shortest.Milliseconds())
+	check.Limit = float64(t.MinReadingTime.Milliseconds())
+	switch {
+	case shortest < t.MinReadingTime:
+		check.Status = QualityFail
+		check.Reason = fmt.Sprintf("reading time of %s is shorter than %s", shortest.Round(time.Millisecond), t.MinReadingTime)
+	case t.MaxReadingTime > 0 && longest > t.MaxReadingTime:
+		check.Status = QualityFail
+		check.Value = float64(longest.Milliseconds())
+		check.Limit = float64(t.MaxReadingTime.Milliseconds())
+		check.Reason = fmt.Sprintf("reading time of %s is longer than %s", longest.Round(time.Second), t.MaxReadingTime)
+	}
+	return check
+}
+
+func checkQuiz(data SessionQualityData, t QualityThresholds) QualityCheck {
+	check := QualityCheck{Name: CheckQuiz, Limit: data.QuizChance}
+	if data.QuizTotal == 0 || data.QuizTotal < t.MinQuizResponses {
+		check.Status = QualitySkipped
+		check.Reason = fmt.Sprintf("%d graded quiz answers, need %d", data.QuizTotal, t.MinQuizResponses)
+		return check
+	}
+
+	check.Value = float64(data.QuizCorrect) / float64(data.QuizTotal)
+	check.Status = QualityPass
+	if check.Value < data.QuizChance {
+		check.Status = QualityFail
+		check.Reason = fmt.Sprintf("quiz accuracy %.0f%% is below chance (%.0f%%)", check.Value*100, data.QuizChance*100)
+	}
+	return check
+}
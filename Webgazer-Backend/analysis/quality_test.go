@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvaluateQuality(t *testing.T) {
+	accuracy := func(percent float64) *float64 { return &percent }
+	// 10 Hz gaze samples, the first offscreen of them off the screen
+	gaze := func(count, offscreen int, interval time.Duration) []Sample {
+		samples := make([]Sample, count)
+		for i := range samples {
+			samples[i] = Sample{X: 500, Y: 400, Timestamp: testStart.Add(time.Duration(i) * interval)}
+			if i < offscreen {
+				samples[i].X = -10
+			}
+		}
+		return samples
+	}
+	good := func() SessionQualityData {
+		return SessionQualityData{
+			CalibrationAccuracy: accuracy(80),
+			CalibrationPassed:   true,
+			Samples:             gaze(100, 0, 100*time.Millisecond),
+			ScreenWidth:         1000,
+			ScreenHeight:        800,
+			ReadingTimes:        []time.Duration{30 * time.Second, 40 * time.Second},
+			QuizCorrect:         4,
+			QuizTotal:           5,
+			QuizChance:          0.25,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		change    func(*SessionQualityData)
+		failed    []string
+		skipped   []string
+		wantScore float64
+	}{
+		{name: "good session", wantScore: 1},
+		{
+			name:      "no calibration",
+			change:    func(d *SessionQualityData) { d.CalibrationAccuracy = nil },
+			failed:    []string{CheckCalibration},
+			wantScore: 0.8,
+		},
+		{
+			name:      "calibration not passed",
+			change:    func(d *SessionQualityData) { d.CalibrationPassed = false },
+			failed:    []string{CheckCalibration},
+			wantScore: 0.8,
+		},
+		{
+			name:      "low sample rate",
+			change:    func(d *SessionQualityData) { d.Samples = gaze(20, 0, 500*time.Millisecond) },
+			failed:    []string{CheckSampleRate},
+			wantScore: 0.8,
+		},
+		{
+			name:      "no gaze data",
+			change:    func(d *SessionQualityData) { d.Samples = nil },
+			failed:    []string{CheckSampleRate},
+			skipped:   []string{CheckOffscreen},
+			wantScore: 0.75,
+		},
+		{
+			name:      "mostly off-screen",
+			change:    func(d *SessionQualityData) { d.Samples = gaze(100, 50, 100*time.Millisecond) },
+			failed:    []string{CheckOffscreen},
+			wantScore: 0.8,
+		},
+		{
+			name:      "reading too short",
+			change:    func(d *SessionQualityData) { d.ReadingTimes[0] = 2 * time.Second },
+			failed:    []string{CheckReadingTime},
+			wantScore: 0.8,
+		},
+		{
+			name:      "reading too long",
+			change:    func(d *SessionQualityData) { d.ReadingTimes[1] = 11 * time.Minute },
+			failed:    []string{CheckReadingTime},
+			wantScore: 0.8,
+		},
+		{
+			name:      "too few quiz answers",
+			change:    func(d *SessionQualityData) { d.QuizCorrect, d.QuizTotal = 0, 2 },
+			skipped:   []string{CheckQuiz},
+			wantScore: 1,
+		},
+		{
+			name:      "quiz below chance",
+			change:    func(d *SessionQualityData) { d.QuizCorrect = 0 },
+			failed:    []string{CheckQuiz},
+			wantScore: 0.8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := good()
+			if tt.change != nil {
+				tt.change(&data)
+			}
+			report := EvaluateQuality(data, DefaultQualityThresholds())
+
+			var failed, skipped []string
+			for _, check := range report.Checks {
+				switch check.Status {
+				case QualityFail:
+					failed = append(failed, check.Name)
+				case QualitySkipped:
+					skipped = append(skipped, check.Name)
+				}
+			}
+			if fmt.Sprint(failed) != fmt.Sprint(tt.failed) || fmt.Sprint(skipped) != fmt.Sprint(tt.skipped) {
+				t.Errorf("failed %v and skipped %v, want %v and %v", failed, skipped, tt.failed, tt.skipped)
+			}
+			if report.Included != (len(tt.failed) == 0) || len(report.Reasons) != len(tt.failed) {
+				t.Errorf("included %v with reasons %q", report.Included, report.Reasons)
+			}
+			if !near(report.Score, tt.wantScore, 1e-9) {
+				t.Errorf("score = %v, want %v", report.Score, tt.wantScore)
+			}
+		})
+	}
+}
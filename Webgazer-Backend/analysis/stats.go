@@ -0,0 +1,438 @@
+package analysis
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrInsufficientData is returned when a test has too few (or degenerate) observations
+var ErrInsufficientData = errors.New("not enough data for this test")
+
+// TTestResult is the outcome of a paired t-test on a-b differences
+type TTestResult struct {
+	N              int
+	MeanDifference float64
+	SD             float64 // standard deviation of the differences
+	StdError       float64
+	T              float64
+	DF             float64
+	PValue         float64    // two-sided
+	CI95           [2]float64 // 95% confidence interval of the mean difference
+	CohensD        float64    // mean difference / SD of differences (d_z)
+}
+
+// WilcoxonResult is the outcome of a Wilcoxon signed-rank test on a-b differences
+type WilcoxonResult struct {
+	N          int // pairs with a non-zero difference
+	WPlus      float64
+	WMinus     float64
+	Z          float64 // normal approximation, without continuity correction
+	PValue     float64 // two-sided
+	Exact      bool    // PValue comes from the exact null distribution
+	EffectSize float64 // r = Z / sqrt(N)
+}
+
+// BinomialResult is the outcome of an exact two-sided binomial test
+type BinomialResult struct {
+	Successes  int
+	N          int
+	Proportion float64
+	CI95       [2]float64 // Wilson score interval for the proportion
+	PValue     float64
+	CohensH    float64 // effect size against the null proportion
+}
+
+// ChiSquareResult is the outcome of a Pearson chi-square test of independence
+type ChiSquareResult struct {
+	ChiSquare float64
+	DF        int
+	PValue    float64
+	CramersV  float64
+}
+
+// Mean returns the arithmetic mean of x, or 0 for an empty slice
+func Mean(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range x {
+		sum += v
+	}
+	return sum / float64(len(x))
+}
+
+// StdDev returns the sample standard deviation of x
+func StdDev(x []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	m := Mean(x)
+	var ss float64
+	for _, v := range x {
+		ss += (v - m) * (v - m)
+	}
+	return math.Sqrt(ss / float64(len(x)-1))
+}
+
+// PairedTTest tests whether the mean of a[i]-b[i] differs from zero
+func PairedTTest(a, b []float64) (TTestResult, error) {
+	if len(a) != len(b) {
+		return TTestResult{}, errors.New("paired samples must have the same length")
+	}
+	if len(a) < 2 {
+		return TTestResult{N: len(a)}, ErrInsufficientData
+	}
+
+	diffs := make([]float64, len(a))
+	for i := range a {
+		diffs[i] = a[i] - b[i]
+	}
+
+	r := TTestResult{N: len(diffs), MeanDifference: Mean(diffs), SD: StdDev(diffs), DF: float64(len(diffs) - 1)}
+	if r.SD == 0 {
+		return r, ErrInsufficientData
+	}
+	r.StdError = r.SD / math.Sqrt(float64(r.N))
+	r.T = r.MeanDifference / r.StdError
+	r.PValue = 2 * (1 - studentTCDF(math.Abs(r.T), r.DF))
+	margin := studentTQuantile(0.975, r.DF) * r.StdError
+	r.CI95 = [2]float64{r.MeanDifference - margin, r.MeanDifference + margin}
+	r.CohensD = r.MeanDifference / r.SD
+	return r, nil
+}
+
+// WilcoxonSignedRank tests whether a[i]-b[i] is symmetric around zero. Zero
+// differences are dropped; the exact distribution is used for up to 25 pairs
+// without ties, otherwise a tie-corrected normal approximation.
+func WilcoxonSignedRank(a, b []float64) (WilcoxonResult, error) {
+	if len(a) != len(b) {
+		return WilcoxonResult{}, errors.New("paired samples must have the same length")
+	}
+
+	var diffs []float64
+	for i := range a {
+		if d := a[i] - b[i]; d != 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	n := len(diffs)
+	r := WilcoxonResult{N: n}
+	if n == 0 {
+		return r, ErrInsufficientData
+	}
+
+	// Rank absolute differences, averaging the ranks of ties
+	sort.Slice(diffs, func(i, j int) bool { return math.Abs(diffs[i]) < math.Abs(diffs[j]) })
+	var tieCorrection float64
+	hasTies := false
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && math.Abs(diffs[j+1]) == math.Abs(diffs[i]) {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			if diffs[k] > 0 {
+				r.WPlus += rank
+			} else {
+				r.WMinus += rank
+			}
+		}
+		if t := float64(j - i + 1); t > 1 {
+			hasTies = true
+			tieCorrection += t*t*t - t
+		}
+		i = j + 1
+	}
+
+	nf := float64(n)
+	mean := nf * (nf + 1) / 4
+	sd := math.Sqrt(nf*(nf+1)*(2*nf+1)/24 - tieCorrection/48)
+	if sd > 0 {
+		r.Z = (r.WPlus - mean) / sd
+	}
+	r.EffectSize = r.Z / math.Sqrt(nf)
+
+	if n <= 25 && !hasTies {
+		r.Exact = true
+		r.PValue = math.Min(1, 2*wilcoxonExactCDF(n, int(math.Min(r.WPlus, r.WMinus))))
+		return r, nil
+	}
+	if sd == 0 {
+		r.PValue = 1
+		return r, nil
+	}
+	// Continuity-corrected normal approximation
+	z := (math.Abs(r.WPlus-mean) - 0.5) / sd
+	if z < 0 {
+		z = 0
+	}
+	r.PValue = math.Min(1, 2*(1-normalCDF(z)))
+	return r, nil
+}
+
+// wilcoxonExactCDF returns P(W+ <= w) under the null for n untied pairs
+func wilcoxonExactCDF(n, w int) float64 {
+	maxSum := n * (n + 1) / 2
+	counts := make([]float64, maxSum+1)
+	counts[0] = 1
+	for rank := 1; rank <= n; rank++ {
+		for s := maxSum; s >= rank; s-- {
+			counts[s] += counts[s-rank]
+		}
+	}
+	var below float64
+	for s := 0; s <= w && s <= maxSum; s++ {
+		below += counts[s]
+	}
+	return below / math.Pow(2, float64(n))
+}
+
+// BinomialTest tests whether successes out of n is consistent with proportion p
+func BinomialTest(successes, n int, p float64) (BinomialResult, error) {
+	r := BinomialResult{Successes: successes, N: n}
+	if n == 0 {
+		return r, ErrInsufficientData
+	}
+	if successes < 0 || successes > n {
+		return r, errors.New("successes must be between 0 and n")
+	}
+
+	r.Proportion = float64(successes) / float64(n)
+	r.CI95 = wilsonInterval(successes, n, 1.959963984540054)
+	r.CohensH = 2*math.Asin(math.Sqrt(r.Proportion)) - 2*math.Asin(math.Sqrt(p))
+
+	// Two-sided p: total probability of outcomes no more likely than the observed one
+	observed := binomialPMF(successes, n, p)
+	for k := 0; k <= n; k++ {
+		if pk := binomialPMF(k, n, p); pk <= observed*(1+1e-7) {
+			r.PValue += pk
+		}
+	}
+	r.PValue = math.Min(1, r.PValue)
+	return r, nil
+}
+
+func binomialPMF(k, n int, p float64) float64 {
+	lnChoose := lgamma(float64(n+1)) - lgamma(float64(k+1)) - lgamma(float64(n-k+1))
+	switch {
+	case p == 0:
+		if k == 0 {
+			return 1
+		}
+		return 0
+	case p == 1:
+		if k == n {
+			return 1
+		}
+		return 0
+	}
+	return math.Exp(lnChoose + float64(k)*math.Log(p) + float64(n-k)*math.Log(1-p))
+}
+
+func wilsonInterval(successes, n int, z float64) [2]float64 {
+	nf := float64(n)
+	p := float64(successes) / nf
+	denominator := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denominator
+	return [2]float64{math.Max(0, center-margin), math.Min(1, center+margin)}
+}
+
+// ProportionDifference returns p1-p2 for two independent proportions with a
+// 95% Wald confidence interval
+func ProportionDifference(successes1, n1, successes2, n2 int) (float64, [2]float64, error) {
+	if n1 == 0 || n2 == 0 {
+		return 0, [2]float64{}, ErrInsufficientData
+	}
+	p1 := float64(successes1) / float64(n1)
+	p2 := float64(successes2) / float64(n2)
+	diff := p1 - p2
+	margin := 1.959963984540054 * math.Sqrt(p1*(1-p1)/float64(n1)+p2*(1-p2)/float64(n2))
+	return diff, [2]float64{diff - margin, diff + margin}, nil
+}
+
+// ChiSquareTest runs Pearson's chi-square test of independence on a contingency
+// table of observed counts (rows x columns)
+func ChiSquareTest(table [][]float64) (ChiSquareResult, error) {
+	rows := len(table)
+	if rows < 2 || len(table[0]) < 2 {
+		return ChiSquareResult{}, ErrInsufficientData
+	}
+	cols := len(table[0])
+
+	rowTotals := make([]float64, rows)
+	colTotals := make([]float64, cols)
+	var total float64
+	for i, row := range table {
+		if len(row) != cols {
+			return ChiSquareResult{}, errors.New("contingency table rows must have the same length")
+		}
+		for j, v := range row {
+			rowTotals[i] += v
+			colTotals[j] += v
+			total += v
+		}
+	}
+	for _, t := range rowTotals {
+		if t == 0 {
+			return ChiSquareResult{}, ErrInsufficientData
+		}
+	}
+	for _, t := range colTotals {
+		if t == 0 {
+			return ChiSquareResult{}, ErrInsufficientData
+		}
+	}
+
+	r := ChiSquareResult{DF: (rows - 1) * (cols - 1)}
+	for i, row := range table {
+		for j, observed := range row {
+			expected := rowTotals[i] * colTotals[j] / total
+			r.ChiSquare += (observed - expected) * (observed - expected) / expected
+		}
+	}
+	r.PValue = regularizedGammaQ(float64(r.DF)/2, r.ChiSquare/2)
+	r.CramersV = math.Sqrt(r.ChiSquare / (total * float64(min(rows, cols)-1)))
+	return r, nil
+}
+
+// Distribution functions
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func studentTCDF(t, df float64) float64 {
+	tail := 0.5 * regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+	if t >= 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// studentTQuantile inverts studentTCDF by bisection
+func studentTQuantile(p, df float64) float64 {
+	lo, hi := -1.0, 1.0
+	for studentTCDF(lo, df) > p {
+		lo *= 2
+	}
+	for studentTCDF(hi, df) < p {
+		hi *= 2
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if studentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func lgamma(x float64) float64 {
+	v, _ := math.Lgamma(x)
+	return v
+}
+
+const (
+	specialEpsilon = 1e-14
+	specialTiny    = 1e-300
+	specialMaxIter = 500
+)
+
+// regularizedIncompleteBeta computes I_x(a, b) with a continued fraction
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	front := math.Exp(lgamma(a+b) - lgamma(a) - lgamma(b) + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+func betaContinuedFraction(a, b, x float64) float64 {
+	clamp := func(v float64) float64 {
+		if math.Abs(v) < specialTiny {
+			return specialTiny
+		}
+		return v
+	}
+
+	c := 1.0
+	d := 1 / clamp(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1; m <= specialMaxIter; m++ {
+		mf := float64(m)
+		numerator := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		h *= d * c
+
+		numerator = -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < specialEpsilon {
+			break
+		}
+	}
+	return h
+}
+
+// regularizedGammaQ computes the upper regularized incomplete gamma Q(a, x),
+// which is the chi-square survival function for a = df/2, x = chi2/2
+func regularizedGammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	front := math.Exp(-x + a*math.Log(x) - lgamma(a))
+
+	if x < a+1 {
+		// Series for P(a, x)
+		term := 1 / a
+		sum := term
+		for n := 1; n <= specialMaxIter; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*specialEpsilon {
+				break
+			}
+		}
+		return 1 - sum*front
+	}
+
+	// Continued fraction for Q(a, x)
+	b := x + 1 - a
+	c := 1 / specialTiny
+	d := 1 / b
+	h := d
+	for i := 1; i <= specialMaxIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < specialTiny {
+			d = specialTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < specialTiny {
+			c = specialTiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < specialEpsilon {
+			break
+		}
+	}
+	return front * h
+}
@@ -0,0 +1,236 @@
+package analysis
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMeanStdDev(t *testing.T) {
+	tests := []struct {
+		x        []float64
+		mean, sd float64
+	}{
+		{nil, 0, 0},
+		{[]float64{3}, 3, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, math.Sqrt(32.0 / 7)},
+	}
+	for _, tt := range tests {
+		if got := Mean(tt.x); !near(got, tt.mean, 1e-12) {
+			t.Errorf("Mean(%v) = %v, want %v", tt.x, got, tt.mean)
+		}
+		if got := StdDev(tt.x); !near(got, tt.sd, 1e-12) {
+			t.Errorf("StdDev(%v) = %v, want %v", tt.x, got, tt.sd)
+		}
+	}
+}
+
+func TestPairedTTest(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []float64
+		want    TTestResult
+		wantErr error
+	}{
+		{
+			// Differences 1..5: mean 3, SD sqrt(2.5), t = 3 / sqrt(0.5)
+			name: "differences one to five",
+			a:    []float64{2, 4, 6, 8, 10},
+			b:    []float64{1, 2, 3, 4, 5},
+			want: TTestResult{
+				N: 5, MeanDifference: 3, SD: math.Sqrt(2.5), StdError: math.Sqrt(0.5),
+				T: 3 / math.Sqrt(0.5), DF: 4, PValue: 0.0132356,
+				CI95: [2]float64{1.0367568, 4.9632432}, CohensD: 3 / math.Sqrt(2.5),
+			},
+		},
+		{
+			name:    "constant difference",
+			a:       []float64{2, 3, 4},
+			b:       []float64{1, 2, 3},
+			wantErr: ErrInsufficientData,
+		},
+		{
+			name:    "one pair",
+			a:       []float64{2},
+			b:       []float64{1},
+			wantErr: ErrInsufficientData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PairedTTest(tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.N != tt.want.N || got.DF != tt.want.DF ||
+				!near(got.MeanDifference, tt.want.MeanDifference, 1e-9) ||
+				!near(got.SD, tt.want.SD, 1e-9) ||
+				!near(got.StdError, tt.want.StdError, 1e-9) ||
+				!near(got.T, tt.want.T, 1e-9) ||
+				!near(got.PValue, tt.want.PValue, 1e-6) ||
+				!near(got.CI95[0], tt.want.CI95[0], 1e-6) ||
+				!near(got.CI95[1], tt.want.CI95[1], 1e-6) ||
+				!near(got.CohensD, tt.want.CohensD, 1e-9) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := PairedTTest([]float64{1, 2}, []float64{1}); err == nil {
+		t.Error("samples of different lengths were accepted")
+	}
+}
+
+func TestWilcoxonSignedRank(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []float64
+		wantN     int
+		wantPlus  float64
+		wantP     float64
+		wantExact bool
+		wantErr   error
+	}{
+		{
+			// Five positive untied differences: P(W- = 0) = 1/32 on each side
+			name:      "all positive",
+			a:         []float64{1, 2, 3, 4, 5},
+			b:         []float64{0, 0, 0, 0, 0},
+			wantN:     5,
+			wantPlus:  15,
+			wantP:     2.0 / 32,
+			wantExact: true,
+		},
+		{
+			name:      "zero differences are dropped",
+			a:         []float64{1, 2, 3, 4, 5, 7},
+			b:         []float64{0, 0, 0, 0, 0, 7},
+			wantN:     5,
+			wantPlus:  15,
+			wantP:     2.0 / 32,
+			wantExact: true,
+		},
+		{
+			// Ranks 1.5, 1.5, 3: ties switch to the normal approximation
+			name:     "ties",
+			a:        []float64{1, 0, 2},
+			b:        []float64{0, 1, 0},
+			wantN:    3,
+			wantPlus: 4.5,
+			wantP:    math.Erfc((1.5 - 0.5) / math.Sqrt(3.5-6.0/48) / math.Sqrt2),
+		},
+		{
+			name:    "no differences",
+			a:       []float64{1, 2},
+			b:       []float64{1, 2},
+			wantErr: ErrInsufficientData,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WilcoxonSignedRank(tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.N != tt.wantN || got.WPlus != tt.wantPlus || got.Exact != tt.wantExact || !near(got.PValue, tt.wantP, 1e-9) {
+				t.Errorf("got %+v, want N %d, W+ %v, p %v, exact %v", got, tt.wantN, tt.wantPlus, tt.wantP, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestBinomialTest(t *testing.T) {
+	tests := []struct {
+		name         string
+		successes, n int
+		p            float64
+		wantP        float64
+		wantErr      bool
+	}{
+		{"nine of ten", 9, 10, 0.5, 22.0 / 1024, false},
+		{"one of ten", 1, 10, 0.5, 22.0 / 1024, false},
+		{"even split", 5, 10, 0.5, 1, false},
+		{"all successes against certainty", 4, 4, 1, 1, false},
+		{"no trials", 0, 0, 0.5, 0, true},
+		{"more successes than trials", 11, 10, 0.5, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BinomialTest(tt.successes, tt.n, tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !near(got.PValue, tt.wantP, 1e-9) {
+				t.Errorf("p = %v, want %v", got.PValue, tt.wantP)
+			}
+			if got.CI95[0] > got.Proportion || got.CI95[1] < got.Proportion {
+				t.Errorf("interval %v does not contain the proportion %v", got.CI95, got.Proportion)
+			}
+		})
+	}
+}
+
+func TestProportionDifference(t *testing.T) {
+	diff, ci, err := ProportionDifference(30, 50, 20, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	margin := 1.959963984540054 * math.Sqrt(0.6*0.4/50+0.4*0.6/50)
+	if !near(diff, 0.2, 1e-12) || !near(ci[0], 0.2-margin, 1e-12) || !near(ci[1], 0.2+margin, 1e-12) {
+		t.Errorf("got %v %v", diff, ci)
+	}
+	if _, _, err := ProportionDifference(1, 0, 1, 2); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("empty group: error = %v", err)
+	}
+}
+
+func TestChiSquareTest(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   [][]float64
+		want    ChiSquareResult
+		wantErr bool
+	}{
+		{
+			// Expected counts 12, 18, 28, 42
+			name:  "two by two",
+			table: [][]float64{{10, 20}, {30, 40}},
+			want: ChiSquareResult{
+				ChiSquare: 4.0/12 + 4.0/18 + 4.0/28 + 4.0/42, DF: 1,
+				PValue: 0.3729985, CramersV: math.Sqrt((4.0/12 + 4.0/18 + 4.0/28 + 4.0/42) / 100),
+			},
+		},
+		{
+			name:  "independent",
+			table: [][]float64{{10, 20, 30}, {20, 40, 60}},
+			want:  ChiSquareResult{ChiSquare: 0, DF: 2, PValue: 1, CramersV: 0},
+		},
+		{name: "one row", table: [][]float64{{1, 2}}, wantErr: true},
+		{name: "empty column", table: [][]float64{{1, 0}, {2, 0}}, wantErr: true},
+		{name: "ragged", table: [][]float64{{1, 2}, {3}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChiSquareTest(tt.table)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.DF != tt.want.DF || !near(got.ChiSquare, tt.want.ChiSquare, 1e-9) ||
+				!near(got.PValue, tt.want.PValue, 1e-6) || !near(got.CramersV, tt.want.CramersV, 1e-9) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
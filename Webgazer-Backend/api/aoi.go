@@ -0,0 +1,447 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// aoiBox is a word or line bounding box as uploaded by the client
+type aoiBox struct {
+	Index  int     `json:"index"`
+	Line   int     `json:"line"`
+	Text   string  `json:"text"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// handleAOIUpload stores the rendered word and line boxes of a passage in one panel.
+// Uploading again for the same session, passage and panel replaces the earlier layout.
+func (s *Server) handleAOIUpload(c echo.Context) error {
+	var upload struct {
+		SessionID uint       `json:"session_id"`
+		PassageID uint       `json:"passage_id"`
+		Panel     string     `json:"panel"`
+		Font      string     `json:"font"`
+		ShownAt   *time.Time `json:"shown_at,omitempty"`
+		Words     []aoiBox   `json:"words"`
+		Lines     []aoiBox   `json:"lines"`
+	}
+	if err := c.Bind(&upload); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	if upload.SessionID == 0 || upload.PassageID == 0 || upload.Panel == "" {
+		return c.JSON(400, map[string]string{"error": "session_id, passage_id and panel are required"})
+	}
+	if len(upload.Words) == 0 && len(upload.Lines) == 0 {
+		return c.JSON(400, map[string]string{"error": "words or lines must contain at least one box"})
+	}
+
+	if _, err := s.repo.Passages().Get(upload.PassageID); err != nil {
+		return c.JSON(404, map[string]string{"error": "Passage not found"})
+	}
+
+	shownAt := time.Now()
+	if upload.ShownAt != nil {
+		shownAt = *upload.ShownAt
+	}
+
+	aois := make([]store.AOI, 0, len(upload.Words)+len(upload.Lines))
+	for kind, boxes := range map[string][]aoiBox{"word": upload.Words, "line": upload.Lines} {
+		for _, b := range boxes {
+			if b.Width <= 0 || b.Height <= 0 {
+				return c.JSON(400, map[string]string{"error": "AOI width and height must be positive"})
+			}
+			lineIndex := b.Line
+			if kind == "line" {
+				lineIndex = b.Index
+			}
+			aois = append(aois, store.AOI{
+				SessionID: upload.SessionID,
+				PassageID: upload.PassageID,
+				Panel:     upload.Panel,
+				Font:      upload.Font,
+				Kind:      kind,
+				Index:     b.Index,
+				LineIndex: lineIndex,
+				Text:      b.Text,
+				X:         b.X,
+				Y:         b.Y,
+				Width:     b.Width,
+				Height:    b.Height,
+				ShownAt:   shownAt,
+			})
+		}
+	}
+
+	err := s.storeSessionData(upload.SessionID, store.StatusReading, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().ReplaceAOIs(upload.SessionID, upload.PassageID, upload.Panel, aois)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save AOIs: " + err.Error()})
+	}
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"words":   len(upload.Words),
+		"lines":   len(upload.Lines),
+	})
+}
+
+// aoiLayout is every word and line box of one passage in one panel, valid from
+// ShownAt until the next layout appears in the same panel
+type aoiLayout struct {
+	Panel   string
+	ShownAt time.Time
+	Words   []store.AOI
+	Lines   []store.AOI
+}
+
+// aoiLocator finds the AOIs under a gaze position at a point in time
+type aoiLocator struct {
+	layouts []aoiLayout // sorted by ShownAt
+	margin  float64
+}
+
+func newAOILocator(aois []store.AOI, margin float64) *aoiLocator {
+	byKey := make(map[string]*aoiLayout)
+	var keys []string
+	for _, a := range aois {
+		key := a.Panel + "|" + a.ShownAt.UTC().Format(time.RFC3339Nano)
+		layout, ok := byKey[key]
+		if !ok {
+			layout = &aoiLayout{Panel: a.Panel, ShownAt: a.ShownAt}
+			byKey[key] = layout
+			keys = append(keys, key)
+		}
+		if a.Kind == "line" {
+			layout.Lines = append(layout.Lines, a)
+		} else {
+			layout.Words = append(layout.Words, a)
+		}
+	}
+
+	locator := &aoiLocator{margin: margin}
+	for _, key := range keys {
+		locator.layouts = append(locator.layouts, *byKey[key])
+	}
+	sort.Slice(locator.layouts, func(i, j int) bool {
+		return locator.layouts[i].ShownAt.Before(locator.layouts[j].ShownAt)
+	})
+	return locator
+}
+
+// locate returns the word and line AOI IDs under (x, y) at time t, or nil when
+// the position falls outside every box. An empty panel matches any panel.
+func (l *aoiLocator) locate(panel string, x, y float64, t time.Time) (word, line *uint) {
+	// Use the most recent layout shown on each panel at time t
+	current := make(map[string]*aoiLayout)
+	for i := range l.layouts {
+		layout := &l.layouts[i]
+		if layout.ShownAt.After(t) {
+			break
+		}
+		if panel == "" || layout.Panel == panel {
+			current[layout.Panel] = layout
+		}
+	}
+
+	for _, layout := range current {
+		if id := findAOI(layout.Words, x, y, l.margin); id != nil {
+			word = id
+		}
+		if id := findAOI(layout.Lines, x, y, l.margin); id != nil {
+			line = id
+		}
+	}
+	return word, line
+}
+
+func findAOI(aois []store.AOI, x, y, margin float64) *uint {
+	boxes := make([]analysis.Box, len(aois))
+	for i, a := range aois {
+		boxes[i] = analysis.Box{X: a.X, Y: a.Y, Width: a.Width, Height: a.Height}
+	}
+	if i := analysis.FindBox(boxes, x, y, margin); i >= 0 {
+		return &aois[i].ID
+	}
+	return nil
+}
+
+// assignAOIs links every gaze point and fixation of a session to the word and
+// line it falls on, clearing earlier assignments
+func (s *Server) assignAOIs(sessionID uint, margin float64) (map[string]int, error) {
+	var aois []store.AOI
+	if err := s.db.Where("session_id = ?", sessionID).Find(&aois).Error; err != nil {
+		return nil, err
+	}
+	locator := newAOILocator(aois, margin)
+
+	var points []store.GazePoint
+	if err := s.db.Where("session_id = ?", sessionID).Find(&points).Error; err != nil {
+		return nil, err
+	}
+	var fixations []store.Fixation
+	if err := s.db.Where("session_id = ?", sessionID).Find(&fixations).Error; err != nil {
+		return nil, err
+	}
+
+	// Group row IDs by the AOI they map to so each AOI needs a single UPDATE
+	type target struct{ word, line uint }
+	pointTargets := make(map[target][]uint)
+	fixationTargets := make(map[target][]uint)
+	counts := map[string]int{"gaze_points": 0, "fixations": 0}
+
+	for _, p := range points {
+		word, line := locator.locate(p.Panel, p.X, p.Y, p.Timestamp)
+		if word == nil && line == nil {
+			continue
+		}
+		key := target{}
+		if word != nil {
+			key.word = *word
+		}
+		if line != nil {
+			key.line = *line
+		}
+		pointTargets[key] = append(pointTargets[key], p.ID)
+		counts["gaze_points"]++
+	}
+	for _, f := range fixations {
+		word, line := locator.locate(f.Panel, f.X, f.Y, f.StartTime)
+		if word == nil && line == nil {
+			continue
+		}
+		key := target{}
+		if word != nil {
+			key.word = *word
+		}
+		if line != nil {
+			key.line = *line
+		}
+		fixationTargets[key] = append(fixationTargets[key], f.ID)
+		counts["fixations"]++
+	}
+
+	// nullableID maps the zero ID used for "no AOI" back to NULL
+	nullableID := func(id uint) interface{} {
+		if id == 0 {
+			return nil
+		}
+		return id
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		reset := map[string]interface{}{"word_aoi_id": nil, "line_aoi_id": nil}
+		if err := tx.Model(&store.GazePoint{}).Where("session_id = ?", sessionID).Updates(reset).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&store.Fixation{}).Where("session_id = ?", sessionID).Updates(reset).Error; err != nil {
+			return err
+		}
+
+		for key, ids := range pointTargets {
+			for _, chunk := range chunkIDs(ids, 500) {
+				if err := tx.Model(&store.GazePoint{}).Where("id IN ?", chunk).Updates(map[string]interface{}{
+					"word_aoi_id": nullableID(key.word),
+					"line_aoi_id": nullableID(key.line),
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for key, ids := range fixationTargets {
+			for _, chunk := range chunkIDs(ids, 500) {
+				if err := tx.Model(&store.Fixation{}).Where("id IN ?", chunk).Updates(map[string]interface{}{
+					"word_aoi_id": nullableID(key.word),
+					"line_aoi_id": nullableID(key.line),
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts["aois"] = len(aois)
+	counts["gaze_points_total"] = len(points)
+	counts["fixations_total"] = len(fixations)
+	return counts, nil
+}
+
+// chunkIDs splits ids into slices of at most size elements to keep IN clauses bounded
+func chunkIDs(ids []uint, size int) [][]uint {
+	var chunks [][]uint
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+// isRegression reports whether consecutive fixations moved backwards to an
+// earlier word or line of the same passage and panel
+func isRegression(aois map[uint]store.AOI, from, to *uint) bool {
+	if from == nil || to == nil {
+		return false
+	}
+	a, okA := aois[*from]
+	b, okB := aois[*to]
+	return okA && okB && a.PassageID == b.PassageID && a.Panel == b.Panel && b.Index < a.Index
+}
+
+// handleAdminSessionAOIs lists a session's AOIs with their hit counts (GET) or
+// assigns gaze points and fixations to AOIs (POST)
+func (s *Server) handleAdminSessionAOIs(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid session ID"})
+	}
+
+	var session store.StudySession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		return c.JSON(404, map[string]string{"error": "Session not found"})
+	}
+
+	switch c.Request().Method {
+	case "POST":
+		var params struct {
+			Margin float64 `json:"margin"` // Pixels to grow each box by before matching
+		}
+		if err := c.Bind(&params); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if params.Margin < 0 {
+			return c.JSON(400, map[string]string{"error": "margin must not be negative"})
+		}
+
+		counts, err := s.assignAOIs(session.ID, params.Margin)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to assign AOIs: " + err.Error()})
+		}
+		s.logAudit(c, auditAssignAOIs, entitySession, session.ID, nil, map[string]interface{}{
+			"margin":   params.Margin,
+			"assigned": counts,
+		})
+
+		return c.JSON(200, map[string]interface{}{
+			"success":  true,
+			"assigned": counts,
+		})
+
+	case "GET":
+		type aoiSummary struct {
+			store.AOI
+			GazePointCount  int64 `json:"gaze_point_count"`
+			FixationCount   int64 `json:"fixation_count"`
+			DwellMS         int64 `json:"dwell_ms"`         // Total fixation duration on this AOI
+			RegressionCount int64 `json:"regression_count"` // Fixations arriving here from a later word or line
+		}
+
+		// Fixation statistics come from a single detector run so they aren't double counted
+		algorithm := c.QueryParam("algorithm")
+		if algorithm == "" {
+			algorithm = "ivt"
+		}
+
+		query := s.db.Where("session_id = ?", session.ID).Order("passage_id ASC, panel ASC, kind ASC").
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "index"}})
+		if kind := c.QueryParam("kind"); kind != "" {
+			query = query.Where("kind = ?", kind)
+		}
+		if passageID := c.QueryParam("passage_id"); passageID != "" {
+			query = query.Where("passage_id = ?", passageID)
+		}
+		var aois []store.AOI
+		if err := query.Find(&aois).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch AOIs: " + err.Error()})
+		}
+
+		var allAOIs []store.AOI
+		if err := s.db.Where("session_id = ?", session.ID).Find(&allAOIs).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch AOIs: " + err.Error()})
+		}
+		aoiByID := make(map[uint]store.AOI, len(allAOIs))
+		for _, a := range allAOIs {
+			aoiByID[a.ID] = a
+		}
+
+		var points []store.GazePoint
+		if err := s.db.Select("id, word_aoi_id, line_aoi_id").Where("session_id = ?", session.ID).Find(&points).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch gaze points: " + err.Error()})
+		}
+		var fixations []store.Fixation
+		runIDs := s.db.Model(&store.FixationRun{}).Select("id").Where("session_id = ? AND algorithm = ?", session.ID, algorithm)
+		if err := s.db.Where("run_id IN (?)", runIDs).Order("start_time ASC, id ASC").Find(&fixations).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch fixations: " + err.Error()})
+		}
+
+		pointHits := make(map[uint]int64)
+		for _, p := range points {
+			for _, id := range []*uint{p.WordAOIID, p.LineAOIID} {
+				if id != nil {
+					pointHits[*id]++
+				}
+			}
+		}
+
+		fixationHits := make(map[uint]int64)
+		dwell := make(map[uint]int64)
+		regressions := make(map[uint]int64)
+		var prevWord, prevLine *uint
+		for _, f := range fixations {
+			for _, id := range []*uint{f.WordAOIID, f.LineAOIID} {
+				if id != nil {
+					fixationHits[*id]++
+					dwell[*id] += int64(f.DurationMS)
+				}
+			}
+			if isRegression(aoiByID, prevWord, f.WordAOIID) {
+				regressions[*f.WordAOIID]++
+			}
+			if isRegression(aoiByID, prevLine, f.LineAOIID) {
+				regressions[*f.LineAOIID]++
+			}
+			prevWord, prevLine = f.WordAOIID, f.LineAOIID
+		}
+
+		summaries := make([]aoiSummary, len(aois))
+		for i, a := range aois {
+			summaries[i] = aoiSummary{
+				AOI:             a,
+				GazePointCount:  pointHits[a.ID],
+				FixationCount:   fixationHits[a.ID],
+				DwellMS:         dwell[a.ID],
+				RegressionCount: regressions[a.ID],
+			}
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success":   true,
+			"algorithm": algorithm,
+			"data":      summaries,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Audit actions
+const (
+	auditCreate          = "create"
+	auditUpdate          = "update"
+	auditDelete          = "delete"
+	auditEvaluate        = "evaluate"         // quality checks (re-)run
+	auditDetectFixations = "detect_fixations" // fixation detection run on a session
+	auditAssignAOIs      = "assign_aois"      // gaze points and fixations matched to AOIs
+	auditClone           = "clone"            // study text copied into a new draft
+	auditPublish         = "publish"          // draft study text published and activated
+)
+
+// Audited entities, named after their tables
+const (
+	entityStudyText          = "study_text"
+	entityPassage            = "passage"
+	entityQuizQuestion       = "quiz_question"
+	entityConsentDocument    = "consent_document"
+	entityDemographicsSchema = "demographics_schema"
+	entitySessionQuality     = "session_quality"
+	entitySession            = "study_session"
+	entityParticipant        = "participant"
+	entityAdminUser          = "admin_user"
+)
+
+// Page size of GET /api/admin/audit
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditJSON encodes an entity snapshot; nil gives an empty string
+func auditJSON(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// writeAudit appends an audit entry. Pass the repository of the change's
+// transaction so the entry is only kept if the change is.
+func writeAudit(repo store.Repository, actor, action, entity string, entityID uint, before, after interface{}) error {
+	entry := store.AuditEntry{Actor: actor, Action: action, Entity: entity, EntityID: entityID}
+	var err error
+	if entry.Before, err = auditJSON(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditJSON(after); err != nil {
+		return err
+	}
+	return repo.Audit().Add(&entry)
+}
+
+// adminActor returns the username of the admin making the request
+func adminActor(c echo.Context) string {
+	if claims, ok := c.Get("admin").(*adminClaims); ok {
+		return claims.Username
+	}
+	return ""
+}
+
+// recordAudit appends an audit entry for the admin making the request
+func recordAudit(repo store.Repository, c echo.Context, action, entity string, entityID uint, before, after interface{}) error {
+	return writeAudit(repo, adminActor(c), action, entity, entityID, before, after)
+}
+
+// logAudit records an audit entry for a change that is already stored, such
+// as an analysis run, and only logs a failure
+func (s *Server) logAudit(c echo.Context, action, entity string, entityID uint, before, after interface{}) {
+	if err := recordAudit(s.repo, c, action, entity, entityID, before, after); err != nil {
+		log.Printf("Failed to write audit entry for %s %s %d: %v", action, entity, entityID, err)
+	}
+}
+
+// handleAdminAudit lists audit entries, newest first. Filters: ?entity,
+// ?entity_id, ?actor, ?action and ?from / ?to (YYYY-MM-DD or RFC 3339);
+// ?limit and ?offset page through the results.
+func (s *Server) handleAdminAudit(c echo.Context) error {
+	query := s.db.Model(&store.AuditEntry{})
+	for _, param := range []string{"entity", "actor", "action"} {
+		if value := c.QueryParam(param); value != "" {
+			query = query.Where(param+" = ?", value)
+		}
+	}
+	if value := c.QueryParam("entity_id"); value != "" {
+		entityID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "entity_id must be a number"})
+		}
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	from, err := parseExportTime(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	to, err := parseExportTime(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		// A plain date for "to" means the whole day is included
+		if len(c.QueryParam("to")) == len("2006-01-02") {
+			end := to.Add(24 * time.Hour)
+			to = &end
+		}
+		query = query.Where("created_at < ?", *to)
+	}
+
+	limit, offset := defaultAuditLimit, 0
+	if value := c.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxAuditLimit {
+			return c.JSON(400, map[string]string{"error": "limit must be between 1 and " + strconv.Itoa(maxAuditLimit)})
+		}
+	}
+	if value := c.QueryParam("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return c.JSON(400, map[string]string{"error": "offset must not be negative"})
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count audit entries: " + err.Error()})
+	}
+	var entries []store.AuditEntry
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch audit entries: " + err.Error()})
+	}
+
+	type auditEntryJSON struct {
+		store.AuditEntry
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}
+	data := make([]auditEntryJSON, len(entries))
+	for i, entry := range entries {
+		data[i] = auditEntryJSON{AuditEntry: entry, Before: rawAuditJSON(entry.Before), After: rawAuditJSON(entry.After)}
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+		"data":    data,
+	})
+}
+
+// rawAuditJSON embeds a stored snapshot as JSON, or null when there is none
+func rawAuditJSON(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
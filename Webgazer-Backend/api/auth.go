@@ -0,0 +1,254 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Admin roles. Editors can do everything viewers can.
+const (
+	RoleViewer = "viewer" // read-only access to statistics and analysis
+	RoleEditor = "editor" // full CRUD on study materials
+)
+
+// adminClaims are the claims carried by an admin session token
+type adminClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Scope    string `json:"scope,omitempty"` // set on tokens that only work on one route
+	jwt.RegisteredClaims
+}
+
+// eventsTokenScope marks tokens that only open the admin event stream.
+// Browsers' EventSource can't send an Authorization header, so these are
+// passed in the URL, where they may end up in logs; they expire quickly.
+const (
+	eventsTokenScope = "events"
+	eventsTokenTTL   = time.Minute
+)
+
+// dummyPasswordHash is compared against when a login names an unknown
+// account, so the response takes as long as for a wrong password and
+// doesn't give away which usernames exist
+const dummyPasswordHash = "$2a$10$L0DYIKvWDIG31UHgmahm6.lqM.cYlgH4ZpwVF3McKpBVFOOdITwGy"
+
+// newAdminTokenSecret returns the token signing key from admin.token_secret
+// (ADMIN_TOKEN_SECRET). Without it a random key is generated, so tokens don't
+// survive a restart.
+func newAdminTokenSecret(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal("Failed to generate admin token secret:", err)
+	}
+	return key
+}
+
+func validRole(role string) bool {
+	return role == RoleViewer || role == RoleEditor
+}
+
+// roleAllows reports whether an account with role may act with the required role
+func roleAllows(role, required string) bool {
+	if role == RoleEditor {
+		return true
+	}
+	return role == required
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CreateAdminUser stores a new admin account with a bcrypt-hashed password.
+// actor is recorded in the audit log as the creator.
+func (s *Server) CreateAdminUser(username, password, role, actor string) (store.AdminUser, error) {
+	user := store.AdminUser{Username: strings.TrimSpace(username), Role: role}
+	if user.Username == "" {
+		return user, errors.New("username is required")
+	}
+	if len(password) < 8 {
+		return user, errors.New("password must be at least 8 characters")
+	}
+	if !validRole(role) {
+		return user, fmt.Errorf("invalid role %q (must be %q or %q)", role, RoleViewer, RoleEditor)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return user, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = hash
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return writeAudit(s.store.Repository(tx), actor, auditCreate, entityAdminUser, user.ID, nil, user)
+	})
+	if err != nil {
+		return user, fmt.Errorf("failed to create admin user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *Server) issueAdminToken(user store.AdminUser) (string, time.Time, error) {
+	return s.issueScopedToken(user, "", time.Duration(s.config.Admin.TokenTTL))
+}
+
+// issueScopedToken signs a token for user that is valid for ttl and, if scope
+// is set, only accepted where that scope is required
+func (s *Server) issueScopedToken(user store.AdminUser, scope string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := adminClaims{
+		Username: user.Username,
+		Role:     user.Role,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprint(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.tokenSecret)
+	return token, expiresAt, err
+}
+
+func (s *Server) parseAdminToken(raw string) (*adminClaims, error) {
+	claims := &adminClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.tokenSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	return claims, nil
+}
+
+// authenticateAdmin checks a raw token for the given scope ("" for a regular
+// session token) and stores its claims on the context under "admin"
+func (s *Server) authenticateAdmin(c echo.Context, raw, scope string, next echo.HandlerFunc) error {
+	claims, err := s.parseAdminToken(raw)
+	if err != nil || claims.Scope != scope {
+		return c.JSON(401, map[string]string{"error": "Invalid or expired token"})
+	}
+
+	// Make sure the account still exists and its role hasn't changed
+	var user store.AdminUser
+	if err := s.db.Where("username = ?", claims.Username).First(&user).Error; err != nil || user.Role != claims.Role {
+		return c.JSON(401, map[string]string{"error": "Invalid or expired token"})
+	}
+
+	c.Set("admin", claims)
+	return next(c)
+}
+
+// requireAdmin rejects requests without a valid admin token and stores the
+// token's claims on the context under "admin"
+func (s *Server) requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get("Authorization")
+		raw := strings.TrimPrefix(header, "Bearer ")
+		if header == "" || raw == header {
+			return c.JSON(401, map[string]string{"error": "Missing bearer token"})
+		}
+		return s.authenticateAdmin(c, raw, "", next)
+	}
+}
+
+// requireEventsToken works like requireAdmin, but also accepts an events
+// token from POST /api/admin/events/token in the ?token query parameter
+func (s *Server) requireEventsToken(next echo.HandlerFunc) echo.HandlerFunc {
+	admin := s.requireAdmin(next)
+	return func(c echo.Context) error {
+		if raw := c.QueryParam("token"); raw != "" {
+			return s.authenticateAdmin(c, raw, eventsTokenScope, next)
+		}
+		return admin(c)
+	}
+}
+
+// requireRole only lets through admins whose role allows the given role.
+// It must run after requireAdmin.
+func requireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("admin").(*adminClaims)
+			if !ok || !roleAllows(claims.Role, role) {
+				return c.JSON(403, map[string]string{"error": "Insufficient permissions"})
+			}
+			return next(c)
+		}
+	}
+}
+
+func (s *Server) handleAdminLogin(c echo.Context) error {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.Bind(&credentials); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+	if credentials.Username == "" || credentials.Password == "" {
+		return c.JSON(400, map[string]string{"error": "username and password are required"})
+	}
+
+	var user store.AdminUser
+	found := s.db.Where("username = ?", credentials.Username).Limit(1).Find(&user)
+	if found.Error != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to look up admin user: " + found.Error.Error()})
+	}
+	// Unknown usernames still pay for a bcrypt comparison
+	hash := user.PasswordHash
+	if found.RowsAffected == 0 {
+		hash = dummyPasswordHash
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(credentials.Password)); err != nil || found.RowsAffected == 0 {
+		return c.JSON(401, map[string]string{"error": "Invalid username or password"})
+	}
+
+	token, expiresAt, err := s.issueAdminToken(user)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to issue token: " + err.Error()})
+	}
+
+	now := time.Now()
+	s.db.Model(&user).Update("last_login_at", &now)
+
+	return c.JSON(200, map[string]interface{}{
+		"success":    true,
+		"token":      token,
+		"expires_at": expiresAt,
+		"username":   user.Username,
+		"role":       user.Role,
+	})
+}
+
+func (s *Server) handleAdminMe(c echo.Context) error {
+	claims := c.Get("admin").(*adminClaims)
+	return c.JSON(200, map[string]interface{}{
+		"success":  true,
+		"username": claims.Username,
+		"role":     claims.Role,
+	})
+}
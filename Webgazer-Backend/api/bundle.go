@@ -0,0 +1,719 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// StudyBundle describes a study text with its passages and quiz questions in
+// one YAML or JSON file. Passages and questions are ordered by their position
+// in the file; passage fonts default to the study text's fonts.
+type StudyBundle struct {
+	Version        string           `json:"version" yaml:"version"`
+	FontLeft       string           `json:"font_left,omitempty" yaml:"font_left,omitempty"`
+	FontRight      string           `json:"font_right,omitempty" yaml:"font_right,omitempty"`
+	CompletionCode string           `json:"completion_code,omitempty" yaml:"completion_code,omitempty"`
+	CompletionURL  string           `json:"completion_url,omitempty" yaml:"completion_url,omitempty"`
+	Content        string           `json:"content,omitempty" yaml:"content,omitempty"` // Legacy single passage
+	Passages       []bundlePassage  `json:"passages,omitempty" yaml:"passages,omitempty"`
+	QuizQuestions  []bundleQuestion `json:"quiz_questions,omitempty" yaml:"quiz_questions,omitempty"` // Not tied to a passage
+}
+
+type bundlePassage struct {
+	Title         string           `json:"title,omitempty" yaml:"title,omitempty"`
+	Content       string           `json:"content" yaml:"content"`
+	FontLeft      string           `json:"font_left,omitempty" yaml:"font_left,omitempty"`
+	FontRight     string           `json:"font_right,omitempty" yaml:"font_right,omitempty"`
+	QuizQuestions []bundleQuestion `json:"quiz_questions,omitempty" yaml:"quiz_questions,omitempty"`
+}
+
+type bundleQuestion struct {
+	ID      string   `json:"id" yaml:"id"` // QuizQuestion.QuestionID, e.g. "q1"
+	Prompt  string   `json:"prompt" yaml:"prompt"`
+	Choices []string `json:"choices" yaml:"choices"`
+	Answer  int      `json:"answer" yaml:"answer"` // Index of the correct choice (0-based)
+}
+
+// bundleChange is one row created, updated or deleted by an import
+type bundleChange struct {
+	Action  string        `json:"action"`
+	Entity  string        `json:"entity"`
+	Key     string        `json:"key"` // Position in the bundle, e.g. passages[0].quiz_questions[q1]
+	ID      uint          `json:"id,omitempty"`
+	Changes []fieldChange `json:"changes,omitempty"`
+}
+
+// BundleImport summarizes an import
+type BundleImport struct {
+	StudyTextID uint
+	Created     bool
+	Published   bool
+	Changes     []bundleChange
+	Unchanged   int
+}
+
+// bundleError lists everything wrong with a bundle
+type bundleError struct {
+	Problems []string
+}
+
+func (e *bundleError) Error() string {
+	return "invalid bundle: " + strings.Join(e.Problems, "; ")
+}
+
+// errDryRun rolls back the transaction of a dry-run import
+var errDryRun = errors.New("dry run")
+
+// ParseBundle decodes a YAML or JSON bundle (JSON is valid YAML). Unknown
+// fields are rejected so that typos do not silently drop content.
+func ParseBundle(data []byte) (StudyBundle, error) {
+	var bundle StudyBundle
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&bundle); err != nil {
+		if errors.Is(err, io.EOF) {
+			return bundle, errors.New("bundle is empty")
+		}
+		return bundle, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// normalize fills in defaults, canonicalizes fonts and checks the bundle,
+// returning a *bundleError listing every problem found
+func (b *StudyBundle) normalize(fonts fontCatalog) error {
+	var problems []string
+	addFont := func(path string, field *string) {
+		if err := fonts.normalizeFonts(field); err != nil {
+			problems = append(problems, path+": "+err.Error())
+		}
+	}
+
+	b.Version = strings.TrimSpace(b.Version)
+	if b.Version == "" {
+		problems = append(problems, "version is required")
+	}
+	if b.FontLeft == "" {
+		b.FontLeft = "georgia"
+	}
+	if b.FontRight == "" {
+		b.FontRight = "inter"
+	}
+	addFont("font_left", &b.FontLeft)
+	addFont("font_right", &b.FontRight)
+	b.CompletionCode = strings.TrimSpace(b.CompletionCode)
+	if err := validateCompletionURL(b.CompletionURL); err != nil {
+		problems = append(problems, "completion_url: "+err.Error())
+	}
+	if len(b.Passages) == 0 && strings.TrimSpace(b.Content) == "" {
+		problems = append(problems, "passages: at least one passage is required")
+	}
+
+	for i := range b.Passages {
+		passage := &b.Passages[i]
+		path := fmt.Sprintf("passages[%d]", i)
+		if strings.TrimSpace(passage.Content) == "" {
+			problems = append(problems, path+".content is required")
+		}
+		if passage.FontLeft == "" {
+			passage.FontLeft = b.FontLeft
+		} else {
+			addFont(path+".font_left", &passage.FontLeft)
+		}
+		if passage.FontRight == "" {
+			passage.FontRight = b.FontRight
+		} else {
+			addFont(path+".font_right", &passage.FontRight)
+		}
+		problems = append(problems, validateBundleQuestions(path+".quiz_questions", passage.QuizQuestions)...)
+	}
+	problems = append(problems, validateBundleQuestions("quiz_questions", b.QuizQuestions)...)
+
+	if len(problems) > 0 {
+		return &bundleError{Problems: problems}
+	}
+	return nil
+}
+
+// validateBundleQuestions checks one list of questions; IDs must be unique within it
+func validateBundleQuestions(path string, questions []bundleQuestion) []string {
+	var problems []string
+	seen := make(map[string]bool, len(questions))
+	for i := range questions {
+		question := &questions[i]
+		at := fmt.Sprintf("%s[%d]", path, i)
+		question.ID = strings.TrimSpace(question.ID)
+		if question.ID == "" {
+			problems = append(problems, at+".id is required")
+		} else if seen[question.ID] {
+			problems = append(problems, at+".id "+strconv.Quote(question.ID)+" is used twice")
+		}
+		seen[question.ID] = true
+		if strings.TrimSpace(question.Prompt) == "" {
+			problems = append(problems, at+".prompt is required")
+		}
+		if len(question.Choices) < 2 {
+			problems = append(problems, at+".choices needs at least two choices")
+		}
+		for j, choice := range question.Choices {
+			if strings.TrimSpace(choice) == "" {
+				problems = append(problems, fmt.Sprintf("%s.choices[%d] is empty", at, j))
+			}
+		}
+		if question.Answer < 0 || question.Answer >= len(question.Choices) {
+			problems = append(problems, at+".answer must be the index of one of the choices")
+		}
+	}
+	return problems
+}
+
+// header returns the study text fields of the bundle without its passages and questions
+func (b StudyBundle) header() StudyBundle {
+	b.Passages, b.QuizQuestions = nil, nil
+	return b
+}
+
+// body returns the passage fields without its questions
+func (p bundlePassage) body() bundlePassage {
+	p.QuizQuestions = nil
+	return p
+}
+
+// newBundleQuestion converts a stored question
+func newBundleQuestion(question store.QuizQuestion) bundleQuestion {
+	bq := bundleQuestion{ID: question.QuestionID, Prompt: question.Prompt, Answer: question.Answer}
+	json.Unmarshal([]byte(question.Choices), &bq.Choices)
+	return bq
+}
+
+// storedBundle is a study text as stored, with the rows its bundle was built from
+type storedBundle struct {
+	Bundle    StudyBundle
+	Passages  []store.Passage              // In bundle order
+	Questions map[int][]store.QuizQuestion // By passage position; -1 for study-text-level questions
+}
+
+// loadBundle builds the bundle of a stored study text. Passage fonts are
+// always filled in; see compact for the exported form.
+func loadBundle(repo store.Repository, studyText store.StudyText) (storedBundle, error) {
+	stored := storedBundle{
+		Bundle: StudyBundle{
+			Version:        studyText.Version,
+			FontLeft:       studyText.FontLeft,
+			FontRight:      studyText.FontRight,
+			CompletionCode: studyText.CompletionCode,
+			CompletionURL:  studyText.CompletionURL,
+			Content:        studyText.Content,
+		},
+		Questions: make(map[int][]store.QuizQuestion),
+	}
+
+	var err error
+	if stored.Passages, err = repo.Passages().ForStudyText(studyText.ID); err != nil {
+		return stored, err
+	}
+	positions := make(map[uint]int, len(stored.Passages))
+	for i, passage := range stored.Passages {
+		positions[passage.ID] = i
+		stored.Bundle.Passages = append(stored.Bundle.Passages, bundlePassage{
+			Title:     passage.Title,
+			Content:   passage.Content,
+			FontLeft:  passage.FontLeft,
+			FontRight: passage.FontRight,
+		})
+	}
+
+	questions, err := repo.QuizQuestions().ForStudyText(studyText.ID)
+	if err != nil {
+		return stored, err
+	}
+	for _, question := range questions {
+		position := -1
+		if question.PassageID != nil {
+			if i, ok := positions[*question.PassageID]; ok {
+				position = i
+			}
+		}
+		stored.Questions[position] = append(stored.Questions[position], question)
+		if position < 0 {
+			stored.Bundle.QuizQuestions = append(stored.Bundle.QuizQuestions, newBundleQuestion(question))
+		} else {
+			passage := &stored.Bundle.Passages[position]
+			passage.QuizQuestions = append(passage.QuizQuestions, newBundleQuestion(question))
+		}
+	}
+	return stored, nil
+}
+
+// compact leaves out passage fonts that match the study text's fonts
+func (b StudyBundle) compact() StudyBundle {
+	passages := make([]bundlePassage, len(b.Passages))
+	for i, passage := range b.Passages {
+		if passage.FontLeft == b.FontLeft {
+			passage.FontLeft = ""
+		}
+		if passage.FontRight == b.FontRight {
+			passage.FontRight = ""
+		}
+		passages[i] = passage
+	}
+	b.Passages = passages
+	return b
+}
+
+// encodeBundle writes a bundle as "yaml" or "json"
+func encodeBundle(w io.Writer, bundle StudyBundle, format string) error {
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(bundle); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(bundle)
+	default:
+		return errors.New("format must be yaml or json")
+	}
+}
+
+// ImportBundle normalizes a parsed bundle against the font catalog and imports
+// it like importBundle; invalid bundles fail with a *bundleError
+func (s *Server) ImportBundle(bundle StudyBundle, actor string, dryRun, publish bool) (BundleImport, error) {
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return BundleImport{}, err
+	}
+	if err := bundle.normalize(fonts); err != nil {
+		return BundleImport{}, err
+	}
+	return s.importBundle(bundle, actor, dryRun, publish)
+}
+
+// ExportBundle writes a stored study text as a "yaml" or "json" bundle
+func (s *Server) ExportBundle(w io.Writer, studyText store.StudyText, format string) error {
+	stored, err := loadBundle(s.repo, studyText)
+	if err != nil {
+		return err
+	}
+	return encodeBundle(w, stored.Bundle.compact(), format)
+}
+
+// importBundle creates or updates the draft study text named by the bundle's
+// version in one transaction, optionally publishing it. A dry run reports the
+// same changes and rolls them back. Published study texts are never changed.
+func (s *Server) importBundle(bundle StudyBundle, actor string, dryRun, publish bool) (BundleImport, error) {
+	var result BundleImport
+	err := s.repo.Transaction(func(repo store.Repository) error {
+		if err := applyBundle(repo, bundle, actor, &result); err != nil {
+			return err
+		}
+		if publish {
+			if _, err := publishStudyText(repo, result.StudyTextID, actor); err != nil {
+				return err
+			}
+			result.Published = true
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if dryRun && errors.Is(err, errDryRun) {
+		err = nil
+		if result.Created {
+			// The study text was rolled back
+			result.StudyTextID = 0
+		}
+	}
+	return result, err
+}
+
+// applyBundle syncs a draft with a normalized bundle. Passages are matched by
+// position and questions by ID within their passage; rows missing from the
+// bundle are deleted. Rows whose content changed get a new revision.
+func applyBundle(repo store.Repository, bundle StudyBundle, actor string, result *BundleImport) error {
+	change := func(action, entity, key string, id uint, changes []fieldChange) {
+		result.Changes = append(result.Changes, bundleChange{Action: action, Entity: entity, Key: key, ID: id, Changes: changes})
+	}
+
+	studyText, err := repo.StudyTexts().GetByVersion(bundle.Version)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	var stored storedBundle
+	if errors.Is(err, store.ErrNotFound) {
+		studyText = store.StudyText{
+			Version:        bundle.Version,
+			Content:        bundle.Content,
+			FontLeft:       bundle.FontLeft,
+			FontRight:      bundle.FontRight,
+			CompletionCode: bundle.CompletionCode,
+			CompletionURL:  bundle.CompletionURL,
+		}
+		if err := createDraft(repo, &studyText); err != nil {
+			return err
+		}
+		if err := writeAudit(repo, actor, auditCreate, entityStudyText, studyText.ID, nil, studyText); err != nil {
+			return err
+		}
+		result.Created = true
+		stored.Questions = map[int][]store.QuizQuestion{}
+		change(auditCreate, entityStudyText, bundle.Version, studyText.ID, nil)
+	} else {
+		if studyText.Status != studyTextDraft {
+			return ErrStudyTextPublished
+		}
+		var err error
+		if stored, err = loadBundle(repo, studyText); err != nil {
+			return err
+		}
+		changes, err := diffFields(stored.Bundle.header(), bundle.header(), "content")
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			result.Unchanged++
+		} else {
+			before := studyText
+			studyText.Content = bundle.Content
+			studyText.FontLeft = bundle.FontLeft
+			studyText.FontRight = bundle.FontRight
+			studyText.CompletionCode = bundle.CompletionCode
+			studyText.CompletionURL = bundle.CompletionURL
+			if err := repo.StudyTexts().Save(&studyText); err != nil {
+				return err
+			}
+			if err := writeAudit(repo, actor, auditUpdate, entityStudyText, studyText.ID, before, studyText); err != nil {
+				return err
+			}
+			change(auditUpdate, entityStudyText, bundle.Version, studyText.ID, changes)
+		}
+	}
+	result.StudyTextID = studyText.ID
+
+	// Passages, matched by position
+	passageIDs := make([]uint, len(bundle.Passages))
+	for i, incoming := range bundle.Passages {
+		key := fmt.Sprintf("passages[%d]", i)
+		if i >= len(stored.Passages) {
+			passage := store.Passage{
+				StudyTextID: studyText.ID,
+				Order:       i,
+				Title:       incoming.Title,
+				Content:     incoming.Content,
+				FontLeft:    incoming.FontLeft,
+				FontRight:   incoming.FontRight,
+			}
+			if err := repo.Passages().Create(&passage); err != nil {
+				return err
+			}
+			if err := repo.Passages().Revise(&passage, actor); err != nil {
+				return err
+			}
+			if err := writeAudit(repo, actor, auditCreate, entityPassage, passage.ID, nil, passage); err != nil {
+				return err
+			}
+			passageIDs[i] = passage.ID
+			change(auditCreate, entityPassage, key, passage.ID, nil)
+			continue
+		}
+
+		passage := stored.Passages[i]
+		passageIDs[i] = passage.ID
+		changes, err := diffFields(stored.Bundle.Passages[i].body(), incoming.body(), "content", "title")
+		if err != nil {
+			return err
+		}
+		if passage.Order != i {
+			changes = append(changes, fieldChange{Field: "order", From: passage.Order, To: i})
+		}
+		if len(changes) == 0 {
+			result.Unchanged++
+			continue
+		}
+		before := passage
+		passage.Order = i
+		passage.Title = incoming.Title
+		passage.Content = incoming.Content
+		passage.FontLeft = incoming.FontLeft
+		passage.FontRight = incoming.FontRight
+		if err := repo.Passages().Save(&passage); err != nil {
+			return err
+		}
+		if err := repo.Passages().Revise(&passage, actor); err != nil {
+			return err
+		}
+		if err := writeAudit(repo, actor, auditUpdate, entityPassage, passage.ID, before, passage); err != nil {
+			return err
+		}
+		change(auditUpdate, entityPassage, key, passage.ID, changes)
+	}
+
+	// Quiz questions, matched by ID within the study text (-1) or a passage
+	for position := -1; position < len(stored.Passages) || position < len(bundle.Passages); position++ {
+		var incoming []bundleQuestion
+		var passageID *uint
+		path := "quiz_questions"
+		if position < 0 {
+			incoming = bundle.QuizQuestions
+		} else {
+			path = fmt.Sprintf("passages[%d].quiz_questions", position)
+			if position < len(bundle.Passages) {
+				incoming = bundle.Passages[position].QuizQuestions
+				id := passageIDs[position]
+				passageID = &id
+			}
+		}
+
+		existing := make(map[string]store.QuizQuestion)
+		var leftover []store.QuizQuestion
+		for _, question := range stored.Questions[position] {
+			if _, ok := existing[question.QuestionID]; ok {
+				leftover = append(leftover, question)
+			} else {
+				existing[question.QuestionID] = question
+			}
+		}
+
+		for j, bq := range incoming {
+			key := path + "[" + bq.ID + "]"
+			choices, err := json.Marshal(bq.Choices)
+			if err != nil {
+				return err
+			}
+
+			question, ok := existing[bq.ID]
+			if !ok {
+				question = store.QuizQuestion{
+					StudyTextID: studyText.ID,
+					PassageID:   passageID,
+					QuestionID:  bq.ID,
+					Prompt:      bq.Prompt,
+					Choices:     string(choices),
+					Answer:      bq.Answer,
+					Order:       j + 1,
+				}
+				if err := repo.QuizQuestions().Create(&question); err != nil {
+					return err
+				}
+				if err := repo.QuizQuestions().Revise(&question, actor); err != nil {
+					return err
+				}
+				if err := writeAudit(repo, actor, auditCreate, entityQuizQuestion, question.ID, nil, question); err != nil {
+					return err
+				}
+				change(auditCreate, entityQuizQuestion, key, question.ID, nil)
+				continue
+			}
+			delete(existing, bq.ID)
+
+			changes, err := diffFields(newBundleQuestion(question), bq, "prompt")
+			if err != nil {
+				return err
+			}
+			if question.Order != j+1 {
+				changes = append(changes, fieldChange{Field: "order", From: question.Order, To: j + 1})
+			}
+			if len(changes) == 0 {
+				result.Unchanged++
+				continue
+			}
+			before := question
+			question.Prompt = bq.Prompt
+			question.Choices = string(choices)
+			question.Answer = bq.Answer
+			question.Order = j + 1
+			if err := repo.QuizQuestions().Save(&question); err != nil {
+				return err
+			}
+			if err := repo.QuizQuestions().Revise(&question, actor); err != nil {
+				return err
+			}
+			if err := writeAudit(repo, actor, auditUpdate, entityQuizQuestion, question.ID, before, question); err != nil {
+				return err
+			}
+			change(auditUpdate, entityQuizQuestion, key, question.ID, changes)
+		}
+
+		for _, question := range existing {
+			leftover = append(leftover, question)
+		}
+		sort.Slice(leftover, func(i, j int) bool { return leftover[i].ID < leftover[j].ID })
+		for _, question := range leftover {
+			if err := repo.QuizQuestions().Delete(&question); err != nil {
+				return err
+			}
+			if err := writeAudit(repo, actor, auditDelete, entityQuizQuestion, question.ID, question, nil); err != nil {
+				return err
+			}
+			change(auditDelete, entityQuizQuestion, path+"["+question.QuestionID+"]", question.ID, nil)
+		}
+	}
+
+	// Passages beyond the end of the bundle
+	for i := len(bundle.Passages); i < len(stored.Passages); i++ {
+		passage := stored.Passages[i]
+		if err := repo.Passages().Delete(&passage); err != nil {
+			return err
+		}
+		if err := writeAudit(repo, actor, auditDelete, entityPassage, passage.ID, passage, nil); err != nil {
+			return err
+		}
+		change(auditDelete, entityPassage, fmt.Sprintf("passages[%d]", i), passage.ID, nil)
+	}
+	return nil
+}
+
+// WriteBundleChanges prints the changes of an import as a readable diff.
+// Removed words are shown as [-...-] and added words as {+...+}.
+func WriteBundleChanges(w io.Writer, result BundleImport) {
+	symbols := map[string]string{auditCreate: "+", auditUpdate: "~", auditDelete: "-"}
+	for _, change := range result.Changes {
+		fmt.Fprintf(w, "%s %s %s\n", symbols[change.Action], change.Entity, change.Key)
+		for _, field := range change.Changes {
+			if field.Words == nil {
+				fmt.Fprintf(w, "    %s: %v -> %v\n", field.Field, field.From, field.To)
+				continue
+			}
+			words := make([]string, len(field.Words))
+			for i, chunk := range field.Words {
+				switch chunk.Op {
+				case analysis.DiffDelete:
+					words[i] = "[-" + chunk.Text + "-]"
+				case analysis.DiffInsert:
+					words[i] = "{+" + chunk.Text + "+}"
+				default:
+					words[i] = chunk.Text
+				}
+			}
+			fmt.Fprintf(w, "    %s: %s\n", field.Field, strings.Join(words, " "))
+		}
+	}
+	fmt.Fprintf(w, "%d changes, %d unchanged\n", len(result.Changes), result.Unchanged)
+}
+
+// handleAdminImportBundle imports a YAML or JSON bundle for
+// POST /api/admin/study-texts/import. ?dry_run=true only reports the changes;
+// ?publish=true publishes the study text afterwards.
+func (s *Server) handleAdminImportBundle(c echo.Context) error {
+	var flags [2]bool
+	for i, param := range []string{"dry_run", "publish"} {
+		if value := c.QueryParam(param); value != "" {
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": param + " must be true or false"})
+			}
+			flags[i] = flag
+		}
+	}
+	dryRun, publish := flags[0], flags[1]
+
+	// The body is limited by ingestion.max_bundle_size_mb
+	maxBundleSize := s.config.MaxBundleSize()
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, int64(maxBundleSize)+1))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Failed to read bundle: " + err.Error()})
+	}
+	if len(data) > maxBundleSize {
+		return c.JSON(413, map[string]string{"error": "Bundle is larger than " + strconv.Itoa(maxBundleSize>>20) + " MB"})
+	}
+
+	bundle, err := ParseBundle(data)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+	if err := bundle.normalize(fonts); err != nil {
+		var invalid *bundleError
+		if errors.As(err, &invalid) {
+			return c.JSON(400, map[string]interface{}{"error": "Invalid bundle", "problems": invalid.Problems})
+		}
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	result, err := s.importBundle(bundle, adminActor(c), dryRun, publish)
+	if err != nil {
+		if errors.Is(err, ErrStudyTextPublished) {
+			return c.JSON(409, map[string]string{"error": "Study text '" + bundle.Version + "' is published; import the bundle under a new version"})
+		}
+		return c.JSON(500, map[string]string{"error": "Failed to import bundle: " + err.Error()})
+	}
+
+	status := 200
+	if result.Created && !dryRun {
+		status = 201
+	}
+	changes := result.Changes
+	if changes == nil {
+		changes = []bundleChange{}
+	}
+	return c.JSON(status, map[string]interface{}{
+		"success":       true,
+		"dry_run":       dryRun,
+		"study_text_id": result.StudyTextID,
+		"version":       bundle.Version,
+		"created":       result.Created,
+		"published":     result.Published,
+		"changes":       changes,
+		"unchanged":     result.Unchanged,
+	})
+}
+
+// handleAdminExportBundle returns a study text as a bundle for
+// GET /api/admin/study-texts/:id/bundle?format=yaml|json
+func (s *Server) handleAdminExportBundle(c echo.Context) error {
+	studyTextID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "yaml"
+	}
+	if format != "yaml" && format != "json" {
+		return c.JSON(400, map[string]string{"error": "format must be yaml or json"})
+	}
+
+	studyText, err := s.repo.StudyTexts().Get(uint(studyTextID))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(404, map[string]string{"error": "Study text not found"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+	}
+	stored, err := loadBundle(s.repo, studyText)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+	}
+
+	var buf bytes.Buffer
+	if err := encodeBundle(&buf, stored.Bundle.compact(), format); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to encode bundle: " + err.Error()})
+	}
+	contentType := "application/yaml"
+	if format == "json" {
+		contentType = echo.MIMEApplicationJSON
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", studyText.Version+"."+format))
+	return c.Blob(200, contentType, buf.Bytes())
+}
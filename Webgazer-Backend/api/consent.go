@@ -0,0 +1,290 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var errConsentRequired = errors.New("participant has not accepted the current consent document")
+
+// activeConsentDocument returns the consent document participants must accept
+func (s *Server) activeConsentDocument() (store.ConsentDocument, bool, error) {
+	document, err := s.repo.Consent().ActiveDocument()
+	if errors.Is(err, store.ErrNotFound) {
+		return document, false, nil
+	}
+	return document, err == nil, err
+}
+
+// requireConsent checks that a participant accepted the active consent document.
+// When no document is active, no consent is asked for.
+func (s *Server) requireConsent(participantID uint) (store.ConsentDocument, error) {
+	document, found, err := s.activeConsentDocument()
+	if err != nil || !found {
+		return document, err
+	}
+
+	accepted, err := s.repo.Consent().HasAccepted(participantID, document.ID)
+	if err != nil {
+		return document, err
+	}
+	if !accepted {
+		return document, errConsentRequired
+	}
+	return document, nil
+}
+
+// respondConsentError turns a requireConsent error into a response
+func respondConsentError(c echo.Context, document store.ConsentDocument, err error) error {
+	if errors.Is(err, errConsentRequired) {
+		return c.JSON(403, map[string]string{
+			"error":           "Consent required: " + err.Error(),
+			"consent_version": document.Version,
+		})
+	}
+	return c.JSON(500, map[string]string{"error": "Failed to check consent: " + err.Error()})
+}
+
+// activateVersion makes one row of a versioned table (consent documents,
+// demographics schemas) the only active one
+func activateVersion(tx *gorm.DB, model interface{}, id uint) error {
+	if err := tx.Model(model).Where("active = ? AND id != ?", true, id).Update("active", false).Error; err != nil {
+		return err
+	}
+	return tx.Model(model).Where("id = ?", id).Update("active", true).Error
+}
+
+// handleConsent returns the active consent document (GET) or records a
+// participant's acceptance of it (POST)
+func (s *Server) handleConsent(c echo.Context) error {
+	switch c.Request().Method {
+	case "GET":
+		document, found, err := s.activeConsentDocument()
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to load consent document: " + err.Error()})
+		}
+		if !found {
+			return c.JSON(404, map[string]string{"error": "No consent document found"})
+		}
+		return c.JSON(200, map[string]interface{}{
+			"id":      document.ID,
+			"version": document.Version,
+			"title":   document.Title,
+			"body":    document.Body,
+		})
+
+	case "POST":
+		var request struct {
+			ParticipantID     uint   `json:"participant_id"`
+			ConsentDocumentID uint   `json:"consent_document_id"`
+			Version           string `json:"version"`
+			Accepted          bool   `json:"accepted"`
+		}
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if request.ParticipantID == 0 || (request.ConsentDocumentID == 0 && request.Version == "") {
+			return c.JSON(400, map[string]string{"error": "participant_id and consent_document_id or version are required"})
+		}
+		if !request.Accepted {
+			return c.JSON(400, map[string]string{"error": "Consent must be accepted to take part in the study"})
+		}
+
+		participant, err := s.repo.Participants().Get(request.ParticipantID)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Participant not found"})
+		}
+
+		// Participants can only accept the version that is currently shown
+		var document store.ConsentDocument
+		if request.ConsentDocumentID != 0 {
+			document, err = s.repo.Consent().GetDocument(request.ConsentDocumentID)
+		} else {
+			document, err = s.repo.Consent().GetDocumentByVersion(request.Version)
+		}
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Consent document not found"})
+		}
+		if !document.Active {
+			current, _, _ := s.activeConsentDocument()
+			return c.JSON(409, map[string]string{
+				"error":           "Consent document " + document.Version + " is no longer current",
+				"consent_version": current.Version,
+			})
+		}
+
+		// Accepting the same version again keeps the original record
+		status := 200
+		record, err := s.repo.Consent().GetRecord(participant.ID, document.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.JSON(500, map[string]string{"error": "Failed to look up consent: " + err.Error()})
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			record = store.ConsentRecord{
+				ParticipantID:     participant.ID,
+				ConsentDocumentID: document.ID,
+				Version:           document.Version,
+				AcceptedAt:        time.Now(),
+				UserAgent:         c.Request().UserAgent(),
+			}
+			if err := s.repo.Consent().AddRecord(&record); err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to save consent: " + err.Error()})
+			}
+			status = 201
+		}
+
+		return c.JSON(status, map[string]interface{}{
+			"success":     true,
+			"id":          record.ID,
+			"version":     record.Version,
+			"accepted_at": record.AcceptedAt,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handleAdminConsent lists (GET), adds (POST) and updates (PUT) consent document versions
+func (s *Server) handleAdminConsent(c echo.Context) error {
+	switch c.Request().Method {
+	case "GET":
+		var documents []store.ConsentDocument
+		if err := s.db.Order("created_at DESC").Find(&documents).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch consent documents: " + err.Error()})
+		}
+		var counts []struct {
+			ConsentDocumentID uint
+			Count             int64
+		}
+		if err := s.db.Model(&store.ConsentRecord{}).Select("consent_document_id, COUNT(*) as count").
+			Group("consent_document_id").Scan(&counts).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to count consent records: " + err.Error()})
+		}
+		accepted := make(map[uint]int64, len(counts))
+		for _, count := range counts {
+			accepted[count.ConsentDocumentID] = count.Count
+		}
+
+		type documentSummary struct {
+			store.ConsentDocument
+			Acceptances int64 `json:"acceptances"`
+		}
+		data := make([]documentSummary, len(documents))
+		for i, document := range documents {
+			data[i] = documentSummary{ConsentDocument: document, Acceptances: accepted[document.ID]}
+		}
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"data":    data,
+		})
+
+	case "POST":
+		var document store.ConsentDocument
+		if err := c.Bind(&document); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		document.ID = 0
+		document.Version = strings.TrimSpace(document.Version)
+		if document.Version == "" || strings.TrimSpace(document.Body) == "" {
+			return c.JSON(400, map[string]string{"error": "version and body are required"})
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&document).Error; err != nil {
+				return err
+			}
+			if document.Active {
+				if err := activateVersion(tx, &store.ConsentDocument{}, document.ID); err != nil {
+					return err
+				}
+			}
+			return recordAudit(s.store.Repository(tx), c, auditCreate, entityConsentDocument, document.ID, nil, document)
+		})
+		if err != nil {
+			if s.store.IsUniqueViolation(err) {
+				return c.JSON(409, map[string]string{"error": "Consent document version " + document.Version + " already exists"})
+			}
+			return c.JSON(500, map[string]string{"error": "Failed to create consent document: " + err.Error()})
+		}
+
+		return c.JSON(201, map[string]interface{}{
+			"success": true,
+			"id":      document.ID,
+			"message": "Consent document created successfully",
+		})
+
+	case "PUT":
+		var updateData struct {
+			ID     uint    `json:"id"`
+			Title  *string `json:"title,omitempty"`
+			Body   *string `json:"body,omitempty"`
+			Active *bool   `json:"active,omitempty"`
+		}
+		if err := c.Bind(&updateData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if updateData.ID == 0 {
+			return c.JSON(400, map[string]string{"error": "ID is required"})
+		}
+
+		var document store.ConsentDocument
+		if err := s.db.First(&document, updateData.ID).Error; err != nil {
+			return c.JSON(404, map[string]string{"error": "Consent document not found"})
+		}
+		before := document
+
+		// The text participants agreed to must stay as it was
+		if updateData.Title != nil || updateData.Body != nil {
+			var accepted int64
+			s.db.Model(&store.ConsentRecord{}).Where("consent_document_id = ?", document.ID).Count(&accepted)
+			if accepted > 0 {
+				return c.JSON(409, map[string]string{"error": "Consent document has been accepted by participants; create a new version instead"})
+			}
+			if updateData.Title != nil {
+				document.Title = *updateData.Title
+			}
+			if updateData.Body != nil {
+				if strings.TrimSpace(*updateData.Body) == "" {
+					return c.JSON(400, map[string]string{"error": "body must not be empty"})
+				}
+				document.Body = *updateData.Body
+			}
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&document).Select("title", "body").Updates(&document).Error; err != nil {
+				return err
+			}
+			if updateData.Active != nil {
+				if *updateData.Active {
+					if err := activateVersion(tx, &store.ConsentDocument{}, document.ID); err != nil {
+						return err
+					}
+				} else if err := tx.Model(&document).Update("active", false).Error; err != nil {
+					return err
+				}
+				document.Active = *updateData.Active
+			}
+			return recordAudit(s.store.Repository(tx), c, auditUpdate, entityConsentDocument, document.ID, before, document)
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to update consent document: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"id":      document.ID,
+			"message": "Consent document updated successfully",
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
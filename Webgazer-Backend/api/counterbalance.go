@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"math/rand"
+
+	"readability-backend/store"
+)
+
+// balancedLatinSquare returns a Williams design for n conditions: every
+// condition appears once in each position, and each condition follows every
+// other condition equally often. Odd n needs the mirrored rows as well.
+func balancedLatinSquare(n int) [][]int {
+	if n <= 0 {
+		return nil
+	}
+
+	// First row: 0, 1, n-1, 2, n-2, ...
+	first := make([]int, n)
+	for j, lo, hi := 1, 1, n-1; j < n; j++ {
+		if j%2 == 1 {
+			first[j] = lo
+			lo++
+		} else {
+			first[j] = hi
+			hi--
+		}
+	}
+
+	var square [][]int
+	for r := 0; r < n; r++ {
+		row := make([]int, n)
+		for j := range first {
+			row[j] = (first[j] + r) % n
+		}
+		square = append(square, row)
+	}
+	if n%2 == 1 {
+		for r := 0; r < n; r++ {
+			row := make([]int, n)
+			for j := range row {
+				row[j] = square[r][n-1-j]
+			}
+			square = append(square, row)
+		}
+	}
+	return square
+}
+
+// assignConditions picks the counterbalancing condition for a new session and
+// returns the resulting per-passage presentation. A condition is a row of the
+// balanced Latin square over passage order, crossed with which side the first
+// passage's fonts start on; sides then alternate with position. The passages'
+// font pairs rotate over the passages with the row, so every passage is read
+// in every pair. The least used condition for the study text is chosen,
+// breaking ties at random.
+func assignConditions(repo store.Repository, session *store.StudySession) ([]store.SessionCondition, error) {
+	if session.StudyTextID == nil {
+		return nil, nil
+	}
+
+	studyText, err := repo.StudyTexts().Get(*session.StudyTextID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	passages, err := repo.Passages().ForStudyText(studyText.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(passages) == 0 {
+		return nil, nil
+	}
+
+	square := balancedLatinSquare(len(passages))
+	conditionCount := len(square) * 2
+
+	usage, err := repo.Sessions().ConditionUsage(studyText.ID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int64, conditionCount)
+	for index, count := range usage {
+		if index >= 0 && index < conditionCount {
+			counts[index] = count
+		}
+	}
+	var leastUsed []int
+	for i, count := range counts {
+		if len(leastUsed) == 0 || count < counts[leastUsed[0]] {
+			leastUsed = []int{i}
+		} else if count == counts[leastUsed[0]] {
+			leastUsed = append(leastUsed, i)
+		}
+	}
+	condition := leastUsed[rand.Intn(len(leastUsed))]
+	session.ConditionIndex = &condition
+
+	// Font pairs in passage order, with the study text's fonts filling in
+	pairs := make([][2]string, len(passages))
+	for i, passage := range passages {
+		pairs[i] = [2]string{passage.FontLeft, passage.FontRight}
+		if pairs[i][0] == "" {
+			pairs[i][0] = studyText.FontLeft
+		}
+		if pairs[i][1] == "" {
+			pairs[i][1] = studyText.FontRight
+		}
+	}
+
+	row, swap := condition/2, condition%2
+	conditions := make([]store.SessionCondition, len(passages))
+	for position, passageIndex := range square[row] {
+		passage := passages[passageIndex]
+		pair := pairs[(passageIndex+row)%len(pairs)]
+		left, right := pair[0], pair[1]
+		if (position+swap)%2 == 1 {
+			left, right = right, left
+		}
+		conditions[position] = store.SessionCondition{
+			PassageID:         passage.ID,
+			PassageRevisionID: passage.RevisionID,
+			Position:          position,
+			FontLeft:          left,
+			FontRight:         right,
+		}
+	}
+	return conditions, nil
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"readability-backend/store"
+)
+
+func TestBalancedLatinSquare(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		square := balancedLatinSquare(n)
+		rows := n
+		if n%2 == 1 {
+			rows = 2 * n
+		}
+		if len(square) != rows {
+			t.Fatalf("n=%d: %d rows, want %d", n, len(square), rows)
+		}
+
+		// Every condition appears equally often in each position and after each other condition
+		positions := make(map[[2]int]int)
+		follows := make(map[[2]int]int)
+		for _, row := range square {
+			for j, condition := range row {
+				positions[[2]int{condition, j}]++
+				if j > 0 {
+					follows[[2]int{row[j-1], condition}]++
+				}
+			}
+		}
+		for pair, count := range positions {
+			if count != rows/n {
+				t.Errorf("n=%d: condition %d at position %d %d times, want %d", n, pair[0], pair[1], count, rows/n)
+			}
+		}
+		for pair, count := range follows {
+			if count != rows/n {
+				t.Errorf("n=%d: %d followed by %d %d times, want %d", n, pair[0], pair[1], count, rows/n)
+			}
+		}
+	}
+}
+
+func TestAssignConditions(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	var passages []store.Passage
+	if err := ts.db.Where("study_text_id = ?", 1).Find(&passages).Error; err != nil {
+		t.Fatal(err)
+	}
+	n := len(passages)
+	if n%2 == 1 {
+		t.Fatalf("the default study text has %d passages; this test expects an even number", n)
+	}
+
+	// Each passage should be read once in each of the study's font pairs, on each side
+	want := make(map[string]int)
+	for _, p := range passages {
+		want[p.FontLeft+"|"+p.FontRight]++
+		want[p.FontRight+"|"+p.FontLeft]++
+	}
+
+	// One full round of conditions: n orders, each with both starting sides
+	studyTextID := uint(1)
+	positions := make(map[string]int)              // "passage position"
+	presentations := make(map[uint]map[string]int) // passage -> "left|right"
+	seen := make(map[int]bool)
+	for i := 0; i < 2*n; i++ {
+		session := store.StudySession{SessionID: fmt.Sprintf("conditions-%d", i), StudyTextID: &studyTextID}
+		conditions, err := assignConditions(ts.repo, &session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ts.db.Create(&session).Error; err != nil {
+			t.Fatal(err)
+		}
+		if seen[*session.ConditionIndex] {
+			t.Errorf("condition %d assigned twice in one round", *session.ConditionIndex)
+		}
+		seen[*session.ConditionIndex] = true
+
+		for _, c := range conditions {
+			positions[fmt.Sprintf("%d %d", c.PassageID, c.Position)]++
+			if presentations[c.PassageID] == nil {
+				presentations[c.PassageID] = make(map[string]int)
+			}
+			presentations[c.PassageID][c.FontLeft+"|"+c.FontRight]++
+		}
+	}
+
+	if len(positions) != n*n {
+		t.Errorf("passages were shown at %d passage/position combinations, want %d", len(positions), n*n)
+	}
+	for key, count := range positions {
+		if count != 2 {
+			t.Errorf("passage/position %s: %d times, want 2", key, count)
+		}
+	}
+	for _, p := range passages {
+		if got := presentations[p.ID]; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("passage %d was shown with %v, want %v", p.ID, got, want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// defaultTextMaxLength limits text answers when a field sets no MaxLength
+const defaultTextMaxLength = 200
+
+// demographicsFieldError reports why one answer or field definition was rejected
+type demographicsFieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// validateDemographicsFields checks a questionnaire definition before it is stored
+func validateDemographicsFields(fields []store.DemographicsField) []demographicsFieldError {
+	var errs []demographicsFieldError
+	if len(fields) == 0 {
+		return []demographicsFieldError{{Error: "fields must contain at least one field"}}
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i, f := range fields {
+		name := f.Key
+		if name == "" {
+			name = fmt.Sprintf("fields[%d]", i)
+		}
+		switch {
+		case f.Key == "":
+			errs = append(errs, demographicsFieldError{Field: name, Error: "key is required"})
+		case seen[f.Key]:
+			errs = append(errs, demographicsFieldError{Field: name, Error: "duplicate key"})
+		}
+		seen[f.Key] = true
+
+		switch f.Type {
+		case store.FieldChoice:
+			if len(f.Options) == 0 {
+				errs = append(errs, demographicsFieldError{Field: name, Error: "choice fields need options"})
+			}
+		case store.FieldNumber:
+			if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+				errs = append(errs, demographicsFieldError{Field: name, Error: "min must not be greater than max"})
+			}
+		case store.FieldText:
+			if f.MaxLength < 0 {
+				errs = append(errs, demographicsFieldError{Field: name, Error: "max_length must not be negative"})
+			}
+		default:
+			errs = append(errs, demographicsFieldError{Field: name, Error: fmt.Sprintf("unknown type %q (use choice, number or text)", f.Type)})
+		}
+	}
+	return errs
+}
+
+// validateDemographicsAnswers checks answers against the questionnaire and
+// returns them cleaned up: text is trimmed and unanswered optional fields are dropped
+func validateDemographicsAnswers(fields []store.DemographicsField, answers map[string]interface{}) (map[string]interface{}, []demographicsFieldError) {
+	var errs []demographicsFieldError
+	cleaned := make(map[string]interface{}, len(fields))
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Key] = true
+
+		value, ok := answers[f.Key]
+		if text, isText := value.(string); isText && strings.TrimSpace(text) == "" {
+			ok = false
+		}
+		if !ok || value == nil {
+			if f.Required {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: "answer is required"})
+			}
+			continue
+		}
+
+		switch f.Type {
+		case store.FieldChoice:
+			choice, isText := value.(string)
+			valid := false
+			for _, option := range f.Options {
+				if isText && choice == option {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: "must be one of: " + strings.Join(f.Options, ", ")})
+				continue
+			}
+			cleaned[f.Key] = choice
+
+		case store.FieldNumber:
+			number, isNumber := value.(float64)
+			if !isNumber || math.IsNaN(number) || math.IsInf(number, 0) {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: "must be a number"})
+				continue
+			}
+			if (f.Min != nil && number < *f.Min) || (f.Max != nil && number > *f.Max) {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: "is out of range"})
+				continue
+			}
+			cleaned[f.Key] = number
+
+		case store.FieldText:
+			text, isText := value.(string)
+			if !isText {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: "must be text"})
+				continue
+			}
+			text = strings.TrimSpace(text)
+			maxLength := f.MaxLength
+			if maxLength == 0 {
+				maxLength = defaultTextMaxLength
+			}
+			if len([]rune(text)) > maxLength {
+				errs = append(errs, demographicsFieldError{Field: f.Key, Error: fmt.Sprintf("must be at most %d characters", maxLength)})
+				continue
+			}
+			cleaned[f.Key] = text
+		}
+	}
+
+	for key := range answers {
+		if !known[key] {
+			errs = append(errs, demographicsFieldError{Field: key, Error: "unknown field"})
+		}
+	}
+	return cleaned, errs
+}
+
+// activeDemographicsSchema returns the questionnaire participants are asked to fill in
+func (s *Server) activeDemographicsSchema() (store.DemographicsSchema, []store.DemographicsField, bool, error) {
+	schema, err := s.repo.Demographics().ActiveSchema()
+	if errors.Is(err, store.ErrNotFound) {
+		return schema, nil, false, nil
+	}
+	if err != nil {
+		return schema, nil, false, err
+	}
+	var fields []store.DemographicsField
+	if err := json.Unmarshal([]byte(schema.Fields), &fields); err != nil {
+		return schema, nil, true, fmt.Errorf("demographics schema %s has invalid fields: %w", schema.Version, err)
+	}
+	return schema, fields, true, nil
+}
+
+// handleDemographics returns the active questionnaire (GET) or stores a
+// participant's answers (POST). Answers can only be given after consent and
+// replace earlier answers of the same participant.
+func (s *Server) handleDemographics(c echo.Context) error {
+	switch c.Request().Method {
+	case "GET":
+		schema, fields, found, err := s.activeDemographicsSchema()
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to load demographics questionnaire: " + err.Error()})
+		}
+		if !found {
+			return c.JSON(404, map[string]string{"error": "No demographics questionnaire found"})
+		}
+		return c.JSON(200, map[string]interface{}{
+			"id":      schema.ID,
+			"version": schema.Version,
+			"fields":  fields,
+		})
+
+	case "POST":
+		var request struct {
+			ParticipantID uint                   `json:"participant_id"`
+			Answers       map[string]interface{} `json:"answers"`
+		}
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if request.ParticipantID == 0 {
+			return c.JSON(400, map[string]string{"error": "participant_id is required"})
+		}
+
+		participant, err := s.repo.Participants().Get(request.ParticipantID)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Participant not found"})
+		}
+		if document, err := s.requireConsent(participant.ID); err != nil {
+			return respondConsentError(c, document, err)
+		}
+
+		schema, fields, found, err := s.activeDemographicsSchema()
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to load demographics questionnaire: " + err.Error()})
+		}
+		if !found {
+			return c.JSON(404, map[string]string{"error": "No demographics questionnaire found"})
+		}
+
+		answers, errs := validateDemographicsAnswers(fields, request.Answers)
+		if len(errs) > 0 {
+			return c.JSON(400, map[string]interface{}{
+				"error":  "Invalid demographics answers",
+				"errors": errs,
+			})
+		}
+		encoded, err := json.Marshal(answers)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to encode answers: " + err.Error()})
+		}
+
+		response, err := s.repo.Demographics().GetResponse(participant.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.JSON(500, map[string]string{"error": "Failed to look up demographics: " + err.Error()})
+		}
+		existing := err == nil
+		response.ParticipantID = participant.ID
+		response.DemographicsSchemaID = schema.ID
+		response.Version = schema.Version
+		response.Answers = string(encoded)
+		if err := s.repo.Demographics().SaveResponse(&response); err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to save demographics: " + err.Error()})
+		}
+
+		status := 201
+		if existing {
+			status = 200
+		}
+		return c.JSON(status, map[string]interface{}{
+			"success": true,
+			"id":      response.ID,
+			"version": response.Version,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// demographicsSchemaJSON is a questionnaire version with its fields decoded,
+// as kept in the audit log
+type demographicsSchemaJSON struct {
+	store.DemographicsSchema
+	Fields []store.DemographicsField `json:"fields"`
+}
+
+func newDemographicsSchemaJSON(schema store.DemographicsSchema) demographicsSchemaJSON {
+	data := demographicsSchemaJSON{DemographicsSchema: schema}
+	json.Unmarshal([]byte(schema.Fields), &data.Fields)
+	return data
+}
+
+// handleAdminDemographics lists (GET), adds (POST) and updates (PUT) demographics questionnaire versions
+func (s *Server) handleAdminDemographics(c echo.Context) error {
+	switch c.Request().Method {
+	case "GET":
+		var schemas []store.DemographicsSchema
+		if err := s.db.Order("created_at DESC").Find(&schemas).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch demographics questionnaires: " + err.Error()})
+		}
+		var counts []struct {
+			DemographicsSchemaID uint
+			Count                int64
+		}
+		if err := s.db.Model(&store.DemographicsResponse{}).Select("demographics_schema_id, COUNT(*) as count").
+			Group("demographics_schema_id").Scan(&counts).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to count demographics responses: " + err.Error()})
+		}
+		answered := make(map[uint]int64, len(counts))
+		for _, count := range counts {
+			answered[count.DemographicsSchemaID] = count.Count
+		}
+
+		type schemaSummary struct {
+			store.DemographicsSchema
+			Fields    []store.DemographicsField `json:"fields"`
+			Responses int64                     `json:"responses"`
+		}
+		data := make([]schemaSummary, len(schemas))
+		for i, schema := range schemas {
+			data[i] = schemaSummary{DemographicsSchema: schema, Responses: answered[schema.ID]}
+			if err := json.Unmarshal([]byte(schema.Fields), &data[i].Fields); err != nil {
+				return c.JSON(500, map[string]string{"error": "Invalid fields in questionnaire " + schema.Version})
+			}
+		}
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"data":    data,
+		})
+
+	case "POST":
+		var request struct {
+			Version string                    `json:"version"`
+			Fields  []store.DemographicsField `json:"fields"`
+			Active  bool                      `json:"active"`
+		}
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		request.Version = strings.TrimSpace(request.Version)
+		if request.Version == "" {
+			return c.JSON(400, map[string]string{"error": "version is required"})
+		}
+		if errs := validateDemographicsFields(request.Fields); len(errs) > 0 {
+			return c.JSON(400, map[string]interface{}{
+				"error":  "Invalid demographics fields",
+				"errors": errs,
+			})
+		}
+		encoded, err := json.Marshal(request.Fields)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to encode fields: " + err.Error()})
+		}
+
+		schema := store.DemographicsSchema{Version: request.Version, Fields: string(encoded), Active: request.Active}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&schema).Error; err != nil {
+				return err
+			}
+			if schema.Active {
+				if err := activateVersion(tx, &store.DemographicsSchema{}, schema.ID); err != nil {
+					return err
+				}
+			}
+			return recordAudit(s.store.Repository(tx), c, auditCreate, entityDemographicsSchema, schema.ID, nil, newDemographicsSchemaJSON(schema))
+		})
+		if err != nil {
+			if s.store.IsUniqueViolation(err) {
+				return c.JSON(409, map[string]string{"error": "Demographics questionnaire version " + schema.Version + " already exists"})
+			}
+			return c.JSON(500, map[string]string{"error": "Failed to create demographics questionnaire: " + err.Error()})
+		}
+
+		return c.JSON(201, map[string]interface{}{
+			"success": true,
+			"id":      schema.ID,
+			"message": "Demographics questionnaire created successfully",
+		})
+
+	case "PUT":
+		var updateData struct {
+			ID     uint                      `json:"id"`
+			Fields []store.DemographicsField `json:"fields,omitempty"`
+			Active *bool                     `json:"active,omitempty"`
+		}
+		if err := c.Bind(&updateData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if updateData.ID == 0 {
+			return c.JSON(400, map[string]string{"error": "ID is required"})
+		}
+
+		var schema store.DemographicsSchema
+		if err := s.db.First(&schema, updateData.ID).Error; err != nil {
+			return c.JSON(404, map[string]string{"error": "Demographics questionnaire not found"})
+		}
+		before := newDemographicsSchemaJSON(schema)
+
+		// Answered questionnaires must stay as participants saw them
+		if updateData.Fields != nil {
+			var answered int64
+			s.db.Model(&store.DemographicsResponse{}).Where("demographics_schema_id = ?", schema.ID).Count(&answered)
+			if answered > 0 {
+				return c.JSON(409, map[string]string{"error": "Demographics questionnaire has responses; create a new version instead"})
+			}
+			if errs := validateDemographicsFields(updateData.Fields); len(errs) > 0 {
+				return c.JSON(400, map[string]interface{}{
+					"error":  "Invalid demographics fields",
+					"errors": errs,
+				})
+			}
+			encoded, err := json.Marshal(updateData.Fields)
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to encode fields: " + err.Error()})
+			}
+			schema.Fields = string(encoded)
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&schema).Update("fields", schema.Fields).Error; err != nil {
+				return err
+			}
+			if updateData.Active != nil {
+				if *updateData.Active {
+					if err := activateVersion(tx, &store.DemographicsSchema{}, schema.ID); err != nil {
+						return err
+					}
+				} else if err := tx.Model(&schema).Update("active", false).Error; err != nil {
+					return err
+				}
+				schema.Active = *updateData.Active
+			}
+			return recordAudit(s.store.Repository(tx), c, auditUpdate, entityDemographicsSchema, schema.ID, before, newDemographicsSchemaJSON(schema))
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to update demographics questionnaire: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"id":      schema.ID,
+			"message": "Demographics questionnaire updated successfully",
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Erasure actions, also used as ErasureRecord.Action
+const (
+	ErasureDelete    = "delete"    // remove the participant and everything recorded for them
+	ErasureAnonymize = "anonymize" // keep the research data, remove what links it to a person
+	erasureRetention = "retention" // scheduled purge of identifying metadata
+)
+
+// erasureSessionTables lists every table holding per-session rows. Unlike
+// exportTables it includes derived analysis results, which are deleted too.
+var erasureSessionTables = []string{
+	"calibration_data",
+	"accuracy_measurements",
+	"gaze_points",
+	"reading_events",
+	"quiz_responses",
+	"aois",
+	"session_conditions",
+	"font_comparisons",
+	"session_qualities",
+	"fixations",
+	"saccades",
+	"fixation_runs",
+}
+
+// erasureParticipantTables lists the tables holding per-participant rows
+var erasureParticipantTables = []string{
+	"consent_records",
+	"demographics_responses",
+}
+
+var ErrParticipantNotFound = errors.New("participant not found")
+
+// ErasureResult summarizes what an erasure removed or changed
+type ErasureResult struct {
+	Record store.ErasureRecord
+	Rows   map[string]int64
+}
+
+// writeErasureRecord stores the audit record of an erasure or purge
+func writeErasureRecord(tx *gorm.DB, record *store.ErasureRecord, rows map[string]int64) error {
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	record.Rows = string(encoded)
+	return tx.Create(record).Error
+}
+
+// EraseParticipant deletes (erasureDelete) or anonymizes (erasureAnonymize) a
+// participant in one transaction and records the erasure. Anonymizing keeps
+// gaze, reading and quiz data but removes platform IDs, user agents,
+// completion codes and demographics answers.
+func (s *Server) EraseParticipant(participantID uint, action, reason, requestedBy string) (ErasureResult, error) {
+	result := ErasureResult{Rows: map[string]int64{}}
+	if action != ErasureDelete && action != ErasureAnonymize {
+		return result, errors.New("mode must be delete or anonymize")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var participant store.Participant
+		found := tx.Limit(1).Find(&participant, participantID)
+		if found.Error != nil {
+			return found.Error
+		}
+		if found.RowsAffected == 0 {
+			return ErrParticipantNotFound
+		}
+
+		var sessionIDs []uint
+		if err := tx.Model(&store.StudySession{}).Where("participant_id = ?", participant.ID).Pluck("id", &sessionIDs).Error; err != nil {
+			return err
+		}
+
+		if action == ErasureDelete {
+			if len(sessionIDs) > 0 {
+				for _, table := range erasureSessionTables {
+					deleted := tx.Exec("DELETE FROM "+table+" WHERE session_id IN ?", sessionIDs)
+					if deleted.Error != nil {
+						return deleted.Error
+					}
+					result.Rows[table] = deleted.RowsAffected
+				}
+			}
+			for _, table := range erasureParticipantTables {
+				deleted := tx.Exec("DELETE FROM "+table+" WHERE participant_id = ?", participant.ID)
+				if deleted.Error != nil {
+					return deleted.Error
+				}
+				result.Rows[table] = deleted.RowsAffected
+			}
+			deleted := tx.Where("participant_id = ?", participant.ID).Delete(&store.StudySession{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.Rows["study_sessions"] = deleted.RowsAffected
+			if err := tx.Delete(&participant).Error; err != nil {
+				return err
+			}
+			result.Rows["participants"] = 1
+		} else {
+			updated := tx.Model(&store.StudySession{}).Where("participant_id = ?", participant.ID).
+				Updates(map[string]interface{}{"user_agent": "", "completion_code": ""})
+			if updated.Error != nil {
+				return updated.Error
+			}
+			result.Rows["study_sessions"] = updated.RowsAffected
+
+			updated = tx.Model(&store.ConsentRecord{}).Where("participant_id = ?", participant.ID).Update("user_agent", "")
+			if updated.Error != nil {
+				return updated.Error
+			}
+			result.Rows["consent_records"] = updated.RowsAffected
+
+			deleted := tx.Where("participant_id = ?", participant.ID).Delete(&store.DemographicsResponse{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.Rows["demographics_responses"] = deleted.RowsAffected
+
+			now := time.Now()
+			err := tx.Model(&participant).Updates(map[string]interface{}{
+				"external_id":         nil,
+				"external_study_id":   "",
+				"external_session_id": "",
+				"anonymized_at":       now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			result.Rows["participants"] = 1
+		}
+
+		result.Record = store.ErasureRecord{
+			Action:        action,
+			ParticipantID: &participant.ID,
+			Sessions:      len(sessionIDs),
+			Reason:        reason,
+			RequestedBy:   requestedBy,
+		}
+		if err := writeErasureRecord(tx, &result.Record, result.Rows); err != nil {
+			return err
+		}
+		// The audit entry points to the erasure record; it must not copy the erased data
+		return writeAudit(s.store.Repository(tx), requestedBy, action, entityParticipant, participant.ID, nil, map[string]interface{}{
+			"erasure_record_id": result.Record.ID,
+			"sessions":          result.Record.Sessions,
+			"rows":              result.Rows,
+		})
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// PurgeMetadata clears identifying metadata older than the retention period:
+// user agents of sessions and consent records, and the platform session IDs of
+// participants without a newer session. The study data itself is kept.
+func (s *Server) PurgeMetadata(retention time.Duration, requestedBy string) (map[string]int64, error) {
+	cutoff := time.Now().Add(-retention)
+	rows := map[string]int64{}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updated := tx.Model(&store.StudySession{}).
+			Where("user_agent != '' AND created_at < ?", cutoff).
+			Update("user_agent", "")
+		if updated.Error != nil {
+			return updated.Error
+		}
+		rows["study_sessions"] = updated.RowsAffected
+
+		updated = tx.Model(&store.ConsentRecord{}).
+			Where("user_agent != '' AND accepted_at < ?", cutoff).
+			Update("user_agent", "")
+		if updated.Error != nil {
+			return updated.Error
+		}
+		rows["consent_records"] = updated.RowsAffected
+
+		recent := tx.Model(&store.StudySession{}).Select("participant_id").Where("created_at >= ?", cutoff)
+		updated = tx.Model(&store.Participant{}).
+			Where("external_session_id != '' AND created_at < ?", cutoff).
+			Where("id NOT IN (?)", recent).
+			Update("external_session_id", "")
+		if updated.Error != nil {
+			return updated.Error
+		}
+		rows["participants"] = updated.RowsAffected
+
+		if rows["study_sessions"]+rows["consent_records"]+rows["participants"] == 0 {
+			return nil
+		}
+		return writeErasureRecord(tx, &store.ErasureRecord{
+			Action:      erasureRetention,
+			Reason:      "metadata older than " + strconv.Itoa(int(retention.Hours()/24)) + " days",
+			RequestedBy: requestedBy,
+		}, rows)
+	})
+	return rows, err
+}
+
+// StartRetentionPurge purges expired metadata at startup and then hourly
+func (s *Server) StartRetentionPurge(retention time.Duration) {
+	if retention <= 0 {
+		log.Println("study.metadata_retention_days is 0, identifying metadata will be kept")
+		return
+	}
+
+	purge := func() {
+		rows, err := s.PurgeMetadata(retention, erasureRetention)
+		if err != nil {
+			log.Printf("Metadata purge failed: %v", err)
+			return
+		}
+		if total := rows["study_sessions"] + rows["consent_records"] + rows["participants"]; total > 0 {
+			log.Printf("Purged identifying metadata from %d rows", total)
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
+
+// handleAdminErasure lists erasure records (GET) or erases a participant (POST).
+// The participant is given by participant_id, or by external_id (PROLIFIC_PID
+// or workerId) with an optional source.
+func (s *Server) handleAdminErasure(c echo.Context) error {
+	switch c.Request().Method {
+	case "GET":
+		var records []store.ErasureRecord
+		if err := s.db.Order("created_at DESC").Find(&records).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch erasure records: " + err.Error()})
+		}
+
+		type erasureSummary struct {
+			store.ErasureRecord
+			Rows map[string]int64 `json:"rows"`
+		}
+		data := make([]erasureSummary, len(records))
+		for i, record := range records {
+			data[i] = erasureSummary{ErasureRecord: record}
+			json.Unmarshal([]byte(record.Rows), &data[i].Rows)
+		}
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"data":    data,
+		})
+
+	case "POST":
+		var request struct {
+			ParticipantID uint   `json:"participant_id"`
+			ExternalID    string `json:"external_id"`
+			Source        string `json:"source"`
+			Mode          string `json:"mode"`
+			Reason        string `json:"reason"`
+		}
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if request.Mode == "" {
+			request.Mode = ErasureDelete
+		}
+		if request.Mode != ErasureDelete && request.Mode != ErasureAnonymize {
+			return c.JSON(400, map[string]string{"error": "mode must be delete or anonymize"})
+		}
+
+		participantID := request.ParticipantID
+		if participantID == 0 {
+			externalID := strings.TrimSpace(request.ExternalID)
+			if externalID == "" {
+				return c.JSON(400, map[string]string{"error": "participant_id or external_id is required"})
+			}
+			query := s.db.Model(&store.Participant{}).Where("external_id = ?", externalID)
+			if source := strings.ToLower(strings.TrimSpace(request.Source)); source != "" {
+				query = query.Where("source = ?", source)
+			}
+			var ids []uint
+			if err := query.Pluck("id", &ids).Error; err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to look up participant: " + err.Error()})
+			}
+			if len(ids) == 0 {
+				return c.JSON(404, map[string]string{"error": "Participant not found"})
+			}
+			if len(ids) > 1 {
+				return c.JSON(409, map[string]string{"error": "external_id matches participants of several sources; give source as well"})
+			}
+			participantID = ids[0]
+		}
+
+		claims := c.Get("admin").(*adminClaims)
+		result, err := s.EraseParticipant(participantID, request.Mode, request.Reason, claims.Username)
+		if err != nil {
+			if errors.Is(err, ErrParticipantNotFound) {
+				return c.JSON(404, map[string]string{"error": "Participant not found"})
+			}
+			return c.JSON(500, map[string]string{"error": "Failed to erase participant: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success":        true,
+			"id":             result.Record.ID,
+			"participant_id": participantID,
+			"mode":           request.Mode,
+			"sessions":       result.Record.Sessions,
+			"rows":           result.Rows,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
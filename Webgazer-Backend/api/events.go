@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Live event types, one per kind of stored record
+const (
+	eventSession      = "session"       // a session was created
+	eventStatus       = "status"        // a session moved to another lifecycle status
+	eventCalibration  = "calibration"   // a calibration click
+	eventAccuracy     = "accuracy"      // an accuracy measurement
+	eventGazePoint    = "gaze_point"    // a single gaze point
+	eventGazePoints   = "gaze_points"   // a batch of gaze points
+	eventReadingEvent = "reading_event" // a reading milestone
+	eventQuizResponse = "quiz_response" // a graded quiz answer
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it
+const eventBufferSize = 256
+
+// eventHeartbeatInterval keeps idle streams open through proxies
+const eventHeartbeatInterval = 15 * time.Second
+
+// sessionEvent is a live notification about data stored for a session
+type sessionEvent struct {
+	ID        uint64      `json:"id"` // increases by one per published event
+	Type      string      `json:"type"`
+	SessionID uint        `json:"session_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// eventSubscriber receives the events of one session, or of all sessions
+type eventSubscriber struct {
+	sessionID uint            // 0 subscribes to every session
+	types     map[string]bool // nil subscribes to every event type
+	events    chan sessionEvent
+	dropped   atomic.Int64 // events skipped because the buffer was full
+}
+
+// eventBus fans out inserts to live subscribers. Publishing never blocks: a
+// subscriber that can't keep up misses events and is told how many. The bus
+// lives in one server's memory, so with several instances on one database a
+// subscriber only sees what was stored through its own instance.
+type eventBus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[*eventSubscriber]struct{}
+}
+
+func (b *eventBus) subscribe(sessionID uint, types map[string]bool) *eventSubscriber {
+	s := &eventSubscriber{sessionID: sessionID, types: types, events: make(chan sessionEvent, eventBufferSize)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[*eventSubscriber]struct{})
+	}
+	b.subscribers[s] = struct{}{}
+	return s
+}
+
+func (b *eventBus) unsubscribe(s *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, s)
+}
+
+// publish sends an event to every subscriber of the session or of all
+// sessions that wants events of this type
+func (b *eventBus) publish(eventType string, sessionID uint, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	event := sessionEvent{ID: b.nextID, Type: eventType, SessionID: sessionID, Timestamp: time.Now(), Data: data}
+	for s := range b.subscribers {
+		if (s.sessionID != 0 && s.sessionID != sessionID) || (s.types != nil && !s.types[eventType]) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// gazeEventPoint is the event payload for one gaze point
+func gazeEventPoint(p store.GazePoint) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        p.ID,
+		"x":         p.X,
+		"y":         p.Y,
+		"panel":     p.Panel,
+		"phase":     p.Phase,
+		"timestamp": p.Timestamp,
+	}
+}
+
+// writeSSE writes one Server-Sent Events message
+func writeSSE(res *echo.Response, id uint64, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(res, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", eventType, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+// handleAdminEventsToken issues a short-lived token that only opens the event
+// stream, for clients such as EventSource that can't set headers
+func (s *Server) handleAdminEventsToken(c echo.Context) error {
+	claims := c.Get("admin").(*adminClaims)
+	var user store.AdminUser
+	if err := s.db.Where("username = ?", claims.Username).First(&user).Error; err != nil {
+		return c.JSON(401, map[string]string{"error": "Invalid or expired token"})
+	}
+
+	token, expiresAt, err := s.issueScopedToken(user, eventsTokenScope, eventsTokenTTL)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to issue token: " + err.Error()})
+	}
+	return c.JSON(200, map[string]interface{}{
+		"success":    true,
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// handleAdminEvents streams live inserts as Server-Sent Events. ?session_id
+// limits the stream to one session and ?types to a comma-separated list of
+// event types; otherwise every event from every session is sent.
+func (s *Server) handleAdminEvents(c echo.Context) error {
+	var sessionID uint
+	if value := c.QueryParam("session_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			return c.JSON(400, map[string]string{"error": "Invalid session ID"})
+		}
+		var session store.StudySession
+		if err := s.db.First(&session, id).Error; err != nil {
+			return c.JSON(404, map[string]string{"error": "Session not found"})
+		}
+		sessionID = session.ID
+	}
+
+	var types map[string]bool
+	if value := c.QueryParam("types"); value != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(value, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case eventSession, eventStatus, eventCalibration, eventAccuracy, eventGazePoint, eventGazePoints, eventReadingEvent, eventQuizResponse:
+				types[t] = true
+			default:
+				return c.JSON(400, map[string]string{"error": "unknown event type: " + t})
+			}
+		}
+	}
+
+	subscriber := s.events.subscribe(sessionID, types)
+	defer s.events.unsubscribe(subscriber)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	res.WriteHeader(200)
+
+	if err := writeSSE(res, 0, "ready", map[string]interface{}{"session_id": sessionID}); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+
+		case event := <-subscriber.events:
+			// Tell the client before the next event if it missed any
+			if dropped := subscriber.dropped.Swap(0); dropped > 0 {
+				if err := writeSSE(res, 0, "dropped", map[string]int64{"count": dropped}); err != nil {
+					return nil
+				}
+			}
+			if err := writeSSE(res, event.ID, event.Type, event); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// exportFlushEvery controls how often rows are flushed to the client while streaming
+const exportFlushEvery = 1000
+
+// exportTables lists the per-session child tables included in an export, in output order
+var exportTables = []string{
+	"calibration_data",
+	"accuracy_measurements",
+	"gaze_points",
+	"reading_events",
+	"quiz_responses",
+	"aois",
+	"session_conditions",
+	"font_comparisons",
+	"session_qualities",
+}
+
+// exportParticipantTables lists the per-participant tables included for the
+// participants of the exported sessions, in output order
+var exportParticipantTables = []string{
+	"consent_records",
+	"demographics_responses",
+}
+
+// exportRevisionTables lists the content revisions included because an
+// exported row references them, in output order
+var exportRevisionTables = []struct {
+	Table, Referrer, Column string
+}{
+	{"passage_revisions", "session_conditions", "passage_revision_id"},
+	{"quiz_question_revisions", "quiz_responses", "question_revision_id"},
+}
+
+// exportFilter narrows an export down to a subset of study sessions
+type exportFilter struct {
+	From    *time.Time
+	To      *time.Time
+	Source  string
+	Version string
+	Exclude bool // leave out sessions excluded by the quality checks
+}
+
+// parseExportTime accepts either RFC 3339 timestamps or plain YYYY-MM-DD dates
+func parseExportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC 3339)", value)
+}
+
+func (s *Server) parseExportFilter(c echo.Context) (exportFilter, error) {
+	var filter exportFilter
+	var err error
+
+	if filter.From, err = parseExportTime(c.QueryParam("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseExportTime(c.QueryParam("to")); err != nil {
+		return filter, err
+	}
+	// A plain date for "to" means the whole day is included
+	if filter.To != nil && len(c.QueryParam("to")) == len("2006-01-02") {
+		end := filter.To.Add(24 * time.Hour)
+		filter.To = &end
+	}
+	filter.Source = c.QueryParam("source")
+	filter.Version = c.QueryParam("version")
+	filter.Exclude, err = s.parseExcludeParam(c)
+	return filter, err
+}
+
+// sessionQuery selects the sessions matching the filter, joined with their
+// participant source and study text version
+func (s *Server) sessionQuery(f exportFilter) *gorm.DB {
+	query := s.db.Table("study_sessions").
+		Joins("LEFT JOIN participants ON participants.id = study_sessions.participant_id").
+		Joins("LEFT JOIN study_texts ON study_texts.id = study_sessions.study_text_id")
+
+	if f.From != nil {
+		query = query.Where("study_sessions.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("study_sessions.created_at < ?", *f.To)
+	}
+	if f.Source != "" {
+		query = query.Where("participants.source = ?", f.Source)
+	}
+	if f.Version != "" {
+		query = query.Where("study_texts.version = ?", f.Version)
+	}
+	return s.withoutExcluded(query, "study_sessions.id", f.Exclude)
+}
+
+// exportQueries returns one query per exported table, keyed by table name, in output order
+func (s *Server) exportQueries(f exportFilter) ([]string, map[string]*gorm.DB) {
+	names := append([]string{"study_sessions"}, exportTables...)
+	names = append(names, exportParticipantTables...)
+	for _, revisions := range exportRevisionTables {
+		names = append(names, revisions.Table)
+	}
+	queries := map[string]*gorm.DB{
+		"study_sessions": s.sessionQuery(f).
+			Select("study_sessions.*, participants.source AS participant_source, study_texts.version AS study_text_version").
+			Order("study_sessions.id ASC"),
+	}
+
+	sessionIDs := s.sessionQuery(f).Select("study_sessions.id")
+	for _, table := range exportTables {
+		queries[table] = s.db.Table(table).Where("session_id IN (?)", sessionIDs).Order("id ASC")
+	}
+	participantIDs := s.sessionQuery(f).Select("study_sessions.participant_id")
+	for _, table := range exportParticipantTables {
+		queries[table] = s.db.Table(table).Where("participant_id IN (?)", participantIDs).Order("id ASC")
+	}
+	for _, revisions := range exportRevisionTables {
+		referenced := s.db.Table(revisions.Referrer).Select(revisions.Column).Where("session_id IN (?)", sessionIDs)
+		queries[revisions.Table] = s.db.Table(revisions.Table).Where("id IN (?)", referenced).Order("id ASC")
+	}
+	return names, queries
+}
+
+// exportValue converts a scanned column value into something that encodes cleanly
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// exportCell formats a scanned column value as a CSV cell
+func exportCell(value interface{}) string {
+	switch v := exportValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// streamRows runs query and calls emit for every row without loading the result set into memory
+func streamRows(query *gorm.DB, emit func(columns []string, values []interface{}) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	// Emit a header-only call so empty tables still produce a CSV header
+	if err := emit(columns, nil); err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if err := emit(columns, values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Server) handleAdminExport(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "csv" && format != "jsonl" {
+		return c.JSON(400, map[string]string{"error": "format must be csv or jsonl"})
+	}
+
+	filter, err := s.parseExportFilter(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	names, queries := s.exportQueries(filter)
+	filename := "readability-export-" + time.Now().UTC().Format("20060102-150405")
+	res := c.Response()
+
+	if format == "csv" {
+		res.Header().Set(echo.HeaderContentType, "application/zip")
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+		res.WriteHeader(200)
+		err = exportCSV(res, names, queries)
+	} else {
+		res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".jsonl"))
+		res.WriteHeader(200)
+		err = exportJSONL(res, names, queries)
+	}
+
+	// Headers are already sent, so a failure can only be logged and the stream cut short
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+	}
+	return nil
+}
+
+// exportCSV writes one CSV file per table into a zip archive streamed to res
+func exportCSV(res *echo.Response, names []string, queries map[string]*gorm.DB) error {
+	archive := zip.NewWriter(res)
+	for _, name := range names {
+		file, err := archive.Create(name + ".csv")
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(file)
+
+		count := 0
+		err = streamRows(queries[name], func(columns []string, values []interface{}) error {
+			if values == nil {
+				return writer.Write(columns)
+			}
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = exportCell(value)
+			}
+			count++
+			if count%exportFlushEvery == 0 {
+				writer.Flush()
+				res.Flush()
+			}
+			return writer.Write(record)
+		})
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", name, err)
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// exportJSONL writes every row as a {"table": ..., "data": {...}} line
+func exportJSONL(res *echo.Response, names []string, queries map[string]*gorm.DB) error {
+	encoder := json.NewEncoder(res)
+	for _, name := range names {
+		count := 0
+		err := streamRows(queries[name], func(columns []string, values []interface{}) error {
+			if values == nil {
+				return nil
+			}
+			row := make(map[string]interface{}, len(columns))
+			for i, column := range columns {
+				row[column] = exportValue(values[i])
+			}
+			count++
+			if count%exportFlushEvery == 0 {
+				res.Flush()
+			}
+			return encoder.Encode(map[string]interface{}{"table": name, "data": row})
+		})
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", name, err)
+		}
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// sessionGazeSamples loads a session's gaze points as analysis samples, ordered by time
+func (s *Server) sessionGazeSamples(sessionID uint) ([]analysis.Sample, error) {
+	var points []store.GazePoint
+	if err := s.db.Where("session_id = ?", sessionID).Order("timestamp ASC, id ASC").Find(&points).Error; err != nil {
+		return nil, err
+	}
+
+	samples := make([]analysis.Sample, len(points))
+	for i, p := range points {
+		samples[i] = analysis.Sample{X: p.X, Y: p.Y, Panel: p.Panel, Phase: p.Phase, Timestamp: p.Timestamp}
+	}
+	return samples, nil
+}
+
+// runFixationDetection runs the configured detector over a session's gaze data and
+// replaces any earlier run with the same algorithm
+func (s *Server) runFixationDetection(sessionID uint, run store.FixationRun) (store.FixationRun, error) {
+	samples, err := s.sessionGazeSamples(sessionID)
+	if err != nil {
+		return run, err
+	}
+
+	minDuration := time.Duration(run.MinFixationMS) * time.Millisecond
+	maxGap := time.Duration(run.MaxGapMS) * time.Millisecond
+	var fixations []analysis.Fixation
+	var saccades []analysis.Saccade
+	if run.Algorithm == "idt" {
+		fixations, saccades = analysis.DetectIDT(samples, analysis.IDTConfig{
+			DispersionThreshold: run.DispersionThreshold,
+			MinFixationDuration: minDuration,
+			MaxGap:              maxGap,
+		})
+	} else {
+		fixations, saccades = analysis.DetectIVT(samples, analysis.IVTConfig{
+			VelocityThreshold:   run.VelocityThreshold,
+			MinFixationDuration: minDuration,
+			MaxGap:              maxGap,
+		})
+	}
+
+	run.SessionID = sessionID
+	run.SampleCount = len(samples)
+	for _, f := range fixations {
+		run.Fixations = append(run.Fixations, store.Fixation{
+			SessionID:   sessionID,
+			StartTime:   f.Start,
+			EndTime:     f.End,
+			DurationMS:  int(f.Duration.Milliseconds()),
+			X:           f.X,
+			Y:           f.Y,
+			Panel:       f.Panel,
+			SampleCount: f.SampleCount,
+		})
+	}
+	for _, saccade := range saccades {
+		run.Saccades = append(run.Saccades, store.Saccade{
+			SessionID:  sessionID,
+			StartTime:  saccade.Start,
+			EndTime:    saccade.End,
+			DurationMS: int(saccade.Duration.Milliseconds()),
+			StartX:     saccade.StartX,
+			StartY:     saccade.StartY,
+			EndX:       saccade.EndX,
+			EndY:       saccade.EndY,
+			Amplitude:  saccade.Amplitude,
+			Velocity:   saccade.Velocity,
+			FromPanel:  saccade.FromPanel,
+			ToPanel:    saccade.ToPanel,
+		})
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Drop the previous run for this algorithm so each session has one current result per detector
+		var oldRunIDs []uint
+		if err := tx.Model(&store.FixationRun{}).Where("session_id = ? AND algorithm = ?", sessionID, run.Algorithm).Pluck("id", &oldRunIDs).Error; err != nil {
+			return err
+		}
+		if len(oldRunIDs) > 0 {
+			if err := tx.Where("run_id IN ?", oldRunIDs).Delete(&store.Fixation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("run_id IN ?", oldRunIDs).Delete(&store.Saccade{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&store.FixationRun{}, oldRunIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// Create the run and its fixations/saccades in batches
+		fixationRows, saccadeRows := run.Fixations, run.Saccades
+		run.Fixations, run.Saccades = nil, nil
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+		for i := range fixationRows {
+			fixationRows[i].RunID = run.ID
+		}
+		for i := range saccadeRows {
+			saccadeRows[i].RunID = run.ID
+		}
+		if len(fixationRows) > 0 {
+			if err := tx.CreateInBatches(&fixationRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(saccadeRows) > 0 {
+			if err := tx.CreateInBatches(&saccadeRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		run.Fixations, run.Saccades = fixationRows, saccadeRows
+		return nil
+	})
+	return run, err
+}
+
+// handleAdminSessionFixations runs detection (POST) or returns stored results (GET)
+// for /api/admin/session/:id/fixations
+func (s *Server) handleAdminSessionFixations(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid session ID"})
+	}
+
+	var session store.StudySession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		return c.JSON(404, map[string]string{"error": "Session not found"})
+	}
+
+	switch c.Request().Method {
+	case "POST":
+		var params struct {
+			Algorithm           string   `json:"algorithm"`
+			VelocityThreshold   *float64 `json:"velocity_threshold,omitempty"`
+			DispersionThreshold *float64 `json:"dispersion_threshold,omitempty"`
+			MinFixationMS       *int     `json:"min_fixation_ms,omitempty"`
+			MaxGapMS            *int     `json:"max_gap_ms,omitempty"`
+		}
+		if err := c.Bind(&params); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		// Fill in defaults for anything not specified
+		run := store.FixationRun{Algorithm: params.Algorithm}
+		switch run.Algorithm {
+		case "", "ivt":
+			defaults := analysis.DefaultIVTConfig()
+			run.Algorithm = "ivt"
+			run.VelocityThreshold = defaults.VelocityThreshold
+			run.MinFixationMS = int(defaults.MinFixationDuration.Milliseconds())
+			run.MaxGapMS = int(defaults.MaxGap.Milliseconds())
+			if params.VelocityThreshold != nil {
+				run.VelocityThreshold = *params.VelocityThreshold
+			}
+			if run.VelocityThreshold <= 0 {
+				return c.JSON(400, map[string]string{"error": "velocity_threshold must be positive"})
+			}
+		case "idt":
+			defaults := analysis.DefaultIDTConfig()
+			run.DispersionThreshold = defaults.DispersionThreshold
+			run.MinFixationMS = int(defaults.MinFixationDuration.Milliseconds())
+			run.MaxGapMS = int(defaults.MaxGap.Milliseconds())
+			if params.DispersionThreshold != nil {
+				run.DispersionThreshold = *params.DispersionThreshold
+			}
+			if run.DispersionThreshold <= 0 {
+				return c.JSON(400, map[string]string{"error": "dispersion_threshold must be positive"})
+			}
+		default:
+			return c.JSON(400, map[string]string{"error": "algorithm must be ivt or idt"})
+		}
+		if params.MinFixationMS != nil {
+			if *params.MinFixationMS < 0 {
+				return c.JSON(400, map[string]string{"error": "min_fixation_ms must not be negative"})
+			}
+			run.MinFixationMS = *params.MinFixationMS
+		}
+		if params.MaxGapMS != nil {
+			if *params.MaxGapMS < 0 {
+				return c.JSON(400, map[string]string{"error": "max_gap_ms must not be negative"})
+			}
+			run.MaxGapMS = *params.MaxGapMS
+		}
+
+		run, err := s.runFixationDetection(session.ID, run)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to detect fixations: " + err.Error()})
+		}
+		summary := run
+		summary.Fixations, summary.Saccades = nil, nil
+		s.logAudit(c, auditDetectFixations, entitySession, session.ID, nil, map[string]interface{}{
+			"run":       summary,
+			"fixations": len(run.Fixations),
+			"saccades":  len(run.Saccades),
+		})
+
+		return c.JSON(201, map[string]interface{}{
+			"success": true,
+			"data":    run,
+		})
+
+	case "GET":
+		// Return the current run for each algorithm, or just the requested one
+		query := s.db.Where("session_id = ?", session.ID).Order("algorithm ASC").
+			Preload("Fixations", func(db *gorm.DB) *gorm.DB {
+				return db.Order("start_time ASC")
+			}).
+			Preload("Saccades", func(db *gorm.DB) *gorm.DB {
+				return db.Order("start_time ASC")
+			})
+		if algorithm := c.QueryParam("algorithm"); algorithm != "" {
+			query = query.Where("algorithm = ?", algorithm)
+		}
+
+		var runs []store.FixationRun
+		if err := query.Find(&runs).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch fixations: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"data":    runs,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errUnknownFont is returned when a font value matches neither a Font nor a legacy category
+var errUnknownFont = errors.New("unknown font")
+
+// legacyFontCategories are the category-only values stored before fonts were
+// tracked individually; they are still accepted wherever a font is expected
+var legacyFontCategories = map[string]string{
+	"serif": "serif",
+	"sans":  "sans-serif",
+}
+
+// fontCatalog looks fonts up by name or display name, case-insensitively
+type fontCatalog map[string]store.Font
+
+func loadFontCatalog(repo store.FontRepository) (fontCatalog, error) {
+	fonts, err := repo.List()
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(fontCatalog, len(fonts)*2)
+	for _, f := range fonts {
+		catalog[strings.ToLower(f.Name)] = f
+		catalog[strings.ToLower(f.DisplayName)] = f
+	}
+	return catalog, nil
+}
+
+// lookup returns the font a stored value refers to
+func (fc fontCatalog) lookup(value string) (store.Font, bool) {
+	f, ok := fc[strings.ToLower(strings.TrimSpace(value))]
+	return f, ok
+}
+
+// category returns "serif", "sans-serif" or "unknown" for a font name,
+// display name or legacy category value
+func (fc fontCatalog) category(value string) string {
+	if f, ok := fc.lookup(value); ok {
+		return f.Category
+	}
+	if category, ok := legacyFontCategories[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return category
+	}
+	return "unknown"
+}
+
+// normalize maps a font value to its canonical Font name, keeping empty and
+// legacy category values as they are
+func (fc fontCatalog) normalize(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if f, ok := fc.lookup(value); ok {
+		return f.Name, nil
+	}
+	if _, ok := legacyFontCategories[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return strings.ToLower(strings.TrimSpace(value)), nil
+	}
+	return "", fmt.Errorf("%w: %q", errUnknownFont, value)
+}
+
+// normalizeFonts canonicalizes each font field in place, stopping at the first unknown value
+func (fc fontCatalog) normalizeFonts(fields ...*string) error {
+	for _, field := range fields {
+		name, err := fc.normalize(*field)
+		if err != nil {
+			return err
+		}
+		*field = name
+	}
+	return nil
+}
+
+// validateFonts canonicalizes font fields against the Font table
+func (s *Server) validateFonts(fields ...*string) error {
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return err
+	}
+	return fonts.normalizeFonts(fields...)
+}
+
+// handleFonts lists the fonts available to the study
+func (s *Server) handleFonts(c echo.Context) error {
+	fonts, err := s.repo.Fonts().List()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch fonts: " + err.Error()})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    fonts,
+	})
+}
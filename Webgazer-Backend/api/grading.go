@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"readability-backend/store"
+
+	"gorm.io/gorm"
+)
+
+var (
+	errQuestionNotFound = errors.New("quiz question not found for this session's study text")
+	errAnswerOutOfRange = errors.New("answer_index is outside the question's choices")
+	errAnswerNotAChoice = errors.New("answer must be the index of one of the choices")
+	errRevisionNotShown = errors.New("question_revision_id is not the revision shown in this session")
+)
+
+// validateAnswer checks that a question's answer key is one of its choices,
+// since participants could never match any other index
+func validateAnswer(answer int, choices []string) error {
+	if answer < 0 || answer >= len(choices) {
+		return errAnswerNotAChoice
+	}
+	return nil
+}
+
+// sessionStudyTextID returns the study text a session was run against,
+// falling back to the active study text for sessions created before it was recorded
+func sessionStudyTextID(repo store.Repository, session store.StudySession) (uint, error) {
+	if session.StudyTextID != nil && *session.StudyTextID > 0 {
+		return *session.StudyTextID, nil
+	}
+
+	studyText, err := repo.StudyTexts().Active()
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, errQuestionNotFound
+	}
+	return studyText.ID, err
+}
+
+// findQuizQuestion looks up a question by its question_id within a study
+// text, see store.QuizQuestionRepository.Find
+func findQuizQuestion(repo store.Repository, studyTextID uint, questionID string, passageID *uint) (store.QuizQuestion, error) {
+	question, err := repo.QuizQuestions().Find(studyTextID, questionID, passageID)
+	if errors.Is(err, store.ErrNotFound) {
+		return question, errQuestionNotFound
+	}
+	return question, err
+}
+
+// answeredRevision returns the question revision a response answers: the
+// question's latest revision from when the session started, or its first
+// revision if it was only added later. A question_revision_id sent with the
+// response must be that revision; otherwise it is recorded.
+func answeredRevision(repo store.Repository, session store.StudySession, response *store.QuizResponse) (store.QuizQuestionRevision, error) {
+	studyTextID, err := sessionStudyTextID(repo, session)
+	if err != nil {
+		return store.QuizQuestionRevision{}, err
+	}
+	question, err := findQuizQuestion(repo, studyTextID, response.QuestionID, response.PassageID)
+	if err != nil {
+		return store.QuizQuestionRevision{}, err
+	}
+
+	revision, err := repo.QuizQuestions().RevisionAt(question.ID, session.CreatedAt)
+	if errors.Is(err, store.ErrNotFound) {
+		// Questions always have a revision once migrations have run
+		return revision, errQuestionNotFound
+	}
+	if err != nil {
+		return revision, err
+	}
+
+	if response.QuestionRevisionID != nil && *response.QuestionRevisionID > 0 && *response.QuestionRevisionID != revision.ID {
+		return revision, errRevisionNotShown
+	}
+	response.QuestionRevisionID = &revision.ID
+	return revision, nil
+}
+
+// gradeQuizResponse sets IsCorrect on the response by comparing it against the
+// answer of the question revision shown, ignoring whatever the client claimed
+func gradeQuizResponse(repo store.Repository, session store.StudySession, response *store.QuizResponse) error {
+	question, err := answeredRevision(repo, session, response)
+	if err != nil {
+		return err
+	}
+
+	var choices []string
+	if err := json.Unmarshal([]byte(question.Choices), &choices); err != nil {
+		return fmt.Errorf("invalid choices stored for question %s: %w", question.QuestionID, err)
+	}
+	if response.AnswerIndex < 0 || response.AnswerIndex >= len(choices) {
+		return errAnswerOutOfRange
+	}
+
+	isCorrect := response.AnswerIndex == question.Answer
+	response.IsCorrect = &isCorrect
+	return nil
+}
+
+// RegradeResult counts the quiz responses a regrade went through
+type RegradeResult struct {
+	Checked int // Responses looked at
+	Changed int // Responses whose is_correct changed, or would change on a dry run
+	Skipped int // Responses without a matching session or question
+}
+
+// RegradeQuiz recomputes is_correct for every stored quiz response from the
+// stored answer of the question revision it was given for, replacing values
+// that were reported by the client. A dry run only counts the changes.
+func (s *Server) RegradeQuiz(dryRun bool) (RegradeResult, error) {
+	return s.regradeQuiz(func(tx *gorm.DB) *gorm.DB { return tx }, dryRun)
+}
+
+// GradeUngradedQuiz grades the quiz responses that have no is_correct yet,
+// such as the answers migration 8 moved out of quiz_responses_json
+func (s *Server) GradeUngradedQuiz() (RegradeResult, error) {
+	return s.regradeQuiz(func(tx *gorm.DB) *gorm.DB { return tx.Where("is_correct IS NULL") }, false)
+}
+
+// regradeQuiz grades the quiz responses scope selects
+func (s *Server) regradeQuiz(scope func(*gorm.DB) *gorm.DB, dryRun bool) (RegradeResult, error) {
+	var result RegradeResult
+	sessions := make(map[uint]store.StudySession)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.store.Repository(tx)
+		var responses []store.QuizResponse
+		return tx.Scopes(scope).Order("id ASC").FindInBatches(&responses, 500, func(batch *gorm.DB, _ int) error {
+			for i := range responses {
+				response := &responses[i]
+				result.Checked++
+
+				session, ok := sessions[response.SessionID]
+				if !ok {
+					var err error
+					if session, err = repo.Sessions().Get(response.SessionID); err != nil {
+						result.Skipped++
+						continue
+					}
+					sessions[response.SessionID] = session
+				}
+
+				previous := response.IsCorrect
+				if err := gradeQuizResponse(repo, session, response); err != nil {
+					if errors.Is(err, errAnswerOutOfRange) {
+						// An impossible answer can never be correct
+						isCorrect := false
+						response.IsCorrect = &isCorrect
+					} else if errors.Is(err, errQuestionNotFound) || errors.Is(err, errRevisionNotShown) {
+						result.Skipped++
+						continue
+					} else {
+						return err
+					}
+				}
+
+				if previous != nil && *previous == *response.IsCorrect {
+					continue
+				}
+				result.Changed++
+				if dryRun {
+					continue
+				}
+				if err := tx.Model(&store.QuizResponse{}).Where("id = ?", response.ID).Update("is_correct", *response.IsCorrect).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+	return result, err
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"readability-backend/store"
+)
+
+func TestRegradeQuiz(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	studyTextID := uint(1)
+	session := store.StudySession{SessionID: "regrade", StudyTextID: &studyTextID, Status: store.StatusCompleted}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	// q1's answer is 1, so the stored is_correct values are both wrong
+	wrong, right := true, false
+	responses := []store.QuizResponse{
+		{SessionID: session.ID, QuestionID: "q1", AnswerIndex: 0, IsCorrect: &wrong, Timestamp: time.Now()},
+		{SessionID: session.ID, QuestionID: "q1", AnswerIndex: 1, IsCorrect: &right, Timestamp: time.Now()},
+		{SessionID: session.ID, QuestionID: "missing", AnswerIndex: 0, Timestamp: time.Now()},
+	}
+	if err := ts.db.Create(&responses).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	want := RegradeResult{Checked: 3, Changed: 2, Skipped: 1}
+	for _, dryRun := range []bool{true, false} {
+		result, err := ts.RegradeQuiz(dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != want {
+			t.Errorf("dry run %v: got %+v, want %+v", dryRun, result, want)
+		}
+	}
+
+	var correct []bool
+	if err := ts.db.Model(&store.QuizResponse{}).Where("question_id = ?", "q1").Order("id ASC").Pluck("is_correct", &correct).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(correct) != 2 || correct[0] || !correct[1] {
+		t.Errorf("is_correct after regrading = %v, want [false true]", correct)
+	}
+
+	result, err := ts.RegradeQuiz(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed != 0 {
+		t.Errorf("second regrade changed %d responses, want 0", result.Changed)
+	}
+}
+
+func TestGradeUngradedQuiz(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	studyTextID := uint(1)
+	session := store.StudySession{SessionID: "ungraded", StudyTextID: &studyTextID, Status: store.StatusCompleted}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	// The graded response is wrong on purpose: only ungraded responses are touched
+	wrong := true
+	responses := []store.QuizResponse{
+		{SessionID: session.ID, QuestionID: "q1", AnswerIndex: 0, IsCorrect: &wrong, Timestamp: time.Now()},
+		{SessionID: session.ID, QuestionID: "q1", AnswerIndex: 1, Timestamp: time.Now()},
+	}
+	if err := ts.db.Create(&responses).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ts.GradeUngradedQuiz()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (RegradeResult{Checked: 1, Changed: 1}); result != want {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+
+	var correct []bool
+	if err := ts.db.Model(&store.QuizResponse{}).Where("session_id = ?", session.ID).Order("id ASC").Pluck("is_correct", &correct).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(correct) != 2 || !correct[0] || !correct[1] {
+		t.Errorf("is_correct after grading = %v, want [true true]", correct)
+	}
+}
+
+func TestAnsweredRevision(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	studyTextID := uint(1)
+	session := store.StudySession{SessionID: "revision", StudyTextID: &studyTextID, Status: store.StatusReading}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// q1's answer changes from 1 to 0 after the session started
+	var question store.QuizQuestion
+	if err := ts.db.Where("study_text_id = ? AND question_id = ?", studyTextID, "q1").First(&question).Error; err != nil {
+		t.Fatal(err)
+	}
+	shown := *question.RevisionID
+	question.Answer = 0
+	if err := ts.db.Save(&question).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ReviseQuizQuestion(ts.db, &question, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if *question.RevisionID == shown {
+		t.Fatal("editing q1 added no revision")
+	}
+
+	// Only the revision shown may be named, and answers are graded against it
+	answer := map[string]interface{}{"session_id": session.ID, "question_id": "q1", "answer_index": 1, "question_revision_id": *question.RevisionID}
+	ts.expect("POST", "/api/quiz-response", answer, "", 400, nil)
+	var graded struct {
+		ID        uint  `json:"id"`
+		IsCorrect *bool `json:"is_correct"`
+	}
+	answer["question_revision_id"] = shown
+	ts.expect("POST", "/api/quiz-response", answer, "", 201, &graded)
+	if graded.IsCorrect == nil || !*graded.IsCorrect {
+		t.Errorf("answer 1 graded against the shown revision: is_correct = %v, want true", graded.IsCorrect)
+	}
+
+	delete(answer, "question_revision_id")
+	ts.expect("POST", "/api/quiz-response", answer, "", 201, &graded)
+	var response store.QuizResponse
+	if err := ts.db.First(&response, graded.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if response.QuestionRevisionID == nil || *response.QuestionRevisionID != shown {
+		t.Errorf("recorded revision = %v, want %d", response.QuestionRevisionID, shown)
+	}
+}
@@ -0,0 +1,1540 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func (s *Server) handleHealth(c echo.Context) error {
+	return c.JSON(200, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleParticipant(c echo.Context) error {
+	var request participantRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Source defaults to "web"; Prolific and MTurk IDs set it to the platform
+	participant, err := request.participant(s.config.Study.DefaultSource)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	// Repeat visits from the same platform participant reuse the existing record
+	if participant.ExternalID != nil {
+		existing, found, err := s.findExternalParticipant(participant.Source, *participant.ExternalID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to look up participant: " + err.Error()})
+		}
+		if found {
+			return s.respondReturningParticipant(c, existing, participant)
+		}
+	}
+
+	// Create participant in database
+	if err := s.repo.Participants().Create(&participant); err != nil {
+		// A concurrent first visit may have created the participant in the meantime
+		if participant.ExternalID != nil && s.store.IsUniqueViolation(err) {
+			if existing, found, _ := s.findExternalParticipant(participant.Source, *participant.ExternalID); found {
+				return s.respondReturningParticipant(c, existing, participant)
+			}
+		}
+		return c.JSON(500, map[string]string{"error": "Failed to save participant: " + err.Error()})
+	}
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"id":      participant.ID,
+		"source":  participant.Source,
+	})
+}
+
+func (s *Server) handleSession(c echo.Context) error {
+	var session store.StudySession
+	if err := c.Bind(&session); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Store concrete font names (display names like "Georgia" are accepted too)
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+	if err := fonts.normalizeFonts(&session.FontLeft, &session.FontRight, &session.PreferredFontType); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	// Sessions only start once the participant has accepted the current consent document
+	if document, err := s.requireConsent(session.ParticipantID); err != nil {
+		return respondConsentError(c, document, err)
+	}
+
+	// Participants take the study once; a repeat gets the completion code it already has
+	if session.ParticipantID != 0 {
+		completed, err := s.participantCompletion(session.ParticipantID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to look up sessions: " + err.Error()})
+		}
+		if completed != nil {
+			return c.JSON(409, map[string]string{
+				"error":           errAlreadyCompleted.Error(),
+				"completion_code": completed.CompletionCode,
+			})
+		}
+	}
+
+	// Record which study text the session is run against so quiz answers can be graded
+	if session.StudyTextID != nil {
+		studyText, err := s.repo.StudyTexts().Get(*session.StudyTextID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+		}
+		if err != nil || studyText.Status != studyTextPublished {
+			return c.JSON(400, map[string]string{"error": "study_text_id must be a published study text"})
+		}
+	} else if studyText, err := s.repo.StudyTexts().Active(); err == nil {
+		session.StudyTextID = &studyText.ID
+	}
+
+	// Conditions and the lifecycle status are always set by the server
+	session.ConditionIndex = nil
+	session.Conditions = nil
+	session.Status = store.StatusCreated
+	session.StatusChangedAt = nil
+	session.CompletedAt = nil
+	session.CompletionCode = ""
+	now := time.Now()
+	session.LastActivityAt = &now
+
+	// Create session and its counterbalanced conditions in one transaction
+	err = s.repo.Transaction(func(repo store.Repository) error {
+		conditions, err := assignConditions(repo, &session)
+		if err != nil {
+			return err
+		}
+		if err := repo.Sessions().Create(&session); err != nil {
+			return err
+		}
+		for i := range conditions {
+			conditions[i].SessionID = session.ID
+		}
+		if err := repo.Sessions().AddConditions(conditions); err != nil {
+			return err
+		}
+		session.Conditions = conditions
+		return nil
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save session: " + err.Error()})
+	}
+
+	s.events.publish(eventSession, session.ID, map[string]interface{}{
+		"session_id":      session.SessionID,
+		"participant_id":  session.ParticipantID,
+		"study_text_id":   session.StudyTextID,
+		"condition_index": session.ConditionIndex,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success":         true,
+		"session_id":      session.SessionID,
+		"id":              session.ID,
+		"condition_index": session.ConditionIndex,
+		"conditions":      session.Conditions,
+	})
+}
+
+func (s *Server) handleQuizResponse(c echo.Context) error {
+	var quizResponse store.QuizResponse
+	if err := c.Bind(&quizResponse); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Set timestamp if not provided
+	if quizResponse.Timestamp.IsZero() {
+		quizResponse.Timestamp = time.Now()
+	}
+
+	// Grade the answer server-side; the client's is_correct is never trusted
+	quizResponse.IsCorrect = nil
+	err := s.storeSessionData(quizResponse.SessionID, store.StatusQuiz, func(repo store.Repository, session store.StudySession) error {
+		if err := gradeQuizResponse(repo, session, &quizResponse); err != nil {
+			return err
+		}
+		return repo.SessionData().AddQuizResponse(&quizResponse)
+	})
+	switch {
+	case isSessionError(err):
+		return respondSessionError(c, err)
+	case errors.Is(err, errQuestionNotFound):
+		return c.JSON(404, map[string]string{"error": "Quiz question not found: " + quizResponse.QuestionID})
+	case errors.Is(err, errAnswerOutOfRange), errors.Is(err, errRevisionNotShown):
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(500, map[string]string{"error": "Failed to save quiz response: " + err.Error()})
+	}
+
+	s.events.publish(eventQuizResponse, quizResponse.SessionID, map[string]interface{}{
+		"id":                   quizResponse.ID,
+		"question_id":          quizResponse.QuestionID,
+		"question_revision_id": quizResponse.QuestionRevisionID,
+		"passage_id":           quizResponse.PassageID,
+		"answer_index":         quizResponse.AnswerIndex,
+		"is_correct":           quizResponse.IsCorrect,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success":    true,
+		"id":         quizResponse.ID,
+		"is_correct": quizResponse.IsCorrect,
+	})
+}
+
+func (s *Server) handleCalibration(c echo.Context) error {
+	var calibration store.CalibrationData
+	if err := c.Bind(&calibration); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Set timestamp if not provided
+	if calibration.Timestamp.IsZero() {
+		calibration.Timestamp = time.Now()
+	}
+
+	// Data that doesn't fit the session's current status is rejected
+	err := s.storeSessionData(calibration.SessionID, store.StatusCalibrated, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().AddCalibration(&calibration)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save calibration data: " + err.Error()})
+	}
+
+	s.events.publish(eventCalibration, calibration.SessionID, map[string]interface{}{
+		"id":           calibration.ID,
+		"point_index":  calibration.PointIndex,
+		"click_number": calibration.ClickNumber,
+		"x":            calibration.X,
+		"y":            calibration.Y,
+		"timestamp":    calibration.Timestamp,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"id":      calibration.ID,
+	})
+}
+
+func (s *Server) handleGazePoint(c echo.Context) error {
+	var gazePoint store.GazePoint
+	if err := c.Bind(&gazePoint); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Set timestamp if not provided
+	if gazePoint.Timestamp.IsZero() {
+		gazePoint.Timestamp = time.Now()
+	}
+
+	// Data that doesn't fit the session's current status is rejected
+	err := s.storeSessionData(gazePoint.SessionID, store.StatusReading, func(repo store.Repository, _ store.StudySession) error {
+		points := []store.GazePoint{gazePoint}
+		if err := repo.SessionData().AddGazePoints(points); err != nil {
+			return err
+		}
+		gazePoint = points[0]
+		return nil
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save gaze point: " + err.Error()})
+	}
+
+	s.events.publish(eventGazePoint, gazePoint.SessionID, gazeEventPoint(gazePoint))
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"id":      gazePoint.ID,
+	})
+}
+
+// gazeBatchItemError reports why a single point in a batch was rejected
+type gazeBatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+func (s *Server) handleGazePointBatch(c echo.Context) error {
+	var batch struct {
+		SessionID uint              `json:"session_id"`
+		Points    []store.GazePoint `json:"points"`
+	}
+	if err := c.Bind(&batch); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	if batch.SessionID == 0 {
+		return c.JSON(400, map[string]string{"error": "session_id is required"})
+	}
+	if len(batch.Points) == 0 {
+		return c.JSON(400, map[string]string{"error": "points must contain at least one gaze point"})
+	}
+	// ingestion.max_gaze_batch_size caps how many points a single batch request may carry
+	if maxPoints := s.config.Ingestion.MaxGazeBatchSize; len(batch.Points) > maxPoints {
+		return c.JSON(413, map[string]string{
+			"error": fmt.Sprintf("Batch too large: %d points (max %d)", len(batch.Points), maxPoints),
+		})
+	}
+
+	// Validate each point individually so one bad sample doesn't sink the batch
+	now := time.Now()
+	valid := make([]store.GazePoint, 0, len(batch.Points))
+	failures := []gazeBatchItemError{}
+	for i, point := range batch.Points {
+		if point.SessionID != 0 && point.SessionID != batch.SessionID {
+			failures = append(failures, gazeBatchItemError{Index: i, Error: "session_id does not match batch session_id"})
+			continue
+		}
+
+		point.ID = 0
+		point.SessionID = batch.SessionID
+		if point.Timestamp.IsZero() {
+			point.Timestamp = now
+		}
+		valid = append(valid, point)
+	}
+
+	if len(valid) == 0 {
+		return c.JSON(400, map[string]interface{}{
+			"success":  false,
+			"inserted": 0,
+			"failed":   len(failures),
+			"errors":   failures,
+		})
+	}
+
+	// Insert all valid points in a single transaction, rejecting batches for
+	// unknown sessions or sessions that aren't reading
+	err := s.storeSessionData(batch.SessionID, store.StatusReading, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().AddGazePoints(valid)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save gaze points: " + err.Error()})
+	}
+
+	points := make([]map[string]interface{}, len(valid))
+	for i, point := range valid {
+		points[i] = gazeEventPoint(point)
+	}
+	s.events.publish(eventGazePoints, batch.SessionID, map[string]interface{}{
+		"count":  len(valid),
+		"points": points,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success":  len(failures) == 0,
+		"inserted": len(valid),
+		"failed":   len(failures),
+		"errors":   failures,
+	})
+}
+
+func (s *Server) handleReadingEvent(c echo.Context) error {
+	var readingEvent store.ReadingEvent
+	if err := c.Bind(&readingEvent); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Set timestamp if not provided
+	if readingEvent.Timestamp.IsZero() {
+		readingEvent.Timestamp = time.Now()
+	}
+
+	// Data that doesn't fit the session's current status is rejected
+	err := s.storeSessionData(readingEvent.SessionID, store.StatusReading, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().AddReadingEvent(&readingEvent)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save reading event: " + err.Error()})
+	}
+
+	s.events.publish(eventReadingEvent, readingEvent.SessionID, map[string]interface{}{
+		"id":         readingEvent.ID,
+		"event_type": readingEvent.EventType,
+		"panel":      readingEvent.Panel,
+		"duration":   readingEvent.Duration,
+		"timestamp":  readingEvent.Timestamp,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"id":      readingEvent.ID,
+	})
+}
+
+func (s *Server) handleAccuracy(c echo.Context) error {
+	var accuracy store.AccuracyMeasurement
+	if err := c.Bind(&accuracy); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	// Set timestamp if not provided
+	if accuracy.Timestamp.IsZero() {
+		accuracy.Timestamp = time.Now()
+	}
+	// Passing is decided against study.accuracy_threshold, not by the client
+	accuracy.Passed = accuracy.Accuracy >= s.config.Study.AccuracyThreshold
+
+	// Data that doesn't fit the session's current status is rejected
+	err := s.storeSessionData(accuracy.SessionID, store.StatusAccuracyChecked, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().AddAccuracy(&accuracy)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save accuracy measurement: " + err.Error()})
+	}
+
+	s.events.publish(eventAccuracy, accuracy.SessionID, map[string]interface{}{
+		"id":        accuracy.ID,
+		"accuracy":  accuracy.Accuracy,
+		"duration":  accuracy.Duration,
+		"passed":    accuracy.Passed,
+		"timestamp": accuracy.Timestamp,
+	})
+
+	return c.JSON(201, map[string]interface{}{
+		"success":   true,
+		"id":        accuracy.ID,
+		"passed":    accuracy.Passed,
+		"threshold": s.config.Study.AccuracyThreshold,
+	})
+}
+
+func (s *Server) handleStudyText(c echo.Context) error {
+	// Get version from query parameter, default to study.default_version
+	version := c.QueryParam("version")
+	if version == "" {
+		version = s.config.Study.DefaultVersion
+	}
+
+	studyText, err := s.repo.StudyTexts().GetByVersion(version)
+	if err == nil && !studyText.Active {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		// If not found, try to get any active study text
+		if studyText, err = s.repo.StudyTexts().Active(); err != nil {
+			return c.JSON(404, map[string]string{"error": "No study text found"})
+		}
+	}
+	if studyText.Passages, err = s.repo.Passages().ForStudyText(studyText.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch passages: " + err.Error()})
+	}
+
+	// Build response - include passages if they exist, otherwise use legacy content
+	response := map[string]interface{}{
+		"id":         studyText.ID,
+		"version":    studyText.Version,
+		"font_left":  studyText.FontLeft,
+		"font_right": studyText.FontRight,
+	}
+
+	// If passages exist, return them; otherwise return legacy content for backward compatibility
+	if len(studyText.Passages) > 0 {
+		response["passages"] = studyText.Passages
+	} else {
+		response["content"] = studyText.Content
+	}
+
+	return c.JSON(200, response)
+}
+
+func (s *Server) handleQuizQuestions(c echo.Context) error {
+	// Get study_text_id and passage_id from query parameters
+	studyTextID := c.QueryParam("study_text_id")
+	passageID := c.QueryParam("passage_id")
+
+	// Questions of draft study texts are only shown in the admin preview
+	var questions []store.QuizQuestion
+	var err error
+	if passageID != "" {
+		// If passage_id is provided, filter by passage (most specific)
+		id, parseErr := strconv.ParseUint(passageID, 10, 64)
+		if parseErr != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid passage_id"})
+		}
+		questions, err = s.repo.QuizQuestions().PublishedForPassage(uint(id))
+	} else if studyTextID != "" {
+		// If study_text_id provided but no passage_id, get questions for study text (not linked to specific passage)
+		id, parseErr := strconv.ParseUint(studyTextID, 10, 64)
+		if parseErr != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid study_text_id"})
+		}
+		questions, err = s.repo.QuizQuestions().PublishedForStudyText(uint(id))
+	} else {
+		// If no parameters provided, get questions for active study text (not linked to specific passage)
+		studyText, activeErr := s.repo.StudyTexts().Active()
+		if activeErr != nil {
+			return c.JSON(404, map[string]string{"error": "No active study text found"})
+		}
+		questions, err = s.repo.QuizQuestions().PublishedForStudyText(studyText.ID)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch quiz questions: " + err.Error()})
+	}
+
+	// Format response to match frontend expectations
+	return c.JSON(200, newQuizQuestionsJSON(questions))
+}
+
+// Admin endpoints for managing study text, passages, and quiz questions
+
+func (s *Server) handleAdminPassage(c echo.Context) error {
+	switch c.Request().Method {
+	case "POST":
+		// Create new passage
+		var passage store.Passage
+		if err := c.Bind(&passage); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		// Validate required fields
+		if passage.StudyTextID == 0 || passage.Content == "" {
+			return c.JSON(400, map[string]string{"error": "study_text_id and content are required"})
+		}
+
+		if err := s.validateFonts(&passage.FontLeft, &passage.FontRight); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		// Revisions are assigned by the server
+		passage.Revision, passage.RevisionID = 0, nil
+
+		err := s.repo.Transaction(func(repo store.Repository) error {
+			if err := requireDraft(repo, passage.StudyTextID); err != nil {
+				return err
+			}
+			// If order not specified, set it to the next available order
+			if passage.Order == 0 {
+				next, err := repo.Passages().NextPosition(passage.StudyTextID)
+				if err != nil {
+					return err
+				}
+				passage.Order = next
+			}
+
+			if err := repo.Passages().Create(&passage); err != nil {
+				return err
+			}
+			if err := repo.Passages().Revise(&passage, adminActor(c)); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditCreate, entityPassage, passage.ID, nil, passage)
+		})
+		if isDraftError(err) {
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to create passage: " + err.Error()})
+		}
+
+		return c.JSON(201, map[string]interface{}{
+			"success":  true,
+			"id":       passage.ID,
+			"revision": passage.Revision,
+			"message":  "Passage created successfully",
+		})
+
+	case "PUT":
+		// Update existing passage
+		var updateData struct {
+			ID        uint   `json:"id"`
+			Order     *int   `json:"order,omitempty"`
+			Content   string `json:"content,omitempty"`
+			Title     string `json:"title,omitempty"`
+			FontLeft  string `json:"font_left,omitempty"`
+			FontRight string `json:"font_right,omitempty"`
+		}
+
+		if err := c.Bind(&updateData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		if updateData.ID == 0 {
+			return c.JSON(400, map[string]string{"error": "ID is required"})
+		}
+		if err := s.validateFonts(&updateData.FontLeft, &updateData.FontRight); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+
+		var passage store.Passage
+		err := s.repo.Transaction(func(repo store.Repository) error {
+			var err error
+			if passage, err = repo.Passages().GetForUpdate(updateData.ID); err != nil {
+				return notFoundAs(err, errPassageNotFound)
+			}
+			before := passage
+			if err := requireDraft(repo, passage.StudyTextID); err != nil {
+				return err
+			}
+
+			// Update fields
+			if updateData.Content != "" {
+				passage.Content = updateData.Content
+			}
+			if updateData.Title != "" {
+				passage.Title = updateData.Title
+			}
+			if updateData.Order != nil {
+				passage.Order = *updateData.Order
+			}
+			if updateData.FontLeft != "" {
+				passage.FontLeft = updateData.FontLeft
+			}
+			if updateData.FontRight != "" {
+				passage.FontRight = updateData.FontRight
+			}
+
+			if err := repo.Passages().Save(&passage); err != nil {
+				return err
+			}
+			if err := repo.Passages().Revise(&passage, adminActor(c)); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditUpdate, entityPassage, passage.ID, before, passage)
+		})
+		if errors.Is(err, errPassageNotFound) {
+			return c.JSON(404, map[string]string{"error": "Passage not found"})
+		}
+		if isDraftError(err) {
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to update passage: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success":  true,
+			"id":       passage.ID,
+			"revision": passage.Revision,
+			"message":  "Passage updated successfully",
+		})
+
+	case "DELETE":
+		// Delete passage
+		id := c.QueryParam("id")
+		if id == "" {
+			return c.JSON(400, map[string]string{"error": "ID parameter is required"})
+		}
+		passageID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+		}
+
+		var passage store.Passage
+		err = s.repo.Transaction(func(repo store.Repository) error {
+			var err error
+			if passage, err = repo.Passages().GetForUpdate(uint(passageID)); err != nil {
+				return notFoundAs(err, errPassageNotFound)
+			}
+			if err := requireDraft(repo, passage.StudyTextID); err != nil {
+				return err
+			}
+			// The passage's quiz questions go with it; their foreign key would block the delete
+			questions, err := repo.QuizQuestions().ForPassage(passage.ID)
+			if err != nil {
+				return err
+			}
+			for _, question := range questions {
+				if err := repo.QuizQuestions().Delete(&question); err != nil {
+					return err
+				}
+				if err := recordAudit(repo, c, auditDelete, entityQuizQuestion, question.ID, question, nil); err != nil {
+					return err
+				}
+			}
+			if err := repo.Passages().Delete(&passage); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditDelete, entityPassage, passage.ID, passage, nil)
+		})
+		if errors.Is(err, errPassageNotFound) {
+			return c.JSON(404, map[string]string{"error": "Passage not found"})
+		}
+		if isDraftError(err) {
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to delete passage: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"message": "Passage deleted successfully",
+		})
+
+	case "GET":
+		// Get passages - either by study_text_id or by id
+		studyTextID := c.QueryParam("study_text_id")
+		id := c.QueryParam("id")
+
+		if id != "" {
+			// Get single passage by ID
+			passageID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+			}
+			passage, err := s.repo.Passages().Get(uint(passageID))
+			if err != nil {
+				return c.JSON(404, map[string]string{"error": "Passage not found"})
+			}
+
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"data":    passage,
+			})
+		} else if studyTextID != "" {
+			// Get all passages for a study text
+			textID, err := strconv.ParseUint(studyTextID, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+			}
+			passages, err := s.repo.Passages().ForStudyText(uint(textID))
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to fetch passages: " + err.Error()})
+			}
+
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"data":    passages,
+			})
+		} else {
+			return c.JSON(400, map[string]string{"error": "Either id or study_text_id parameter is required"})
+		}
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+func (s *Server) handleAdminStudyText(c echo.Context) error {
+	switch c.Request().Method {
+	case "POST":
+		// Create new study text
+		var studyText store.StudyText
+		if err := c.Bind(&studyText); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		// Set defaults
+		if studyText.Version == "" {
+			studyText.Version = s.config.Study.DefaultVersion
+		}
+		if studyText.FontLeft == "" {
+			studyText.FontLeft = "georgia"
+		}
+		if studyText.FontRight == "" {
+			studyText.FontRight = "inter"
+		}
+		if err := s.validateFonts(&studyText.FontLeft, &studyText.FontRight); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		studyText.CompletionCode = strings.TrimSpace(studyText.CompletionCode)
+		if err := validateCompletionURL(studyText.CompletionURL); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		// New study texts are drafts until they are published
+		if studyText.Active {
+			return c.JSON(400, map[string]string{"error": "New study texts are drafts; publish them with POST /api/admin/study-texts/:id/publish"})
+		}
+		studyText.ClonedFromID = nil
+
+		// Check if version already exists (idempotent behavior)
+		if existingStudyText, err := s.repo.StudyTexts().GetByVersion(studyText.Version); err == nil {
+			// Version exists, return existing study text
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"id":      existingStudyText.ID,
+				"message": "Study text with this version already exists",
+			})
+		}
+
+		err := s.repo.Transaction(func(repo store.Repository) error {
+			if err := createDraft(repo, &studyText); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditCreate, entityStudyText, studyText.ID, nil, studyText)
+		})
+		if err != nil {
+			// Check for unique constraint violation (fallback check)
+			if s.store.IsUniqueViolation(err) {
+				return c.JSON(409, map[string]string{
+					"error": fmt.Sprintf("Study text with version '%s' already exists", studyText.Version),
+				})
+			}
+			return c.JSON(500, map[string]string{"error": "Failed to create study text: " + err.Error()})
+		}
+
+		return c.JSON(201, map[string]interface{}{
+			"success": true,
+			"id":      studyText.ID,
+			"status":  studyText.Status,
+			"message": "Study text created successfully",
+		})
+
+	case "PUT":
+		// Update existing study text
+		var updateData struct {
+			ID        uint   `json:"id"`
+			Version   string `json:"version,omitempty"`
+			Content   string `json:"content,omitempty"`
+			FontLeft  string `json:"font_left,omitempty"`
+			FontRight string `json:"font_right,omitempty"`
+			Active    *bool  `json:"active,omitempty"`
+
+			// Pointers so an empty string can clear them
+			CompletionCode *string `json:"completion_code,omitempty"`
+			CompletionURL  *string `json:"completion_url,omitempty"`
+		}
+
+		if err := c.Bind(&updateData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		if updateData.ID == 0 {
+			return c.JSON(400, map[string]string{"error": "ID is required"})
+		}
+		if err := s.validateFonts(&updateData.FontLeft, &updateData.FontRight); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		if updateData.CompletionURL != nil {
+			if err := validateCompletionURL(*updateData.CompletionURL); err != nil {
+				return c.JSON(400, map[string]string{"error": err.Error()})
+			}
+		}
+
+		studyText, err := s.repo.StudyTexts().Get(updateData.ID)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Study text not found"})
+		}
+		before := studyText
+
+		// Published texts only change which one is active and their completion settings
+		if studyText.Status != studyTextDraft &&
+			(updateData.Version != "" || updateData.Content != "" || updateData.FontLeft != "" || updateData.FontRight != "") {
+			return c.JSON(409, map[string]string{"error": ErrStudyTextPublished.Error()})
+		}
+		if studyText.Status == studyTextDraft && updateData.Active != nil && *updateData.Active {
+			return c.JSON(409, map[string]string{"error": "Drafts cannot be activated; publish them with POST /api/admin/study-texts/:id/publish"})
+		}
+
+		// Update fields
+		if updateData.Version != "" {
+			studyText.Version = updateData.Version
+		}
+		if updateData.Content != "" {
+			studyText.Content = updateData.Content
+		}
+		if updateData.FontLeft != "" {
+			studyText.FontLeft = updateData.FontLeft
+		}
+		if updateData.FontRight != "" {
+			studyText.FontRight = updateData.FontRight
+		}
+		if updateData.Active != nil {
+			studyText.Active = *updateData.Active
+		}
+		if updateData.CompletionCode != nil {
+			studyText.CompletionCode = strings.TrimSpace(*updateData.CompletionCode)
+		}
+		if updateData.CompletionURL != nil {
+			studyText.CompletionURL = *updateData.CompletionURL
+		}
+
+		err = s.repo.Transaction(func(repo store.Repository) error {
+			// If setting to active, deactivate all others first
+			if updateData.Active != nil && *updateData.Active {
+				if err := repo.StudyTexts().DeactivateOthers(updateData.ID); err != nil {
+					return err
+				}
+			}
+			if err := repo.StudyTexts().Save(&studyText); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditUpdate, entityStudyText, studyText.ID, before, studyText)
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to update study text: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"id":      studyText.ID,
+			"message": "Study text updated successfully",
+		})
+
+	case "GET":
+		// List all study texts
+		studyTexts, err := s.repo.StudyTexts().List()
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch study texts: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"data":    studyTexts,
+		})
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+func (s *Server) handleAdminQuizQuestion(c echo.Context) error {
+	switch c.Request().Method {
+	case "POST":
+		// Create new quiz question
+		var questionData struct {
+			StudyTextID uint     `json:"study_text_id"`
+			PassageID   *uint    `json:"passage_id,omitempty"` // Optional: link to specific passage
+			QuestionID  string   `json:"question_id"`
+			Prompt      string   `json:"prompt"`
+			Choices     []string `json:"choices"`
+			Answer      int      `json:"answer"`
+			Order       int      `json:"order"`
+		}
+
+		if err := c.Bind(&questionData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		// Validate required fields
+		if questionData.StudyTextID == 0 || questionData.QuestionID == "" || questionData.Prompt == "" {
+			return c.JSON(400, map[string]string{"error": "study_text_id, question_id, and prompt are required"})
+		}
+
+		// If passage_id is provided, verify it exists and belongs to the study_text_id
+		if questionData.PassageID != nil && *questionData.PassageID > 0 {
+			if _, err := s.repo.Passages().GetInStudyText(*questionData.PassageID, questionData.StudyTextID); err != nil {
+				return c.JSON(404, map[string]string{"error": "Passage not found or does not belong to the specified study text"})
+			}
+		}
+
+		if err := validateAnswer(questionData.Answer, questionData.Choices); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+
+		// Convert choices to JSON string
+		choicesJSON, err := json.Marshal(questionData.Choices)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid choices format: " + err.Error()})
+		}
+
+		question := store.QuizQuestion{
+			StudyTextID: questionData.StudyTextID,
+			PassageID:   questionData.PassageID,
+			QuestionID:  questionData.QuestionID,
+			Prompt:      questionData.Prompt,
+			Choices:     string(choicesJSON),
+			Answer:      questionData.Answer,
+			Order:       questionData.Order,
+		}
+
+		err = s.repo.Transaction(func(repo store.Repository) error {
+			if err := requireDraft(repo, question.StudyTextID); err != nil {
+				return err
+			}
+			if err := repo.QuizQuestions().Create(&question); err != nil {
+				return err
+			}
+			if err := repo.QuizQuestions().Revise(&question, adminActor(c)); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditCreate, entityQuizQuestion, question.ID, nil, question)
+		})
+		if isDraftError(err) {
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to create quiz question: " + err.Error()})
+		}
+
+		return c.JSON(201, map[string]interface{}{
+			"success":  true,
+			"id":       question.ID,
+			"revision": question.Revision,
+			"message":  "Quiz question created successfully",
+		})
+
+	case "PUT":
+		// Update existing quiz question
+		var updateData struct {
+			ID         uint     `json:"id"`
+			PassageID  *uint    `json:"passage_id,omitempty"` // Optional: can update passage link
+			QuestionID string   `json:"question_id,omitempty"`
+			Prompt     string   `json:"prompt,omitempty"`
+			Choices    []string `json:"choices,omitempty"`
+			Answer     *int     `json:"answer,omitempty"`
+			Order      *int     `json:"order,omitempty"`
+		}
+
+		if err := c.Bind(&updateData); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+
+		if updateData.ID == 0 {
+			return c.JSON(400, map[string]string{"error": "ID is required"})
+		}
+
+		var choicesJSON []byte
+		if updateData.Choices != nil {
+			var err error
+			if choicesJSON, err = json.Marshal(updateData.Choices); err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid choices format: " + err.Error()})
+			}
+		}
+
+		var question store.QuizQuestion
+		err := s.repo.Transaction(func(repo store.Repository) error {
+			var err error
+			if question, err = repo.QuizQuestions().GetForUpdate(updateData.ID); err != nil {
+				return notFoundAs(err, errQuizQuestionNotFound)
+			}
+			before := question
+			if err := requireDraft(repo, question.StudyTextID); err != nil {
+				return err
+			}
+
+			// If passage_id is being updated, verify it exists and belongs to the study_text_id
+			if updateData.PassageID != nil {
+				if *updateData.PassageID > 0 {
+					if _, err := repo.Passages().GetInStudyText(*updateData.PassageID, question.StudyTextID); err != nil {
+						return notFoundAs(err, errPassageNotInText)
+					}
+				}
+				question.PassageID = updateData.PassageID
+			}
+
+			// Update fields
+			if updateData.QuestionID != "" {
+				question.QuestionID = updateData.QuestionID
+			}
+			if updateData.Prompt != "" {
+				question.Prompt = updateData.Prompt
+			}
+			if choicesJSON != nil {
+				question.Choices = string(choicesJSON)
+			}
+			if updateData.Answer != nil {
+				question.Answer = *updateData.Answer
+			}
+			if updateData.Order != nil {
+				question.Order = *updateData.Order
+			}
+
+			// The answer has to fit the choices, whichever of the two changed
+			var choices []string
+			if err := json.Unmarshal([]byte(question.Choices), &choices); err != nil {
+				return fmt.Errorf("invalid choices stored for quiz question: %w", err)
+			}
+			if err := validateAnswer(question.Answer, choices); err != nil {
+				return err
+			}
+
+			if err := repo.QuizQuestions().Save(&question); err != nil {
+				return err
+			}
+			if err := repo.QuizQuestions().Revise(&question, adminActor(c)); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditUpdate, entityQuizQuestion, question.ID, before, question)
+		})
+		switch {
+		case errors.Is(err, errQuizQuestionNotFound):
+			return c.JSON(404, map[string]string{"error": "Quiz question not found"})
+		case errors.Is(err, errPassageNotInText):
+			return c.JSON(404, map[string]string{"error": "Passage not found or does not belong to the study text"})
+		case errors.Is(err, errAnswerNotAChoice):
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		case isDraftError(err):
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to update quiz question: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success":  true,
+			"id":       question.ID,
+			"revision": question.Revision,
+			"message":  "Quiz question updated successfully",
+		})
+
+	case "DELETE":
+		// Delete quiz question
+		id := c.QueryParam("id")
+		if id == "" {
+			return c.JSON(400, map[string]string{"error": "ID parameter is required"})
+		}
+		questionID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid quiz question ID"})
+		}
+
+		var question store.QuizQuestion
+		err = s.repo.Transaction(func(repo store.Repository) error {
+			var err error
+			if question, err = repo.QuizQuestions().GetForUpdate(uint(questionID)); err != nil {
+				return notFoundAs(err, errQuizQuestionNotFound)
+			}
+			if err := requireDraft(repo, question.StudyTextID); err != nil {
+				return err
+			}
+			if err := repo.QuizQuestions().Delete(&question); err != nil {
+				return err
+			}
+			return recordAudit(repo, c, auditDelete, entityQuizQuestion, question.ID, question, nil)
+		})
+		if errors.Is(err, errQuizQuestionNotFound) {
+			return c.JSON(404, map[string]string{"error": "Quiz question not found"})
+		}
+		if isDraftError(err) {
+			return respondDraftError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to delete quiz question: " + err.Error()})
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"message": "Quiz question deleted successfully",
+		})
+
+	case "GET":
+		// Get quiz question(s) - by id, passage_id, or study_text_id
+		id := c.QueryParam("id")
+		passageID := c.QueryParam("passage_id")
+		studyTextID := c.QueryParam("study_text_id")
+
+		if id != "" {
+			// Get single quiz question by ID
+			questionID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid quiz question ID"})
+			}
+			question, err := s.repo.QuizQuestions().Get(uint(questionID))
+			if err != nil {
+				return c.JSON(404, map[string]string{"error": "Quiz question not found"})
+			}
+
+			// Parse choices JSON
+			var choices []string
+			json.Unmarshal([]byte(question.Choices), &choices)
+
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"data": map[string]interface{}{
+					"id":            question.ID,
+					"study_text_id": question.StudyTextID,
+					"passage_id":    question.PassageID,
+					"question_id":   question.QuestionID,
+					"prompt":        question.Prompt,
+					"choices":       choices,
+					"answer":        question.Answer,
+					"order":         question.Order,
+					"revision":      question.Revision,
+				},
+			})
+		} else if passageID != "" {
+			// Get all quiz questions for a passage
+			id, err := strconv.ParseUint(passageID, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+			}
+			questions, err := s.repo.QuizQuestions().ForPassage(uint(id))
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to fetch quiz questions: " + err.Error()})
+			}
+
+			// Format response
+			type QuestionResponse struct {
+				ID          uint     `json:"id"`
+				StudyTextID uint     `json:"study_text_id"`
+				PassageID   *uint    `json:"passage_id"`
+				QuestionID  string   `json:"question_id"`
+				Prompt      string   `json:"prompt"`
+				Choices     []string `json:"choices"`
+				Answer      int      `json:"answer"`
+				Order       int      `json:"order"`
+				Revision    int      `json:"revision"`
+			}
+
+			response := make([]QuestionResponse, len(questions))
+			for i, q := range questions {
+				var choices []string
+				json.Unmarshal([]byte(q.Choices), &choices)
+				response[i] = QuestionResponse{
+					ID:          q.ID,
+					StudyTextID: q.StudyTextID,
+					PassageID:   q.PassageID,
+					QuestionID:  q.QuestionID,
+					Prompt:      q.Prompt,
+					Choices:     choices,
+					Answer:      q.Answer,
+					Order:       q.Order,
+					Revision:    q.Revision,
+				}
+			}
+
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"data":    response,
+			})
+		} else if studyTextID != "" {
+			// Get all quiz questions for a study text (including those linked to passages)
+			id, err := strconv.ParseUint(studyTextID, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+			}
+			questions, err := s.repo.QuizQuestions().ForStudyText(uint(id))
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to fetch quiz questions: " + err.Error()})
+			}
+
+			// Format response
+			type QuestionResponse struct {
+				ID          uint     `json:"id"`
+				StudyTextID uint     `json:"study_text_id"`
+				PassageID   *uint    `json:"passage_id"`
+				QuestionID  string   `json:"question_id"`
+				Prompt      string   `json:"prompt"`
+				Choices     []string `json:"choices"`
+				Answer      int      `json:"answer"`
+				Order       int      `json:"order"`
+				Revision    int      `json:"revision"`
+			}
+
+			response := make([]QuestionResponse, len(questions))
+			for i, q := range questions {
+				var choices []string
+				json.Unmarshal([]byte(q.Choices), &choices)
+				response[i] = QuestionResponse{
+					ID:          q.ID,
+					StudyTextID: q.StudyTextID,
+					PassageID:   q.PassageID,
+					QuestionID:  q.QuestionID,
+					Prompt:      q.Prompt,
+					Choices:     choices,
+					Answer:      q.Answer,
+					Order:       q.Order,
+					Revision:    q.Revision,
+				}
+			}
+
+			return c.JSON(200, map[string]interface{}{
+				"success": true,
+				"data":    response,
+			})
+		} else {
+			return c.JSON(400, map[string]string{"error": "Either id, passage_id, or study_text_id parameter is required"})
+		}
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+func (s *Server) handleAdminStatistics(c echo.Context) error {
+	type Statistics struct {
+		Participants struct {
+			Total    int64            `json:"total"`
+			BySource map[string]int64 `json:"by_source"`
+		} `json:"participants"`
+		Sessions struct {
+			Total    int64            `json:"total"`
+			ByStatus map[string]int64 `json:"by_status"`
+		} `json:"sessions"`
+		FontPreferences struct {
+			Serif      int64            `json:"serif"`
+			Sans       int64            `json:"sans"`
+			Total      int64            `json:"total"`
+			ByFont     map[string]int64 `json:"by_font"`
+			ByCategory map[string]int64 `json:"by_category"`
+		} `json:"font_preferences"`
+		QuizPerformance struct {
+			TotalResponses  int64   `json:"total_responses"`
+			CorrectAnswers  int64   `json:"correct_answers"`
+			AverageAccuracy float64 `json:"average_accuracy"`
+			ByQuestion      map[string]struct {
+				Total    int64   `json:"total"`
+				Correct  int64   `json:"correct"`
+				Accuracy float64 `json:"accuracy"`
+			} `json:"by_question"`
+		} `json:"quiz_performance"`
+		ReadingTimes struct {
+			AverageSerif  float64            `json:"average_serif_ms"`
+			AverageSans   float64            `json:"average_sans_ms"`
+			TotalSessions int64              `json:"total_sessions"`
+			ByFont        map[string]float64 `json:"by_font"`     // Average ms per font name
+			ByCategory    map[string]float64 `json:"by_category"` // Average ms per font category
+		} `json:"reading_times"`
+		AccuracyMeasurements struct {
+			Total           int64   `json:"total"`
+			AverageAccuracy float64 `json:"average_accuracy"`
+			Passed          int64   `json:"passed"`
+			Failed          int64   `json:"failed"`
+		} `json:"accuracy_measurements"`
+		GazePoints struct {
+			Total   int64            `json:"total"`
+			ByPhase map[string]int64 `json:"by_phase"`
+			ByPanel map[string]int64 `json:"by_panel"`
+		} `json:"gaze_points"`
+		CalibrationData struct {
+			Total int64 `json:"total"`
+		} `json:"calibration_data"`
+		Exclusions struct {
+			Applied          bool  `json:"applied"`
+			ExcludedSessions int64 `json:"excluded_sessions"`
+		} `json:"exclusions"`
+	}
+
+	exclude, err := s.parseExcludeParam(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	// With ?exclude=true, sessions excluded by the quality checks are left out of everything except participant counts
+	scope := func(query *gorm.DB, column string) *gorm.DB {
+		return s.withoutExcluded(query, column, exclude)
+	}
+
+	// A failed query fails the whole report, so missing data never shows up as zeros
+	failed := func(what string, err error) error {
+		return c.JSON(500, map[string]string{"error": "Failed to " + what + ": " + err.Error()})
+	}
+
+	var stats Statistics
+	stats.Exclusions.Applied = exclude
+	if err := s.db.Model(&store.SessionQuality{}).Where("included = ?", false).Count(&stats.Exclusions.ExcludedSessions).Error; err != nil {
+		return failed("count excluded sessions", err)
+	}
+
+	// Initialize maps
+	stats.Participants.BySource = make(map[string]int64)
+	stats.Sessions.ByStatus = make(map[string]int64)
+	stats.QuizPerformance.ByQuestion = make(map[string]struct {
+		Total    int64   `json:"total"`
+		Correct  int64   `json:"correct"`
+		Accuracy float64 `json:"accuracy"`
+	})
+	stats.GazePoints.ByPhase = make(map[string]int64)
+	stats.GazePoints.ByPanel = make(map[string]int64)
+	stats.FontPreferences.ByFont = make(map[string]int64)
+	stats.FontPreferences.ByCategory = make(map[string]int64)
+	stats.ReadingTimes.ByFont = make(map[string]float64)
+	stats.ReadingTimes.ByCategory = make(map[string]float64)
+
+	// Fonts are grouped by name and by category; legacy "serif"/"sans" values only count towards a category
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return failed("load fonts", err)
+	}
+
+	// Participants
+	if err := s.db.Model(&store.Participant{}).Count(&stats.Participants.Total).Error; err != nil {
+		return failed("count participants", err)
+	}
+	var participantSources []struct {
+		Source string
+		Count  int64
+	}
+	if err := s.db.Model(&store.Participant{}).Select("source, COUNT(*) as count").Group("source").Scan(&participantSources).Error; err != nil {
+		return failed("count participant sources", err)
+	}
+	for _, ps := range participantSources {
+		stats.Participants.BySource[ps.Source] = ps.Count
+	}
+
+	// Sessions
+	if err := scope(s.db.Model(&store.StudySession{}), "id").Count(&stats.Sessions.Total).Error; err != nil {
+		return failed("count sessions", err)
+	}
+	var statusCounts []struct {
+		Status string
+		Count  int64
+	}
+	if err := scope(s.db.Model(&store.StudySession{}), "id").Select("status, COUNT(*) as count").Group("status").Scan(&statusCounts).Error; err != nil {
+		return failed("count session statuses", err)
+	}
+	for _, sc := range statusCounts {
+		stats.Sessions.ByStatus[sc.Status] = sc.Count
+	}
+
+	// Font Preferences
+	var preferenceCounts []struct {
+		PreferredFontType string
+		Count             int64
+	}
+	if err := scope(s.db.Model(&store.StudySession{}), "id").Select("preferred_font_type, COUNT(*) as count").Where("preferred_font_type IS NOT NULL AND preferred_font_type != ''").Group("preferred_font_type").Scan(&preferenceCounts).Error; err != nil {
+		return failed("count font preferences", err)
+	}
+	for _, pc := range preferenceCounts {
+		if font, ok := fonts.lookup(pc.PreferredFontType); ok {
+			stats.FontPreferences.ByFont[font.Name] += pc.Count
+		}
+		category := fonts.category(pc.PreferredFontType)
+		stats.FontPreferences.ByCategory[category] += pc.Count
+		switch category {
+		case "serif":
+			stats.FontPreferences.Serif += pc.Count
+		case "sans-serif":
+			stats.FontPreferences.Sans += pc.Count
+		}
+	}
+	stats.FontPreferences.Total = stats.FontPreferences.Serif + stats.FontPreferences.Sans
+
+	// Quiz Performance
+	if err := scope(s.db.Model(&store.QuizResponse{}), "session_id").Count(&stats.QuizPerformance.TotalResponses).Error; err != nil {
+		return failed("count quiz responses", err)
+	}
+	var correctCount int64
+	if err := scope(s.db.Model(&store.QuizResponse{}), "session_id").Where("is_correct = ?", true).Count(&correctCount).Error; err != nil {
+		return failed("count correct answers", err)
+	}
+	stats.QuizPerformance.CorrectAnswers = correctCount
+	if stats.QuizPerformance.TotalResponses > 0 {
+		stats.QuizPerformance.AverageAccuracy = float64(correctCount) / float64(stats.QuizPerformance.TotalResponses) * 100
+	}
+
+	// Quiz by question
+	var quizResults []struct {
+		QuestionID string
+		Total      int64
+		Correct    int64
+	}
+	if err := scope(s.db.Model(&store.QuizResponse{}), "session_id").Select("question_id, COUNT(*) as total, SUM(CASE WHEN is_correct THEN 1 ELSE 0 END) as correct").Group("question_id").Scan(&quizResults).Error; err != nil {
+		return failed("count quiz results", err)
+	}
+	for _, result := range quizResults {
+		accuracy := 0.0
+		if result.Total > 0 {
+			accuracy = float64(result.Correct) / float64(result.Total) * 100
+		}
+		stats.QuizPerformance.ByQuestion[result.QuestionID] = struct {
+			Total    int64   `json:"total"`
+			Correct  int64   `json:"correct"`
+			Accuracy float64 `json:"accuracy"`
+		}{Total: result.Total, Correct: result.Correct, Accuracy: accuracy}
+	}
+
+	// Reading Times
+	var sessionCount int64
+	if err := scope(s.db.Model(&store.StudySession{}), "id").Where("time_left_ms > 0 OR time_right_ms > 0").Count(&sessionCount).Error; err != nil {
+		return failed("count timed sessions", err)
+	}
+	var timedSessions []store.StudySession
+	if err := scope(s.db.Select("font_left, font_right, time_left_ms, time_right_ms"), "id").Where("time_left_ms > 0 OR time_right_ms > 0").Find(&timedSessions).Error; err != nil {
+		return failed("load reading times", err)
+	}
+	fontTimes := make(map[string][]float64)
+	categoryTimes := make(map[string][]float64)
+	for _, session := range timedSessions {
+		for _, side := range []struct {
+			font string
+			ms   int
+		}{{session.FontLeft, session.TimeLeftMS}, {session.FontRight, session.TimeRightMS}} {
+			if side.ms <= 0 || side.font == "" {
+				continue
+			}
+			if font, ok := fonts.lookup(side.font); ok {
+				fontTimes[font.Name] = append(fontTimes[font.Name], float64(side.ms))
+			}
+			category := fonts.category(side.font)
+			categoryTimes[category] = append(categoryTimes[category], float64(side.ms))
+		}
+	}
+	for name, times := range fontTimes {
+		stats.ReadingTimes.ByFont[name] = analysis.Mean(times)
+	}
+	for category, times := range categoryTimes {
+		stats.ReadingTimes.ByCategory[category] = analysis.Mean(times)
+	}
+	stats.ReadingTimes.AverageSerif = analysis.Mean(categoryTimes["serif"])
+	stats.ReadingTimes.AverageSans = analysis.Mean(categoryTimes["sans-serif"])
+	stats.ReadingTimes.TotalSessions = sessionCount
+
+	// Accuracy Measurements
+	var avgAccuracy float64
+	var passedCount, failedCount int64
+	if err := scope(s.db.Model(&store.AccuracyMeasurement{}), "session_id").Count(&stats.AccuracyMeasurements.Total).Error; err != nil {
+		return failed("count accuracy measurements", err)
+	}
+	if err := scope(s.db.Model(&store.AccuracyMeasurement{}), "session_id").Select("COALESCE(AVG(accuracy), 0)").Scan(&avgAccuracy).Error; err != nil {
+		return failed("average accuracy measurements", err)
+	}
+	if err := scope(s.db.Model(&store.AccuracyMeasurement{}), "session_id").Where("passed = ?", true).Count(&passedCount).Error; err != nil {
+		return failed("count passed accuracy checks", err)
+	}
+	if err := scope(s.db.Model(&store.AccuracyMeasurement{}), "session_id").Where("passed = ?", false).Count(&failedCount).Error; err != nil {
+		return failed("count failed accuracy checks", err)
+	}
+	stats.AccuracyMeasurements.AverageAccuracy = avgAccuracy
+	stats.AccuracyMeasurements.Passed = passedCount
+	stats.AccuracyMeasurements.Failed = failedCount
+
+	// Gaze Points
+	if err := scope(s.db.Model(&store.GazePoint{}), "session_id").Count(&stats.GazePoints.Total).Error; err != nil {
+		return failed("count gaze points", err)
+	}
+	var phaseCounts []struct {
+		Phase string
+		Count int64
+	}
+	if err := scope(s.db.Model(&store.GazePoint{}), "session_id").Select("phase, COUNT(*) as count").Where("phase IS NOT NULL AND phase != ''").Group("phase").Scan(&phaseCounts).Error; err != nil {
+		return failed("count gaze phases", err)
+	}
+	for _, pc := range phaseCounts {
+		stats.GazePoints.ByPhase[pc.Phase] = pc.Count
+	}
+	var panelCounts []struct {
+		Panel string
+		Count int64
+	}
+	if err := scope(s.db.Model(&store.GazePoint{}), "session_id").Select("panel, COUNT(*) as count").Where("panel IS NOT NULL AND panel != ''").Group("panel").Scan(&panelCounts).Error; err != nil {
+		return failed("count gaze panels", err)
+	}
+	for _, pc := range panelCounts {
+		stats.GazePoints.ByPanel[pc.Panel] = pc.Count
+	}
+
+	// Calibration Data
+	if err := scope(s.db.Model(&store.CalibrationData{}), "session_id").Count(&stats.CalibrationData.Total).Error; err != nil {
+		return failed("count calibration data", err)
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    stats,
+	})
+}
@@ -0,0 +1,755 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"readability-backend/config"
+	"readability-backend/store"
+)
+
+func TestStudyText(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	var studyText struct {
+		ID       uint   `json:"id"`
+		Version  string `json:"version"`
+		Passages []struct {
+			Order int `json:"order"`
+		} `json:"passages"`
+	}
+	ts.expect("GET", "/api/study-text", nil, "", 200, &studyText)
+	if studyText.Version != "default" || len(studyText.Passages) != 6 {
+		t.Fatalf("study text = %+v, want the 6 seeded passages", studyText)
+	}
+	for i, passage := range studyText.Passages {
+		if passage.Order != i {
+			t.Errorf("passage %d has order %d", i, passage.Order)
+		}
+	}
+
+	// Unknown versions fall back to the active study text
+	ts.expect("GET", "/api/study-text?version=default", nil, "", 200, nil)
+	ts.expect("GET", "/api/study-text?version=missing", nil, "", 200, nil)
+
+	ts.db.Exec("UPDATE study_texts SET active = ?", false)
+	ts.expect("GET", "/api/study-text", nil, "", 404, nil)
+}
+
+func TestQuizQuestions(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	var questions []quizQuestionJSON
+	ts.expect("GET", "/api/quiz-questions", nil, "", 200, &questions)
+	if len(questions) != 5 {
+		t.Errorf("got %d quiz questions, want the 5 seeded ones", len(questions))
+	}
+	ts.expect("GET", "/api/quiz-questions?study_text_id=1", nil, "", 200, &questions)
+	if len(questions) != 5 {
+		t.Errorf("study_text_id=1: got %d quiz questions, want 5", len(questions))
+	}
+	ts.expect("GET", "/api/quiz-questions?passage_id=1", nil, "", 200, &questions)
+	if len(questions) != 0 {
+		t.Errorf("passage_id=1: got %d quiz questions, want none", len(questions))
+	}
+
+	ts.db.Exec("UPDATE study_texts SET active = ?", false)
+	ts.expect("GET", "/api/quiz-questions", nil, "", 404, nil)
+}
+
+func TestFonts(t *testing.T) {
+	ts := newTestServer(t, nil)
+	var fonts struct {
+		Data []struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/fonts", nil, "", 200, &fonts)
+	if len(fonts.Data) != 6 {
+		t.Errorf("got %d fonts, want the 6 seeded ones", len(fonts.Data))
+	}
+}
+
+// TestParticipantFlow walks one participant through consent, the
+// questionnaire and a whole session, in the order the frontend sends data
+func TestParticipantFlow(t *testing.T) { testParticipantFlow(t, newTestServer) }
+
+func testParticipantFlow(t *testing.T, newServer newServerFunc) {
+	ts := newServer(t, func(cfg *config.Config) { cfg.Ingestion.MaxGazeBatchSize = 3 })
+
+	// Participants
+	var participant struct {
+		result
+		Source string `json:"source"`
+	}
+	ts.expect("POST", "/api/participant", map[string]string{"source": "test"}, "", 201, &participant)
+	if participant.Source != "test" {
+		t.Errorf("source = %q, want test", participant.Source)
+	}
+	ts.expect("POST", "/api/participant", `{"source":`, "", 400, nil)
+	ts.expect("POST", "/api/participant", map[string]string{"source": "prolific"}, "", 400, nil)
+	participantID := participant.ID
+
+	// Sessions need consent first
+	session := map[string]interface{}{
+		"participant_id":      participantID,
+		"font_left":           "serif",
+		"font_right":          "sans",
+		"font_preference":     "A",
+		"preferred_font_type": "serif",
+		"user_agent":          "go test",
+		"screen_width":        1920,
+		"screen_height":       1080,
+	}
+	var denied struct {
+		ConsentVersion string `json:"consent_version"`
+	}
+	ts.expect("POST", "/api/session", session, "", 403, &denied)
+
+	// Consent
+	var consent struct {
+		Version string `json:"version"`
+	}
+	ts.expect("GET", "/api/consent", nil, "", 200, &consent)
+	if consent.Version == "" || consent.Version != denied.ConsentVersion {
+		t.Errorf("consent version %q, session was denied for %q", consent.Version, denied.ConsentVersion)
+	}
+	accept := map[string]interface{}{"participant_id": participantID, "version": consent.Version, "accepted": true}
+	ts.expect("POST", "/api/consent", map[string]interface{}{"participant_id": participantID, "version": consent.Version}, "", 400, nil)
+	ts.expect("POST", "/api/consent", map[string]interface{}{"participant_id": 999, "version": consent.Version, "accepted": true}, "", 404, nil)
+	ts.expect("POST", "/api/consent", map[string]interface{}{"participant_id": participantID, "version": "v0", "accepted": true}, "", 404, nil)
+	ts.expect("POST", "/api/consent", accept, "", 201, nil)
+
+	// Demographics
+	ts.expect("GET", "/api/demographics", nil, "", 200, nil)
+	answers := map[string]string{
+		"age_band":          "25-34",
+		"native_language":   "English",
+		"vision_correction": "No",
+		"dyslexia":          "No",
+	}
+	ts.expect("POST", "/api/demographics", map[string]interface{}{"answers": answers}, "", 400, nil)
+	ts.expect("POST", "/api/demographics", map[string]interface{}{"participant_id": 999, "answers": answers}, "", 404, nil)
+	ts.expect("POST", "/api/demographics", map[string]interface{}{"participant_id": participantID, "answers": map[string]string{"age_band": "200"}}, "", 400, nil)
+	ts.expect("POST", "/api/demographics", map[string]interface{}{"participant_id": participantID, "answers": answers}, "", 201, nil)
+
+	// Session
+	session["font_left"] = "comic-sans"
+	ts.expect("POST", "/api/session", session, "", 400, nil)
+	session["font_left"] = "serif"
+	ts.expect("POST", "/api/session", `{"participant_id":`, "", 400, nil)
+	var created result
+	ts.expect("POST", "/api/session", session, "", 201, &created)
+	sessionID := created.ID
+
+	// Data must follow the session lifecycle; reading data before calibration is out of order
+	gaze := map[string]interface{}{"session_id": sessionID, "x": 500.2, "y": 300.8, "panel": "A", "phase": "reading_A"}
+	var conflict struct {
+		Status string `json:"status"`
+	}
+	ts.expect("POST", "/api/gaze-point", gaze, "", 409, &conflict)
+	if conflict.Status != "created" {
+		t.Errorf("conflict status = %q, want created", conflict.Status)
+	}
+	ts.expect("POST", "/api/calibration", map[string]interface{}{"session_id": 999, "point_index": 0, "click_number": 1, "x": 100.5, "y": 200.3}, "", 404, nil)
+	ts.expect("POST", "/api/calibration", `{"session_id":`, "", 400, nil)
+
+	// Calibration and accuracy
+	ts.expect("POST", "/api/calibration", map[string]interface{}{"session_id": sessionID, "point_index": 0, "click_number": 1, "x": 100.5, "y": 200.3}, "", 201, nil)
+	var accuracy struct {
+		Passed bool `json:"passed"`
+	}
+	ts.expect("POST", "/api/accuracy", map[string]interface{}{"session_id": sessionID, "accuracy": 42, "duration": 5000, "passed": true}, "", 201, &accuracy)
+	if accuracy.Passed {
+		t.Error("accuracy below the threshold passed because the client said so")
+	}
+	ts.expect("POST", "/api/calibration", map[string]interface{}{"session_id": sessionID, "point_index": 0, "click_number": 2, "x": 100.5, "y": 200.3}, "", 201, nil)
+	ts.expect("POST", "/api/accuracy", map[string]interface{}{"session_id": sessionID, "accuracy": 85.5, "duration": 5000}, "", 201, &accuracy)
+	if !accuracy.Passed {
+		t.Error("accuracy above the threshold did not pass")
+	}
+
+	// Gaze points, one at a time and in batches
+	ts.expect("POST", "/api/gaze-point", gaze, "", 201, nil)
+	ts.expect("POST", "/api/gaze-point", map[string]interface{}{"session_id": sessionID, "x": 1200.5, "y": 400.2, "panel": "B", "phase": "reading_B"}, "", 201, nil)
+	ts.expect("POST", "/api/gaze-point", map[string]interface{}{"session_id": sessionID, "x": 960.0, "y": 540.0, "panel": "A", "phase": "waiting"}, "", 201, nil)
+	batch := map[string]interface{}{
+		"session_id": sessionID,
+		"points": []map[string]interface{}{
+			{"x": 510.0, "y": 305.0, "panel": "A", "phase": "reading_A"},
+			{"x": 520.4, "y": 310.1, "panel": "A", "phase": "reading_A"},
+			{"x": 1210.7, "y": 402.3, "panel": "B", "phase": "reading_B", "session_id": sessionID + 1},
+		},
+	}
+	var inserted struct {
+		Success  bool `json:"success"`
+		Inserted int  `json:"inserted"`
+		Failed   int  `json:"failed"`
+	}
+	ts.expect("POST", "/api/gaze-points/batch", batch, "", 201, &inserted)
+	if inserted.Success || inserted.Inserted != 2 || inserted.Failed != 1 {
+		t.Errorf("batch = %+v, want 2 inserted and 1 failed", inserted)
+	}
+	ts.expect("POST", "/api/gaze-points/batch", map[string]interface{}{"points": batch["points"]}, "", 400, nil)
+	ts.expect("POST", "/api/gaze-points/batch", map[string]interface{}{"session_id": sessionID, "points": []interface{}{}}, "", 400, nil)
+	tooMany := []map[string]float64{{"x": 1, "y": 1}, {"x": 2, "y": 2}, {"x": 3, "y": 3}, {"x": 4, "y": 4}}
+	ts.expect("POST", "/api/gaze-points/batch", map[string]interface{}{"session_id": sessionID, "points": tooMany}, "", 413, nil)
+	ts.expect("POST", "/api/gaze-points/batch", map[string]interface{}{"session_id": 999, "points": tooMany[:1]}, "", 404, nil)
+
+	// Reading events
+	ts.expect("POST", "/api/reading-event", map[string]interface{}{"session_id": sessionID, "event_type": "start", "panel": "A", "duration": 0}, "", 201, nil)
+	ts.expect("POST", "/api/reading-event", `{"session_id":`, "", 400, nil)
+
+	// A rejected quiz response doesn't move the session on to the quiz
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": sessionID, "question_id": "missing", "answer_index": 0}, "", 404, nil)
+	var reading store.StudySession
+	if err := ts.db.First(&reading, sessionID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reading.Status != store.StatusReading {
+		t.Errorf("status after a rejected quiz response = %s, want %s", reading.Status, store.StatusReading)
+	}
+
+	// Quiz responses are graded by the server
+	var graded struct {
+		IsCorrect *bool `json:"is_correct"`
+	}
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": sessionID, "question_id": "q1", "answer_index": 1, "response_time": 3000, "is_correct": false}, "", 201, &graded)
+	if graded.IsCorrect == nil || !*graded.IsCorrect {
+		t.Errorf("q1 answered with 1: is_correct = %v, want true", graded.IsCorrect)
+	}
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": sessionID, "question_id": "q2", "answer_index": 0, "is_correct": true}, "", 201, &graded)
+	if graded.IsCorrect == nil || *graded.IsCorrect {
+		t.Errorf("q2 answered with 0: is_correct = %v, want false", graded.IsCorrect)
+	}
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": sessionID, "question_id": "missing", "answer_index": 0}, "", 404, nil)
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": sessionID, "question_id": "q1", "answer_index": 9}, "", 400, nil)
+	ts.expect("POST", "/api/quiz-response", map[string]interface{}{"session_id": 999, "question_id": "q1", "answer_index": 1}, "", 404, nil)
+
+	// A second session the participant started in parallel and read in
+	var parallel result
+	ts.expect("POST", "/api/session", session, "", 201, &parallel)
+	ts.expect("POST", "/api/calibration", map[string]interface{}{"session_id": parallel.ID, "point_index": 0, "click_number": 1, "x": 100.5, "y": 200.3}, "", 201, nil)
+	ts.expect("POST", "/api/accuracy", map[string]interface{}{"session_id": parallel.ID, "accuracy": 85.5, "duration": 5000}, "", 201, nil)
+	ts.expect("POST", "/api/reading-event", map[string]interface{}{"session_id": parallel.ID, "event_type": "start", "panel": "A", "duration": 0}, "", 201, nil)
+
+	// Completion
+	complete := map[string]interface{}{"time_left_ms": 5000, "time_right_ms": 4500, "font_preference": "A"}
+	ts.expect("POST", "/api/session/abc/complete", complete, "", 400, nil)
+	ts.expect("POST", "/api/session/999/complete", complete, "", 404, nil)
+	var completed struct {
+		Status         string `json:"status"`
+		CompletionCode string `json:"completion_code"`
+	}
+	ts.expect("POST", fmt.Sprintf("/api/session/%d/complete", sessionID), complete, "", 200, &completed)
+	if completed.Status != "completed" || completed.CompletionCode == "" {
+		t.Errorf("completion = %+v", completed)
+	}
+	ts.expect("POST", fmt.Sprintf("/api/session/%d/complete", sessionID), complete, "", 409, nil)
+	ts.expect("POST", "/api/gaze-point", gaze, "", 409, nil)
+
+	// The participant can't earn a second completion code
+	ts.expect("POST", fmt.Sprintf("/api/session/%d/complete", parallel.ID), complete, "", 409, nil)
+	var repeat struct {
+		CompletionCode string `json:"completion_code"`
+	}
+	ts.expect("POST", "/api/session", session, "", 409, &repeat)
+	if repeat.CompletionCode != completed.CompletionCode {
+		t.Errorf("repeat session completion code = %q, want %q", repeat.CompletionCode, completed.CompletionCode)
+	}
+
+	// The statistics count what was collected
+	var stats struct {
+		Data struct {
+			Sessions struct {
+				ByStatus map[string]int64 `json:"by_status"`
+			} `json:"sessions"`
+			QuizPerformance struct {
+				ByQuestion map[string]struct {
+					Total   int64 `json:"total"`
+					Correct int64 `json:"correct"`
+				} `json:"by_question"`
+			} `json:"quiz_performance"`
+			GazePoints struct {
+				Total int64 `json:"total"`
+			} `json:"gaze_points"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/statistics?exclude=false", nil, ts.login(testViewer), 200, &stats)
+	if stats.Data.Sessions.ByStatus["completed"] != 1 {
+		t.Errorf("sessions by status = %v, want 1 completed", stats.Data.Sessions.ByStatus)
+	}
+	if q1 := stats.Data.QuizPerformance.ByQuestion["q1"]; q1.Total != 1 || q1.Correct != 1 {
+		t.Errorf("q1 = %d/%d correct, want 1/1", q1.Correct, q1.Total)
+	}
+	if stats.Data.GazePoints.Total != 5 {
+		t.Errorf("%d gaze points, want 5", stats.Data.GazePoints.Total)
+	}
+	ts.expect("GET", "/api/admin/statistics?exclude=maybe", nil, ts.login(testViewer), 400, nil)
+}
+
+// TestStatisticsErrors checks that a failed query fails the statistics
+// instead of reporting zeros
+func TestStatisticsErrors(t *testing.T) {
+	ts := newTestServer(t, nil)
+	viewer := ts.login(testViewer)
+
+	ts.expect("GET", "/api/admin/statistics", nil, viewer, 200, nil)
+	if err := ts.db.Migrator().DropTable("calibration_data"); err != nil {
+		t.Fatal(err)
+	}
+	ts.expect("GET", "/api/admin/statistics", nil, viewer, 500, nil)
+}
+
+// TestAdminStudyTextEditing creates a draft study text with passages and quiz
+// questions, edits it and publishes it, the way researchers set up a study
+func TestAdminStudyTextEditing(t *testing.T) { testAdminStudyTextEditing(t, newTestServer) }
+
+func testAdminStudyTextEditing(t *testing.T, newServer newServerFunc) {
+	ts := newServer(t, nil)
+	editor := ts.login(testEditor)
+
+	var list struct {
+		Data []struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/study-text", nil, editor, 200, &list)
+	if len(list.Data) != 1 || list.Data[0].Version != "default" {
+		t.Errorf("study texts = %+v, want only the default one", list.Data)
+	}
+
+	// Study texts start as drafts
+	draft := map[string]interface{}{"version": "test", "font_left": "georgia", "font_right": "inter"}
+	var created struct {
+		result
+		Status string `json:"status"`
+	}
+	ts.expect("POST", "/api/admin/study-text", draft, editor, 201, &created)
+	if created.Status != "draft" {
+		t.Errorf("new study text status = %q, want draft", created.Status)
+	}
+	studyTextID := created.ID
+	var existing result
+	ts.expect("POST", "/api/admin/study-text", draft, editor, 200, &existing)
+	if existing.ID != studyTextID {
+		t.Errorf("creating an existing version returned id %d, want %d", existing.ID, studyTextID)
+	}
+	ts.expect("POST", "/api/admin/study-text", map[string]interface{}{"version": "active", "active": true}, editor, 400, nil)
+	ts.expect("POST", "/api/admin/study-text", map[string]interface{}{"version": "fonts", "font_left": "comic-sans"}, editor, 400, nil)
+	ts.expect("POST", "/api/admin/study-text", `{"version":`, editor, 400, nil)
+
+	ts.expect("PUT", "/api/admin/study-text", map[string]interface{}{"id": studyTextID, "font_left": "inter", "font_right": "georgia"}, editor, 200, nil)
+	ts.expect("PUT", "/api/admin/study-text", map[string]interface{}{"font_left": "inter"}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/study-text", map[string]interface{}{"id": 999, "font_left": "inter"}, editor, 404, nil)
+	ts.expect("PUT", "/api/admin/study-text", map[string]interface{}{"id": studyTextID, "active": true}, editor, 409, nil)
+	ts.expect("PUT", "/api/admin/study-text", map[string]interface{}{"id": 1, "content": "changed"}, editor, 409, nil)
+
+	// Passages
+	passage := map[string]interface{}{
+		"study_text_id": studyTextID,
+		"order":         0,
+		"title":         "Test Passage",
+		"content":       "This is a test passage.",
+		"font_left":     "serif",
+		"font_right":    "sans",
+	}
+	var passageCreated struct {
+		result
+		Revision int `json:"revision"`
+	}
+	ts.expect("POST", "/api/admin/passage", passage, editor, 201, &passageCreated)
+	if passageCreated.Revision != 1 {
+		t.Errorf("new passage revision = %d, want 1", passageCreated.Revision)
+	}
+	passageID := passageCreated.ID
+	ts.expect("POST", "/api/admin/passage", map[string]interface{}{"study_text_id": studyTextID}, editor, 400, nil)
+	ts.expect("POST", "/api/admin/passage", `{"study_text_id":`, editor, 400, nil)
+	ts.expect("POST", "/api/admin/passage", map[string]interface{}{"study_text_id": 999, "content": "x"}, editor, 404, nil)
+	ts.expect("POST", "/api/admin/passage", map[string]interface{}{"study_text_id": 1, "content": "x"}, editor, 409, nil)
+
+	var passages struct {
+		Data []struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/passage?study_text_id=%d", studyTextID), nil, editor, 200, &passages)
+	if len(passages.Data) != 1 || passages.Data[0].ID != passageID {
+		t.Errorf("passages = %+v, want only %d", passages.Data, passageID)
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/passage?id=%d", passageID), nil, editor, 200, nil)
+	ts.expect("GET", "/api/admin/passage?id=999", nil, editor, 404, nil)
+	ts.expect("GET", "/api/admin/passage", nil, editor, 400, nil)
+	ts.expect("GET", "/api/admin/passage?id=abc", nil, editor, 400, nil)
+
+	ts.expect("PUT", "/api/admin/passage", map[string]interface{}{"id": passageID, "title": "Updated Test Passage", "content": "Updated content."}, editor, 200, &passageCreated)
+	if passageCreated.Revision != 2 {
+		t.Errorf("edited passage revision = %d, want 2", passageCreated.Revision)
+	}
+	ts.expect("PUT", "/api/admin/passage", map[string]interface{}{"title": "no id"}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/passage", `{"id":`, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/passage", map[string]interface{}{"id": 999, "title": "missing"}, editor, 404, nil)
+	ts.expect("PUT", "/api/admin/passage", map[string]interface{}{"id": 1, "title": "published"}, editor, 409, nil)
+
+	var doomed result
+	ts.expect("POST", "/api/admin/passage", map[string]interface{}{"study_text_id": studyTextID, "order": 999, "content": "This passage will be deleted."}, editor, 201, &doomed)
+	var doomedQuestion result
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": studyTextID, "passage_id": doomed.ID, "question_id": "q_doomed", "prompt": "Deleted with its passage?", "choices": []string{"A", "B"}}, editor, 201, &doomedQuestion)
+	ts.expect("DELETE", fmt.Sprintf("/api/admin/passage?id=%d", doomed.ID), nil, editor, 200, nil)
+	ts.expect("GET", fmt.Sprintf("/api/admin/quiz-question?id=%d", doomedQuestion.ID), nil, editor, 404, nil)
+	ts.expect("DELETE", fmt.Sprintf("/api/admin/passage?id=%d", doomed.ID), nil, editor, 404, nil)
+	ts.expect("DELETE", "/api/admin/passage", nil, editor, 400, nil)
+	ts.expect("DELETE", "/api/admin/passage?id=abc", nil, editor, 400, nil)
+	ts.expect("DELETE", "/api/admin/passage?id=1", nil, editor, 409, nil)
+
+	// Quiz questions
+	question := map[string]interface{}{
+		"study_text_id": studyTextID,
+		"passage_id":    passageID,
+		"question_id":   "q_test",
+		"prompt":        "Test question?",
+		"choices":       []string{"A", "B", "C", "D"},
+		"answer":        0,
+		"order":         1,
+	}
+	var questionCreated result
+	ts.expect("POST", "/api/admin/quiz-question", question, editor, 201, &questionCreated)
+	questionID := questionCreated.ID
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": studyTextID, "prompt": "no id"}, editor, 400, nil)
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": studyTextID, "passage_id": 1, "question_id": "q", "prompt": "other passage"}, editor, 404, nil)
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": 1, "question_id": "q", "prompt": "published", "choices": []string{"A", "B"}}, editor, 409, nil)
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": 999, "question_id": "q", "prompt": "missing", "choices": []string{"A", "B"}}, editor, 404, nil)
+	ts.expect("POST", "/api/admin/quiz-question", `{"study_text_id":`, editor, 400, nil)
+	for _, answer := range []int{-1, 4} {
+		question["answer"] = answer
+		ts.expect("POST", "/api/admin/quiz-question", question, editor, 400, nil)
+	}
+
+	var fetched struct {
+		Data struct {
+			QuestionID string   `json:"question_id"`
+			Choices    []string `json:"choices"`
+		} `json:"data"`
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/quiz-question?id=%d", questionID), nil, editor, 200, &fetched)
+	if fetched.Data.QuestionID != "q_test" || len(fetched.Data.Choices) != 4 {
+		t.Errorf("quiz question = %+v", fetched.Data)
+	}
+	var questions struct {
+		Data []struct {
+			QuestionID string `json:"question_id"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/quiz-question?study_text_id=1", nil, editor, 200, &questions)
+	if len(questions.Data) != 5 {
+		t.Errorf("study text 1 has %d quiz questions, want 5", len(questions.Data))
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/quiz-question?passage_id=%d", passageID), nil, editor, 200, &questions)
+	if len(questions.Data) != 1 {
+		t.Errorf("passage %d has %d quiz questions, want 1", passageID, len(questions.Data))
+	}
+	ts.expect("GET", "/api/admin/quiz-question?id=999", nil, editor, 404, nil)
+	ts.expect("GET", "/api/admin/quiz-question", nil, editor, 400, nil)
+	ts.expect("GET", "/api/admin/quiz-question?id=abc", nil, editor, 400, nil)
+
+	update := map[string]interface{}{"id": questionID, "prompt": "Updated test question?", "choices": []string{"Option 1", "Option 2"}, "answer": 1}
+	ts.expect("PUT", "/api/admin/quiz-question", update, editor, 200, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"id": questionID, "answer": -1}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"id": questionID, "answer": 2}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", `{"id":`, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"id": questionID, "choices": []string{"Only option"}}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"prompt": "no id"}, editor, 400, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"id": 999, "prompt": "missing"}, editor, 404, nil)
+	ts.expect("PUT", "/api/admin/quiz-question", map[string]interface{}{"id": 1, "prompt": "published"}, editor, 409, nil)
+
+	ts.expect("POST", "/api/admin/quiz-question", map[string]interface{}{"study_text_id": studyTextID, "question_id": "q_test_delete", "prompt": "This question will be deleted", "choices": []string{"A", "B"}}, editor, 201, &doomedQuestion)
+	ts.expect("DELETE", fmt.Sprintf("/api/admin/quiz-question?id=%d", doomedQuestion.ID), nil, editor, 200, nil)
+	ts.expect("DELETE", fmt.Sprintf("/api/admin/quiz-question?id=%d", doomedQuestion.ID), nil, editor, 404, nil)
+	ts.expect("DELETE", "/api/admin/quiz-question", nil, editor, 400, nil)
+	ts.expect("DELETE", "/api/admin/quiz-question?id=1", nil, editor, 409, nil)
+
+	// Drafts can be previewed but participants don't see them until they are published
+	var preview struct {
+		Data struct {
+			Passages []struct {
+				QuizQuestions []previewQuizQuestion `json:"quiz_questions"`
+			} `json:"passages"`
+		} `json:"data"`
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/study-texts/%d/preview", studyTextID), nil, ts.login(testViewer), 200, &preview)
+	if passages := preview.Data.Passages; len(passages) != 1 || len(passages[0].QuizQuestions) != 1 {
+		t.Errorf("preview = %+v, want one passage with one question", preview.Data)
+	} else if answer := passages[0].QuizQuestions[0].Answer; answer != 1 {
+		t.Errorf("preview answer = %d, want 1", answer)
+	}
+	ts.expect("GET", "/api/admin/study-texts/999/preview", nil, editor, 404, nil)
+	ts.expect("GET", "/api/admin/study-texts/abc/preview", nil, editor, 400, nil)
+
+	var participantQuestions []quizQuestionJSON
+	passageQuestions := fmt.Sprintf("/api/quiz-questions?passage_id=%d", passageID)
+	ts.expect("GET", passageQuestions, nil, "", 200, &participantQuestions)
+	if len(participantQuestions) != 0 {
+		t.Errorf("draft questions are visible to participants: %+v", participantQuestions)
+	}
+
+	publish := fmt.Sprintf("/api/admin/study-texts/%d/publish", studyTextID)
+	ts.expect("POST", publish, nil, editor, 200, nil)
+	ts.expect("POST", publish, nil, editor, 409, nil)
+	ts.expect("POST", "/api/admin/study-texts/999/publish", nil, editor, 404, nil)
+
+	ts.expect("GET", passageQuestions, nil, "", 200, &participantQuestions)
+	if len(participantQuestions) != 1 || participantQuestions[0].ID != "q_test" {
+		t.Errorf("published passage questions = %+v, want q_test", participantQuestions)
+	}
+	// Participants never receive the answer key
+	var rawQuestions []map[string]interface{}
+	ts.expect("GET", passageQuestions, nil, "", 200, &rawQuestions)
+	for _, question := range rawQuestions {
+		if _, ok := question["answer"]; ok {
+			t.Errorf("participant quiz question includes the answer: %v", question)
+		}
+	}
+	var active struct {
+		Version string `json:"version"`
+	}
+	ts.expect("GET", "/api/study-text", nil, "", 200, &active)
+	if active.Version != "test" {
+		t.Errorf("active study text = %q, want the published test version", active.Version)
+	}
+	ts.expect("PUT", "/api/admin/passage", map[string]interface{}{"id": passageID, "title": "published"}, editor, 409, nil)
+}
+
+func TestAOIs(t *testing.T) { testAOIs(t, newTestServer) }
+
+func testAOIs(t *testing.T, newServer newServerFunc) {
+	ts := newServer(t, nil)
+	editor := ts.login(testEditor)
+
+	session := store.StudySession{SessionID: "aois", Status: store.StatusAccuracyChecked}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Two words on one line, uploaded out of order
+	shownAt := time.Now().Add(-time.Minute).UTC()
+	layout := map[string]interface{}{
+		"session_id": session.ID,
+		"passage_id": 1,
+		"panel":      "A",
+		"shown_at":   shownAt,
+		"words": []map[string]interface{}{
+			{"index": 1, "line": 0, "text": "world", "x": 100, "y": 0, "width": 100, "height": 20},
+			{"index": 0, "line": 0, "text": "hello", "x": 0, "y": 0, "width": 100, "height": 20},
+		},
+		"lines": []map[string]interface{}{
+			{"index": 0, "text": "hello world", "x": 0, "y": 0, "width": 200, "height": 20},
+		},
+	}
+	ts.expect("POST", "/api/aois", map[string]interface{}{"session_id": session.ID, "passage_id": 1, "panel": "A"}, "", 400, nil)
+	ts.expect("POST", "/api/aois", layout, "", 201, nil)
+
+	// A fixation on each word
+	var points []store.GazePoint
+	for i := 0; i < 20; i++ {
+		x := 30.0
+		if i >= 10 {
+			x = 170
+		}
+		points = append(points, store.GazePoint{SessionID: session.ID, X: x, Y: 10, Panel: "A", Phase: "reading_A", Timestamp: shownAt.Add(time.Duration(100+50*i) * time.Millisecond)})
+	}
+	if err := ts.db.Create(&points).Error; err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/admin/session/%d/", session.ID)
+	ts.expect("POST", path+"fixations", map[string]string{"algorithm": "idt"}, editor, 201, nil)
+	ts.expect("POST", path+"aois", map[string]string{}, editor, 200, nil)
+
+	var listing struct {
+		Data []struct {
+			Kind           string `json:"kind"`
+			Index          int    `json:"index"`
+			GazePointCount int64  `json:"gaze_point_count"`
+			FixationCount  int64  `json:"fixation_count"`
+		} `json:"data"`
+	}
+	ts.expect("GET", path+"aois?algorithm=idt", nil, ts.login(testViewer), 200, &listing)
+	var got []string
+	for _, a := range listing.Data {
+		got = append(got, fmt.Sprintf("%s %d: %d points, %d fixations", a.Kind, a.Index, a.GazePointCount, a.FixationCount))
+	}
+	want := []string{
+		"line 0: 20 points, 2 fixations",
+		"word 0: 10 points, 1 fixations",
+		"word 1: 10 points, 1 fixations",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("AOIs = %q, want %q", got, want)
+	}
+
+	// The passage metrics count the reading until the session is excluded
+	quality := store.SessionQuality{SessionID: session.ID, AutoIncluded: true, Included: true, EvaluatedAt: time.Now()}
+	if err := ts.db.Create(&quality).Error; err != nil {
+		t.Fatal(err)
+	}
+	var metrics struct {
+		Data struct {
+			Overall struct {
+				Readings int `json:"readings"`
+			} `json:"overall"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/passages/1/metrics?algorithm=idt&exclude=true", nil, editor, 200, &metrics)
+	if metrics.Data.Overall.Readings != 1 {
+		t.Errorf("%d readings, want 1", metrics.Data.Overall.Readings)
+	}
+	if err := ts.db.Model(&quality).Update("included", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	ts.expect("GET", "/api/admin/passages/1/metrics?algorithm=idt&exclude=true", nil, editor, 200, &metrics)
+	if metrics.Data.Overall.Readings != 0 {
+		t.Errorf("%d readings of an excluded session, want 0", metrics.Data.Overall.Readings)
+	}
+}
+
+// TestFontRankingRefits checks that the cached ranking follows comparisons
+// written to the database by anything else, such as another server instance
+func TestFontRankingRefits(t *testing.T) {
+	ts := newTestServer(t, nil)
+	viewer := ts.login(testViewer)
+
+	var ranking struct {
+		Data struct {
+			Comparisons int `json:"comparisons"`
+			Ranking     []struct {
+				Font string `json:"font"`
+				Wins int    `json:"wins"`
+			} `json:"ranking"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/font-ranking", nil, viewer, 200, &ranking)
+	if ranking.Data.Comparisons != 0 {
+		t.Fatalf("new installation ranks %d comparisons, want 0", ranking.Data.Comparisons)
+	}
+
+	session := store.StudySession{SessionID: "ranking", Status: store.StatusCompleted}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	comparison := store.FontComparison{SessionID: session.ID, FontA: "georgia", FontB: "inter", Preferred: "inter", Timestamp: time.Now()}
+	if err := ts.db.Create(&comparison).Error; err != nil {
+		t.Fatal(err)
+	}
+	ts.expect("GET", "/api/admin/font-ranking", nil, viewer, 200, &ranking)
+	if ranking.Data.Comparisons != 1 || len(ranking.Data.Ranking) == 0 || ranking.Data.Ranking[0].Font != "inter" {
+		t.Errorf("after one comparison: %+v, want inter first of 1 comparison", ranking.Data)
+	}
+
+	if err := ts.db.Delete(&comparison).Error; err != nil {
+		t.Fatal(err)
+	}
+	ts.expect("GET", "/api/admin/font-ranking", nil, viewer, 200, &ranking)
+	if ranking.Data.Comparisons != 0 {
+		t.Errorf("after deleting the comparison: %d comparisons, want 0", ranking.Data.Comparisons)
+	}
+}
+
+func TestExclusions(t *testing.T) {
+	ts := newTestServer(t, nil)
+	viewer := ts.login(testViewer)
+
+	// Two sessions each preferred georgia over inter; the second one is excluded
+	for i, included := range []bool{true, false} {
+		session := store.StudySession{SessionID: fmt.Sprintf("exclusions-%d", i), Status: store.StatusCompleted}
+		if err := ts.db.Create(&session).Error; err != nil {
+			t.Fatal(err)
+		}
+		comparison := store.FontComparison{SessionID: session.ID, FontA: "georgia", FontB: "inter", Preferred: "georgia", Timestamp: time.Now()}
+		if err := ts.db.Create(&comparison).Error; err != nil {
+			t.Fatal(err)
+		}
+		quality := store.SessionQuality{SessionID: session.ID, AutoIncluded: included, Included: included, EvaluatedAt: time.Now()}
+		if err := ts.db.Create(&quality).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ranking struct {
+		Excluded bool `json:"excluded"`
+		Data     struct {
+			Comparisons int `json:"comparisons"`
+		} `json:"data"`
+	}
+	ts.expect("GET", "/api/admin/font-ranking", nil, viewer, 200, &ranking)
+	if ranking.Excluded || ranking.Data.Comparisons != 2 {
+		t.Errorf("ranking counts %d comparisons (excluded %v), want 2", ranking.Data.Comparisons, ranking.Excluded)
+	}
+	ts.expect("GET", "/api/admin/font-ranking?exclude=true", nil, viewer, 200, &ranking)
+	if !ranking.Excluded || ranking.Data.Comparisons != 1 {
+		t.Errorf("ranking with exclusions counts %d comparisons (excluded %v), want 1", ranking.Data.Comparisons, ranking.Excluded)
+	}
+	ts.expect("GET", "/api/admin/font-ranking?exclude=maybe", nil, viewer, 400, nil)
+
+	var metrics struct {
+		Excluded bool `json:"excluded"`
+	}
+	ts.expect("GET", "/api/admin/passages/1/metrics?exclude=true", nil, viewer, 200, &metrics)
+	if !metrics.Excluded {
+		t.Error("passage metrics did not apply exclusions")
+	}
+	ts.expect("GET", "/api/admin/passages/1/metrics?exclude=maybe", nil, viewer, 400, nil)
+}
+
+func TestQualityEvaluation(t *testing.T) {
+	ts := newTestServer(t, nil)
+	editor, viewer := ts.login(testEditor), ts.login(testViewer)
+
+	decisions := func() int64 {
+		var count int64
+		if err := ts.db.Model(&store.SessionQuality{}).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	// Sessions in progress are not scored, and reading statistics doesn't score anything
+	session := store.StudySession{SessionID: "quality", Status: store.StatusReading}
+	if err := ts.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/admin/session/%d/quality", session.ID)
+	ts.expect("GET", "/api/admin/statistics?exclude=true", nil, viewer, 200, nil)
+	ts.expect("GET", path, nil, viewer, 404, nil)
+	ts.expect("POST", path, nil, editor, 409, nil)
+	ts.expect("PUT", path, map[string]interface{}{"included": true}, editor, 409, nil)
+	if n := decisions(); n != 0 {
+		t.Fatalf("%d quality decisions for sessions in progress, want 0", n)
+	}
+
+	// Completing the session scores it
+	ts.expect("POST", fmt.Sprintf("/api/session/%d/complete", session.ID), map[string]interface{}{}, "", 200, nil)
+	var quality struct {
+		Data struct {
+			Included bool     `json:"included"`
+			Reasons  []string `json:"reasons"`
+		} `json:"data"`
+	}
+	ts.expect("GET", path, nil, viewer, 200, &quality)
+	if quality.Data.Included || len(quality.Data.Reasons) == 0 {
+		t.Errorf("session without any data: %+v, want excluded with reasons", quality.Data)
+	}
+
+	// So does abandoning it
+	idle := time.Now().Add(-time.Hour)
+	abandoned := store.StudySession{SessionID: "quality-idle", Status: store.StatusReading, LastActivityAt: &idle}
+	if err := ts.db.Create(&abandoned).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count, err := ts.sweepIdleSessions(time.Minute); err != nil || count != 1 {
+		t.Fatalf("sweep abandoned %d sessions (%v), want 1", count, err)
+	}
+	ts.expect("GET", fmt.Sprintf("/api/admin/session/%d/quality", abandoned.ID), nil, viewer, 200, nil)
+	if n := decisions(); n != 2 {
+		t.Errorf("%d quality decisions, want 2", n)
+	}
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sessionTransitions lists the statuses each status may move to. Staying in a
+// non-final status is always allowed, and any non-final status may be abandoned.
+// Failing the accuracy check sends participants back to calibration, and each
+// passage is read and then quizzed, so reading and quiz alternate.
+var sessionTransitions = map[string][]string{
+	store.StatusCreated:         {store.StatusCalibrated},
+	store.StatusCalibrated:      {store.StatusAccuracyChecked},
+	store.StatusAccuracyChecked: {store.StatusCalibrated, store.StatusReading},
+	store.StatusReading:         {store.StatusQuiz, store.StatusCompleted},
+	store.StatusQuiz:            {store.StatusReading, store.StatusCompleted},
+}
+
+var errSessionNotFound = errors.New("session not found")
+
+// transitionError is returned for data or a status change that doesn't fit the
+// session's current status
+type transitionError struct {
+	From string
+	To   string
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("session is %s and cannot move to %s", e.From, e.To)
+}
+
+// finalStatuses are the statuses a session ends in
+var finalStatuses = []string{store.StatusCompleted, store.StatusAbandoned}
+
+func isFinalStatus(status string) bool {
+	return status == store.StatusCompleted || status == store.StatusAbandoned
+}
+
+// canTransition reports whether a session may move from one status to another
+func canTransition(from, to string) bool {
+	if isFinalStatus(from) {
+		return false
+	}
+	if from == to || to == store.StatusAbandoned {
+		return true
+	}
+	for _, next := range sessionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// advanceSession moves a session to status, or keeps it there, and records the
+// activity. It returns the session and the status it was in before, and fails
+// with errSessionNotFound or a *transitionError when the move isn't allowed, so
+// data that arrives out of order is rejected. The caller publishes the status
+// change with publishStatus once the transaction of repo has committed.
+func (s *Server) advanceSession(repo store.Repository, sessionID uint, status string) (store.StudySession, string, error) {
+	// The update only applies if the status is still the one that was checked;
+	// if another request changed it in between, check again
+	for attempt := 0; attempt < 3; attempt++ {
+		session, err := repo.Sessions().Get(sessionID)
+		if errors.Is(err, store.ErrNotFound) {
+			return session, "", errSessionNotFound
+		}
+		if err != nil {
+			return session, "", err
+		}
+		if !canTransition(session.Status, status) {
+			return session, "", &transitionError{From: session.Status, To: status}
+		}
+
+		now := time.Now()
+		moved, err := repo.Sessions().MoveStatus(session.ID, session.Status, status, now)
+		if err != nil {
+			return session, "", err
+		}
+		if !moved {
+			continue
+		}
+
+		previous := session.Status
+		session.LastActivityAt = &now
+		if previous != status {
+			session.Status = status
+			session.StatusChangedAt = &now
+			if status == store.StatusCompleted {
+				session.CompletedAt = &now
+			}
+		}
+		return session, previous, nil
+	}
+	return store.StudySession{}, "", errors.New("session status kept changing, try again")
+}
+
+// publishStatus sends a status event if a session moved from one status to another
+func (s *Server) publishStatus(sessionID uint, from, to string) {
+	if from != to {
+		s.events.publish(eventStatus, sessionID, map[string]interface{}{"from": from, "to": to})
+	}
+}
+
+// storeSessionData advances a session to status and runs save in the same
+// transaction, so data is only stored if the session accepts it, and the status
+// only changes if the data is stored. The status event is published after commit.
+func (s *Server) storeSessionData(sessionID uint, status string, save func(repo store.Repository, session store.StudySession) error) error {
+	var session store.StudySession
+	var previous string
+	err := s.repo.Transaction(func(repo store.Repository) error {
+		var err error
+		if session, previous, err = s.advanceSession(repo, sessionID, status); err != nil {
+			return err
+		}
+		return save(repo, session)
+	})
+	if err != nil {
+		return err
+	}
+	s.publishStatus(session.ID, previous, session.Status)
+	return nil
+}
+
+// isSessionError reports whether err is advanceSession rejecting the session,
+// as opposed to data that failed to save
+func isSessionError(err error) bool {
+	var transition *transitionError
+	return errors.Is(err, errSessionNotFound) || errors.As(err, &transition)
+}
+
+// respondSessionError turns an advanceSession error into a response
+func respondSessionError(c echo.Context, err error) error {
+	var transition *transitionError
+	switch {
+	case errors.Is(err, errSessionNotFound):
+		return c.JSON(404, map[string]string{"error": "Session not found"})
+	case errors.As(err, &transition):
+		return c.JSON(409, map[string]string{"error": err.Error(), "status": transition.From})
+	default:
+		return c.JSON(500, map[string]string{"error": "Failed to update session status: " + err.Error()})
+	}
+}
+
+// sweepIdleSessions marks sessions without activity for longer than timeout as abandoned
+func (s *Server) sweepIdleSessions(timeout time.Duration) (int, error) {
+	cutoff := time.Now().Add(-timeout)
+	sessions, err := s.repo.Sessions().Idle(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	abandoned := 0
+	for _, session := range sessions {
+		// Abandon re-checks idleness and status, so a session that just became active is kept
+		moved, err := s.repo.Sessions().Abandon(session, cutoff, time.Now())
+		if err != nil {
+			return abandoned, err
+		}
+		if moved {
+			abandoned++
+			s.publishStatus(session.ID, session.Status, store.StatusAbandoned)
+			s.evaluateFinishedSession(session.ID)
+		}
+	}
+	return abandoned, nil
+}
+
+// StartSessionSweeper periodically abandons idle sessions in the background
+func (s *Server) StartSessionSweeper(timeout time.Duration) {
+	if timeout <= 0 {
+		log.Println("study.session_idle_timeout is 0, idle sessions will not be abandoned")
+		return
+	}
+
+	// Check often enough that sessions are abandoned within about a tenth of the timeout
+	interval := timeout / 10
+	if interval < 10*time.Second {
+		interval = 10 * time.Second
+	}
+	if interval > 5*time.Minute {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := s.sweepIdleSessions(timeout)
+			if err != nil {
+				log.Printf("Session sweep failed: %v", err)
+			} else if count > 0 {
+				log.Printf("Marked %d idle sessions as abandoned", count)
+			}
+		}
+	}()
+}
+
+// handleSessionComplete marks a session completed, storing the summary the
+// client collected along the way (fonts, reading times and preferences), and
+// issues the completion code for the recruitment platform
+func (s *Server) handleSessionComplete(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid session ID"})
+	}
+
+	var summary store.StudySession
+	if err := c.Bind(&summary); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+	if err := fonts.normalizeFonts(&summary.FontLeft, &summary.FontRight, &summary.PreferredFontType); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	var session store.StudySession
+	var previous string
+	var done completion
+	err = s.repo.Transaction(func(repo store.Repository) error {
+		var err error
+		if session, previous, err = s.advanceSession(repo, uint(sessionID), store.StatusCompleted); err != nil {
+			return err
+		}
+		// A session started in parallel may have completed meanwhile
+		if session.ParticipantID != 0 {
+			completed, err := repo.Sessions().CountCompleted(session.ParticipantID, session.ID)
+			if err != nil {
+				return err
+			}
+			if completed > 0 {
+				return errAlreadyCompleted
+			}
+		}
+		// Only summary fields the client sent are stored; zero values are skipped
+		if err := repo.Sessions().SaveSummary(session.ID, summary); err != nil {
+			return err
+		}
+		done, err = issueCompletion(repo, &session)
+		return err
+	})
+	if errors.Is(err, errAlreadyCompleted) {
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return respondSessionError(c, err)
+	}
+	s.publishStatus(session.ID, previous, session.Status)
+	s.evaluateFinishedSession(session.ID)
+
+	response := map[string]interface{}{
+		"success":         true,
+		"id":              session.ID,
+		"status":          session.Status,
+		"completed_at":    session.CompletedAt,
+		"completion_code": done.Code,
+	}
+	if done.RedirectURL != "" {
+		response["redirect_url"] = done.RedirectURL
+	}
+	return c.JSON(200, response)
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// passageReading is one session's reading of a passage in one panel
+type passageReading struct {
+	SessionID uint
+	Panel     string
+	Font      string
+	ShownAt   time.Time
+	Words     int
+	Fixations []analysis.WordFixation
+}
+
+// readingMetricsRow is the JSON form of one reading's metrics
+type readingMetricsRow struct {
+	SessionID      uint      `json:"session_id"`
+	Panel          string    `json:"panel"`
+	Font           string    `json:"font"`
+	Category       string    `json:"category"`
+	ShownAt        time.Time `json:"shown_at"`
+	Words          int       `json:"words"`
+	ReadingTimeMS  int64     `json:"reading_time_ms"`
+	WordsPerMinute float64   `json:"words_per_minute"`
+	TotalDwellMS   int64     `json:"total_dwell_ms"`
+	FirstPassMS    int64     `json:"first_pass_ms"`
+	Regressions    int       `json:"regressions"`
+	RegressionRate float64   `json:"regression_rate"`
+	RereadingRatio float64   `json:"rereading_ratio"`
+}
+
+// metricsSummary averages reading metrics over a group of readings
+type metricsSummary struct {
+	Readings       int     `json:"readings"`
+	WordsPerMinute float64 `json:"words_per_minute"`
+	ReadingTimeMS  float64 `json:"reading_time_ms"`
+	TotalDwellMS   float64 `json:"total_dwell_ms"`
+	FirstPassMS    float64 `json:"first_pass_ms"`
+	RegressionRate float64 `json:"regression_rate"`
+	RereadingRatio float64 `json:"rereading_ratio"`
+}
+
+func summarizeReadings(rows []readingMetricsRow) metricsSummary {
+	s := metricsSummary{Readings: len(rows)}
+	if len(rows) == 0 {
+		return s
+	}
+	for _, r := range rows {
+		s.WordsPerMinute += r.WordsPerMinute
+		s.ReadingTimeMS += float64(r.ReadingTimeMS)
+		s.TotalDwellMS += float64(r.TotalDwellMS)
+		s.FirstPassMS += float64(r.FirstPassMS)
+		s.RegressionRate += r.RegressionRate
+		s.RereadingRatio += r.RereadingRatio
+	}
+	n := float64(len(rows))
+	s.WordsPerMinute /= n
+	s.ReadingTimeMS /= n
+	s.TotalDwellMS /= n
+	s.FirstPassMS /= n
+	s.RegressionRate /= n
+	s.RereadingRatio /= n
+	return s
+}
+
+// passageReadings collects every reading of a passage with the word fixations
+// from the given detector run, leaving out excluded sessions when exclude is set
+func (s *Server) passageReadings(passageID uint, algorithm string, exclude bool) ([]*passageReading, error) {
+	var words []store.AOI
+	query := s.db.Where("passage_id = ? AND kind = ?", passageID, "word")
+	if err := s.withoutExcluded(query, "session_id", exclude).Find(&words).Error; err != nil {
+		return nil, err
+	}
+
+	type readingKey struct {
+		SessionID uint
+		Panel     string
+		ShownAt   int64
+	}
+	readings := make(map[readingKey]*passageReading)
+	type wordRef struct {
+		Reading *passageReading
+		Index   int
+	}
+	wordsByID := make(map[uint]wordRef, len(words))
+	sessionIDs := make(map[uint]bool)
+	for _, w := range words {
+		key := readingKey{w.SessionID, w.Panel, w.ShownAt.UnixNano()}
+		reading, ok := readings[key]
+		if !ok {
+			reading = &passageReading{SessionID: w.SessionID, Panel: w.Panel, Font: w.Font, ShownAt: w.ShownAt}
+			readings[key] = reading
+		}
+		reading.Words++
+		wordsByID[w.ID] = wordRef{reading, w.Index}
+		sessionIDs[w.SessionID] = true
+	}
+
+	// Layouts uploaded without a font fall back to the legacy left/right session fonts
+	if len(sessionIDs) > 0 {
+		ids := make([]uint, 0, len(sessionIDs))
+		for id := range sessionIDs {
+			ids = append(ids, id)
+		}
+		var sessions []store.StudySession
+		if err := s.db.Select("id, font_left, font_right").Where("id IN ?", ids).Find(&sessions).Error; err != nil {
+			return nil, err
+		}
+		sessionsByID := make(map[uint]store.StudySession, len(sessions))
+		for _, session := range sessions {
+			sessionsByID[session.ID] = session
+		}
+		for _, reading := range readings {
+			if reading.Font != "" {
+				continue
+			}
+			switch reading.Panel {
+			case "left":
+				reading.Font = sessionsByID[reading.SessionID].FontLeft
+			case "right":
+				reading.Font = sessionsByID[reading.SessionID].FontRight
+			}
+		}
+	}
+
+	var fixations []store.Fixation
+	wordIDs := s.db.Model(&store.AOI{}).Select("id").Where("passage_id = ? AND kind = ?", passageID, "word")
+	runIDs := s.db.Model(&store.FixationRun{}).Select("id").Where("algorithm = ?", algorithm)
+	if err := s.db.Where("word_aoi_id IN (?) AND run_id IN (?)", wordIDs, runIDs).
+		Order("start_time ASC, id ASC").Find(&fixations).Error; err != nil {
+		return nil, err
+	}
+	for _, f := range fixations {
+		ref, ok := wordsByID[*f.WordAOIID]
+		if !ok {
+			continue
+		}
+		ref.Reading.Fixations = append(ref.Reading.Fixations, analysis.WordFixation{
+			WordIndex: ref.Index,
+			Start:     f.StartTime,
+			Duration:  time.Duration(f.DurationMS) * time.Millisecond,
+		})
+	}
+
+	result := make([]*passageReading, 0, len(readings))
+	for _, reading := range readings {
+		result = append(result, reading)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SessionID != result[j].SessionID {
+			return result[i].SessionID < result[j].SessionID
+		}
+		return result[i].ShownAt.Before(result[j].ShownAt)
+	})
+	return result, nil
+}
+
+// readingTimes looks up "complete" reading events for each reading. An event
+// counts when it is for the same panel and arrives before the session shows
+// its next layout in that panel.
+func (s *Server) readingTimes(readings []*passageReading) (map[*passageReading]time.Duration, error) {
+	times := make(map[*passageReading]time.Duration)
+	if len(readings) == 0 {
+		return times, nil
+	}
+
+	sessionIDs := make([]uint, 0, len(readings))
+	for _, r := range readings {
+		sessionIDs = append(sessionIDs, r.SessionID)
+	}
+
+	var events []store.ReadingEvent
+	if err := s.db.Where("session_id IN ? AND event_type = ? AND duration > 0", sessionIDs, "complete").
+		Order("timestamp ASC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return times, nil
+	}
+
+	// Start times of every layout shown per session and panel, across all passages
+	var layouts []struct {
+		SessionID uint
+		Panel     string
+		ShownAt   time.Time
+	}
+	if err := s.db.Model(&store.AOI{}).Distinct("session_id", "panel", "shown_at").
+		Where("session_id IN ?", sessionIDs).Order("shown_at ASC").Scan(&layouts).Error; err != nil {
+		return nil, err
+	}
+
+	for _, r := range readings {
+		var end time.Time
+		for _, l := range layouts {
+			if l.SessionID == r.SessionID && l.Panel == r.Panel && l.ShownAt.After(r.ShownAt) {
+				end = l.ShownAt
+				break
+			}
+		}
+		for _, e := range events {
+			if e.SessionID != r.SessionID || e.Panel != r.Panel || e.Timestamp.Before(r.ShownAt) {
+				continue
+			}
+			if !end.IsZero() && !e.Timestamp.Before(end) {
+				continue
+			}
+			times[r] = time.Duration(e.Duration) * time.Millisecond
+			break
+		}
+	}
+	return times, nil
+}
+
+// handleAdminPassageMetrics reports words per minute, dwell time, first-pass
+// reading time, regression rate and re-reading ratio for a passage, broken
+// down by font and font category
+func (s *Server) handleAdminPassageMetrics(c echo.Context) error {
+	passageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+	}
+
+	var passage store.Passage
+	if err := s.db.First(&passage, passageID).Error; err != nil {
+		return c.JSON(404, map[string]string{"error": "Passage not found"})
+	}
+
+	algorithm := c.QueryParam("algorithm")
+	if algorithm == "" {
+		algorithm = "ivt"
+	}
+	if algorithm != "ivt" && algorithm != "idt" {
+		return c.JSON(400, map[string]string{"error": "algorithm must be ivt or idt"})
+	}
+	exclude, err := s.parseExcludeParam(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	readings, err := s.passageReadings(passage.ID, algorithm, exclude)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load readings: " + err.Error()})
+	}
+	times, err := s.readingTimes(readings)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load reading events: " + err.Error()})
+	}
+
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+
+	// Readings without any word fixations (no gaze data, or AOIs not assigned yet) are skipped
+	rows := []readingMetricsRow{}
+	skipped := 0
+	byFont := make(map[string][]readingMetricsRow)
+	byCategory := make(map[string][]readingMetricsRow)
+	for _, r := range readings {
+		if len(r.Fixations) == 0 {
+			skipped++
+			continue
+		}
+		m := analysis.ComputeReadingMetrics(r.Fixations, r.Words, times[r])
+		font := r.Font
+		if f, ok := fonts.lookup(font); ok {
+			font = f.Name
+		} else if font == "" {
+			font = "unknown"
+		}
+		row := readingMetricsRow{
+			SessionID:      r.SessionID,
+			Panel:          r.Panel,
+			Font:           font,
+			Category:       fonts.category(font),
+			ShownAt:        r.ShownAt,
+			Words:          m.Words,
+			ReadingTimeMS:  m.ReadingTime.Milliseconds(),
+			WordsPerMinute: m.WordsPerMinute,
+			TotalDwellMS:   m.TotalDwell.Milliseconds(),
+			FirstPassMS:    m.FirstPass.Milliseconds(),
+			Regressions:    m.Regressions,
+			RegressionRate: m.RegressionRate,
+			RereadingRatio: m.RereadingRatio,
+		}
+		rows = append(rows, row)
+		byFont[row.Font] = append(byFont[row.Font], row)
+		byCategory[row.Category] = append(byCategory[row.Category], row)
+	}
+
+	fontSummaries := make(map[string]metricsSummary, len(byFont))
+	for font, group := range byFont {
+		fontSummaries[font] = summarizeReadings(group)
+	}
+	categorySummaries := make(map[string]metricsSummary, len(byCategory))
+	for category, group := range byCategory {
+		categorySummaries[category] = summarizeReadings(group)
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success":    true,
+		"passage_id": passage.ID,
+		"algorithm":  algorithm,
+		"excluded":   exclude,
+		"data": map[string]interface{}{
+			"overall":          summarizeReadings(rows),
+			"by_font":          fontSummaries,
+			"by_category":      categorySummaries,
+			"readings":         rows,
+			"skipped_readings": skipped,
+		},
+	})
+}
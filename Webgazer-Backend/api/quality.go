@@ -0,0 +1,412 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/config"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type qualityCheckJSON struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"` // "pass", "fail" or "skipped"
+	Value  float64 `json:"value"`
+	Limit  float64 `json:"limit"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// sessionQualityJSON is a SessionQuality with its JSON columns decoded
+type sessionQualityJSON struct {
+	store.SessionQuality
+	Reasons    []string                 `json:"reasons"`
+	Checks     []qualityCheckJSON       `json:"checks"`
+	Thresholds config.QualityThresholds `json:"thresholds"`
+}
+
+func newSessionQualityJSON(q store.SessionQuality) sessionQualityJSON {
+	out := sessionQualityJSON{SessionQuality: q, Reasons: []string{}, Checks: []qualityCheckJSON{}}
+	// Columns are only ever written by evaluateSessionQuality, so decoding errors leave the defaults
+	json.Unmarshal([]byte(q.Reasons), &out.Reasons)
+	json.Unmarshal([]byte(q.Checks), &out.Checks)
+	json.Unmarshal([]byte(q.Thresholds), &out.Thresholds)
+	return out
+}
+
+// currentQualityThresholds returns the thresholds of the most recent
+// evaluation, so sessions scored later are held to the same limits
+func (s *Server) currentQualityThresholds() analysis.QualityThresholds {
+	var latest store.SessionQuality
+	if result := s.db.Order("evaluated_at DESC, id DESC").Limit(1).Find(&latest); result.Error == nil && result.RowsAffected > 0 {
+		var stored config.QualityThresholds
+		if err := json.Unmarshal([]byte(latest.Thresholds), &stored); err == nil {
+			return stored.Thresholds()
+		}
+	}
+	return s.config.Quality.Thresholds()
+}
+
+// sessionQualityData gathers the calibration, gaze, reading time and quiz data
+// the quality checks need for one session
+func (s *Server) sessionQualityData(session store.StudySession) (analysis.SessionQualityData, error) {
+	data := analysis.SessionQualityData{
+		ScreenWidth:  float64(session.ScreenWidth),
+		ScreenHeight: float64(session.ScreenHeight),
+	}
+
+	// Sessions without any measurement are common, so Find is used to avoid logging a "record not found" error
+	var accuracy store.AccuracyMeasurement
+	result := s.db.Where("session_id = ?", session.ID).Order("timestamp DESC, id DESC").Limit(1).Find(&accuracy)
+	if result.Error != nil {
+		return data, result.Error
+	}
+	if result.RowsAffected > 0 {
+		data.CalibrationAccuracy = &accuracy.Accuracy
+		data.CalibrationPassed = accuracy.Passed
+	}
+
+	var err error
+	if data.Samples, err = s.sessionGazeSamples(session.ID); err != nil {
+		return data, err
+	}
+
+	// Reading times come from the session itself, each pairwise comparison and
+	// any "complete" reading events
+	addTime := func(ms int) {
+		if ms > 0 {
+			data.ReadingTimes = append(data.ReadingTimes, time.Duration(ms)*time.Millisecond)
+		}
+	}
+	for _, ms := range []int{session.TimeLeftMS, session.TimeRightMS, session.TimeAMS, session.TimeBMS} {
+		addTime(ms)
+	}
+	var comparisons []store.FontComparison
+	if err := s.db.Where("session_id = ?", session.ID).Find(&comparisons).Error; err != nil {
+		return data, err
+	}
+	for _, comparison := range comparisons {
+		addTime(comparison.TimeAMS)
+		addTime(comparison.TimeBMS)
+	}
+	var events []store.ReadingEvent
+	if err := s.db.Select("duration").Where("session_id = ? AND event_type = ?", session.ID, "complete").Find(&events).Error; err != nil {
+		return data, err
+	}
+	for _, event := range events {
+		addTime(event.Duration)
+	}
+
+	// Chance level is the mean of 1/choices over the graded answers whose question can be found
+	var responses []store.QuizResponse
+	if err := s.db.Where("session_id = ? AND is_correct IS NOT NULL", session.ID).Find(&responses).Error; err != nil {
+		return data, err
+	}
+	if len(responses) == 0 {
+		return data, nil
+	}
+	var chanceSum float64
+	for _, response := range responses {
+		question, err := answeredRevision(s.repo, session, &response)
+		if err != nil {
+			continue
+		}
+		var choices []string
+		if err := json.Unmarshal([]byte(question.Choices), &choices); err != nil || len(choices) == 0 {
+			continue
+		}
+		chanceSum += 1 / float64(len(choices))
+		data.QuizTotal++
+		if *response.IsCorrect {
+			data.QuizCorrect++
+		}
+	}
+	if data.QuizTotal > 0 {
+		data.QuizChance = chanceSum / float64(data.QuizTotal)
+	}
+	return data, nil
+}
+
+// evaluateSessionQuality scores a session and stores the decision, keeping any
+// manual override an admin has made
+func (s *Server) evaluateSessionQuality(session store.StudySession, thresholds analysis.QualityThresholds) (store.SessionQuality, error) {
+	data, err := s.sessionQualityData(session)
+	if err != nil {
+		return store.SessionQuality{}, err
+	}
+	report := analysis.EvaluateQuality(data, thresholds)
+
+	checks := make([]qualityCheckJSON, len(report.Checks))
+	for i, check := range report.Checks {
+		checks[i] = qualityCheckJSON{Name: check.Name, Status: check.Status, Value: check.Value, Limit: check.Limit, Reason: check.Reason}
+	}
+	reasons := report.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+	reasonsJSON, err := json.Marshal(reasons)
+	if err != nil {
+		return store.SessionQuality{}, err
+	}
+	checksJSON, err := json.Marshal(checks)
+	if err != nil {
+		return store.SessionQuality{}, err
+	}
+	thresholdsJSON, err := json.Marshal(config.NewQualityThresholds(thresholds))
+	if err != nil {
+		return store.SessionQuality{}, err
+	}
+
+	var quality store.SessionQuality
+	if err := s.db.Where("session_id = ?", session.ID).Limit(1).Find(&quality).Error; err != nil {
+		return store.SessionQuality{}, err
+	}
+	quality.SessionID = session.ID
+	quality.Score = report.Score
+	quality.AutoIncluded = report.Included
+	quality.Included = report.Included
+	if quality.Override != nil {
+		quality.Included = *quality.Override
+	}
+	quality.Reasons = string(reasonsJSON)
+	quality.Checks = string(checksJSON)
+	quality.Thresholds = string(thresholdsJSON)
+	quality.EvaluatedAt = time.Now()
+	if err := s.db.Save(&quality).Error; err != nil {
+		return store.SessionQuality{}, err
+	}
+	return quality, nil
+}
+
+// evaluateFinishedSession scores a session once it has been completed or
+// abandoned. The session is already finished, so a failure is only logged;
+// the session can be scored again with POST /api/admin/session/:id/quality.
+func (s *Server) evaluateFinishedSession(sessionID uint) {
+	var session store.StudySession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		log.Printf("Quality evaluation of session %d failed: %v", sessionID, err)
+		return
+	}
+	if _, err := s.evaluateSessionQuality(session, s.currentQualityThresholds()); err != nil {
+		log.Printf("Quality evaluation of session %d failed: %v", sessionID, err)
+	}
+}
+
+// EvaluateSessions scores every completed or abandoned session; sessions still
+// in progress are left without a decision
+func (s *Server) EvaluateSessions(thresholds analysis.QualityThresholds) (int, error) {
+	var sessions []store.StudySession
+	if err := s.db.Where("status IN ?", finalStatuses).Order("id ASC").Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		if _, err := s.evaluateSessionQuality(session, thresholds); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+// excludedSessionIDs selects the IDs of sessions currently excluded from analyses
+func (s *Server) excludedSessionIDs() *gorm.DB {
+	return s.db.Model(&store.SessionQuality{}).Select("session_id").Where("included = ?", false)
+}
+
+// withoutExcluded drops excluded sessions from query, matching them on column, when exclude is set
+func (s *Server) withoutExcluded(query *gorm.DB, column string, exclude bool) *gorm.DB {
+	if !exclude {
+		return query
+	}
+	return query.Where(column+" NOT IN (?)", s.excludedSessionIDs())
+}
+
+// parseExcludeParam reads the ?exclude query parameter used by statistics and
+// exports. Sessions are scored when they finish, so sessions without a
+// decision are still in progress and are not left out.
+func (s *Server) parseExcludeParam(c echo.Context) (bool, error) {
+	value := c.QueryParam("exclude")
+	if value == "" {
+		return false, nil
+	}
+	exclude, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("exclude must be true or false")
+	}
+	return exclude, nil
+}
+
+// handleAdminQuality lists quality decisions (GET) or re-evaluates every
+// session (POST) for /api/admin/quality
+func (s *Server) handleAdminQuality(c echo.Context) error {
+	switch c.Request().Method {
+	case "POST":
+		// Thresholds not given in the body keep their configured defaults
+		params := s.config.Quality
+		if err := c.Bind(&params); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if err := params.Validate(); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+
+		evaluated, err := s.EvaluateSessions(params.Thresholds())
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to evaluate sessions: " + err.Error()})
+		}
+
+		var excluded int64
+		s.db.Model(&store.SessionQuality{}).Where("included = ?", false).Count(&excluded)
+		s.logAudit(c, auditEvaluate, entitySessionQuality, 0, nil, map[string]interface{}{
+			"evaluated":  evaluated,
+			"excluded":   excluded,
+			"thresholds": params,
+		})
+		return c.JSON(200, map[string]interface{}{
+			"success":    true,
+			"evaluated":  evaluated,
+			"excluded":   excluded,
+			"thresholds": params,
+		})
+
+	case "GET":
+		query := s.db.Order("session_id ASC")
+		if value := c.QueryParam("included"); value != "" {
+			included, err := strconv.ParseBool(value)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "included must be true or false"})
+			}
+			query = query.Where("included = ?", included)
+		}
+
+		var qualities []store.SessionQuality
+		if err := query.Find(&qualities).Error; err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to fetch quality decisions: " + err.Error()})
+		}
+
+		var sessions, finished, evaluated, included int64
+		s.db.Model(&store.StudySession{}).Count(&sessions)
+		s.db.Model(&store.StudySession{}).Where("status IN ?", finalStatuses).Count(&finished)
+		s.db.Model(&store.SessionQuality{}).Count(&evaluated)
+		s.db.Model(&store.SessionQuality{}).Where("included = ?", true).Count(&included)
+
+		// Count how often each check fails among the listed sessions
+		failures := make(map[string]int)
+		data := make([]sessionQualityJSON, len(qualities))
+		for i, q := range qualities {
+			data[i] = newSessionQualityJSON(q)
+			for _, check := range data[i].Checks {
+				if check.Status == analysis.QualityFail {
+					failures[check.Name]++
+				}
+			}
+		}
+
+		return c.JSON(200, map[string]interface{}{
+			"success": true,
+			"summary": map[string]interface{}{
+				"sessions":      sessions,
+				"in_progress":   sessions - finished,
+				"evaluated":     evaluated,
+				"unevaluated":   finished - evaluated,
+				"included":      included,
+				"excluded":      evaluated - included,
+				"failed_checks": failures,
+			},
+			"data": data,
+		})
+	}
+
+	return c.JSON(405, map[string]string{"error": "Method not allowed"})
+}
+
+// handleAdminSessionQuality returns (GET), re-evaluates (POST) or manually
+// overrides (PUT) the quality decision for /api/admin/session/:id/quality
+func (s *Server) handleAdminSessionQuality(c echo.Context) error {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid session ID"})
+	}
+
+	var session store.StudySession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		return c.JSON(404, map[string]string{"error": "Session not found"})
+	}
+
+	var quality store.SessionQuality
+	result := s.db.Where("session_id = ?", session.ID).Limit(1).Find(&quality)
+	if result.Error != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch quality decision: " + result.Error.Error()})
+	}
+	evaluated := result.RowsAffected > 0
+
+	// Sessions are only scored once they are finished, on data that won't change
+	if c.Request().Method != "GET" && !isFinalStatus(session.Status) {
+		return c.JSON(409, map[string]string{"error": "Session is still in progress", "status": session.Status})
+	}
+
+	switch c.Request().Method {
+	case "GET":
+		if !evaluated {
+			return c.JSON(404, map[string]string{"error": "Session has not been evaluated", "status": session.Status})
+		}
+
+	case "POST":
+		before := quality
+		if quality, err = s.evaluateSessionQuality(session, s.currentQualityThresholds()); err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to evaluate session: " + err.Error()})
+		}
+		if evaluated {
+			s.logAudit(c, auditEvaluate, entitySessionQuality, session.ID, newSessionQualityJSON(before), newSessionQualityJSON(quality))
+		} else {
+			s.logAudit(c, auditEvaluate, entitySessionQuality, session.ID, nil, newSessionQualityJSON(quality))
+		}
+
+	case "PUT":
+		// included: true/false overrides the automatic decision, null clears the override
+		var params struct {
+			Included *bool  `json:"included"`
+			Note     string `json:"note"`
+		}
+		if err := c.Bind(&params); err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+		}
+		if !evaluated {
+			if quality, err = s.evaluateSessionQuality(session, s.currentQualityThresholds()); err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to evaluate session: " + err.Error()})
+			}
+		}
+		before := newSessionQualityJSON(quality)
+
+		quality.Override = params.Included
+		quality.OverrideNote = params.Note
+		quality.Included = quality.AutoIncluded
+		if params.Included != nil {
+			quality.Included = *params.Included
+		} else {
+			quality.OverrideNote = ""
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&quality).Error; err != nil {
+				return err
+			}
+			return recordAudit(s.store.Repository(tx), c, auditUpdate, entitySessionQuality, session.ID, before, newSessionQualityJSON(quality))
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to save override: " + err.Error()})
+		}
+
+	default:
+		return c.JSON(405, map[string]string{"error": "Method not allowed"})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    newSessionQualityJSON(quality),
+	})
+}
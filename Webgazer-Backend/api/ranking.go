@@ -0,0 +1,307 @@
+package api
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// fontRankingEntry is one row of the font leaderboard
+type fontRankingEntry struct {
+	Rank        int        `json:"rank"`
+	Font        string     `json:"font"`
+	DisplayName string     `json:"display_name"`
+	Category    string     `json:"category"`
+	Score       float64    `json:"score"` // Bradley–Terry log-strength, centered on 0
+	StdError    float64    `json:"std_error"`
+	CI95        [2]float64 `json:"ci95"`
+	Strength    float64    `json:"strength"` // Share of the pool's total strength
+	Wins        int        `json:"wins"`
+	Losses      int        `json:"losses"`
+}
+
+// fontRankingSnapshot is the latest fitted leaderboard
+type fontRankingSnapshot struct {
+	Ranking     []fontRankingEntry `json:"ranking"`
+	Comparisons int                `json:"comparisons"`
+	Iterations  int                `json:"iterations"`
+	Converged   bool               `json:"converged"`
+	Prior       float64            `json:"prior"`
+	FittedAt    time.Time          `json:"fitted_at"`
+}
+
+// fontRanker caches the latest Bradley–Terry fit and refits it, warm-starting
+// from the previous scores, once the stored comparisons change. Changes are
+// noticed from the database, so comparisons stored or deleted by another
+// server instance on the same database count too.
+type fontRanker struct {
+	db       *gorm.DB
+	fonts    store.FontRepository
+	mu       sync.Mutex
+	fitted   comparisonsVersion // of the comparisons behind snapshot
+	scores   map[string]float64 // last fitted scores, used as the warm start
+	snapshot fontRankingSnapshot
+}
+
+// comparisonsVersion summarizes the stored comparisons cheaply: the number of
+// rows and the highest ID of both kinds. Adding or deleting any changes it.
+type comparisonsVersion struct {
+	Comparisons    int64
+	LastComparison int64
+	Legacy         int64
+	LastLegacy     int64
+}
+
+func (r *fontRanker) version() (comparisonsVersion, error) {
+	var v comparisonsVersion
+	err := r.db.Model(&store.FontComparison{}).Select("COUNT(*), COALESCE(MAX(id), 0)").
+		Row().Scan(&v.Comparisons, &v.LastComparison)
+	if err != nil {
+		return v, err
+	}
+	err = r.db.Model(&store.StudySession{}).Where("font_preference IN ?", []string{"A", "B"}).
+		Select("COUNT(*), COALESCE(MAX(id), 0)").Row().Scan(&v.Legacy, &v.LastLegacy)
+	return v, err
+}
+
+// countWins reads every recorded comparison as win counts, leaving out the
+// sessions selected by excluded if it is set. Sessions from before pairwise
+// comparisons were stored count once, using font_preference "A"/"B" to pick
+// between font_left and font_right, when both are concrete fonts.
+func (r *fontRanker) countWins(fonts fontCatalog, excluded *gorm.DB) (map[[2]string]int, int, error) {
+	type comparisonCount struct {
+		FontA     string
+		FontB     string
+		Preferred string
+		Count     int
+	}
+	comparisonQuery := r.db.Model(&store.FontComparison{})
+	legacyQuery := r.db.Select("id, font_left, font_right, font_preference").
+		Where("font_preference IN ?", []string{"A", "B"}).
+		Where("id NOT IN (?)", r.db.Model(&store.FontComparison{}).Select("session_id"))
+	if excluded != nil {
+		comparisonQuery = comparisonQuery.Where("session_id NOT IN (?)", excluded)
+		legacyQuery = legacyQuery.Where("id NOT IN (?)", excluded)
+	}
+
+	var rows []comparisonCount
+	if err := comparisonQuery.Select("font_a, font_b, preferred, COUNT(*) AS count").
+		Group("font_a, font_b, preferred").Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	var legacy []store.StudySession
+	if err := legacyQuery.Find(&legacy).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, s := range legacy {
+		a, okA := fonts.lookup(s.FontLeft)
+		b, okB := fonts.lookup(s.FontRight)
+		if !okA || !okB || a.Name == b.Name {
+			continue
+		}
+		preferred := a.Name
+		if s.FontPreference == "B" {
+			preferred = b.Name
+		}
+		rows = append(rows, comparisonCount{a.Name, b.Name, preferred, 1})
+	}
+
+	wins := make(map[[2]string]int)
+	comparisons := 0
+	for _, row := range rows {
+		loser := row.FontB
+		if row.Preferred == row.FontB {
+			loser = row.FontA
+		}
+		wins[[2]string{row.Preferred, loser}] += row.Count
+		comparisons += row.Count
+	}
+	return wins, comparisons, nil
+}
+
+// current returns the leaderboard, refitting only if the comparisons changed.
+// With excluded set, the sessions it selects are left out; that leaderboard
+// is fitted every time and not cached.
+func (r *fontRanker) current(excluded *gorm.DB) (fontRankingSnapshot, error) {
+	fonts, err := loadFontCatalog(r.fonts)
+	if err != nil {
+		return fontRankingSnapshot{}, err
+	}
+	var pool []store.Font
+	if err := r.db.Order("name ASC").Find(&pool).Error; err != nil {
+		return fontRankingSnapshot{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if excluded != nil {
+		wins, comparisons, err := r.countWins(fonts, excluded)
+		if err != nil {
+			return fontRankingSnapshot{}, err
+		}
+		return r.fit(pool, wins, comparisons), nil
+	}
+
+	version, err := r.version()
+	if err != nil {
+		return fontRankingSnapshot{}, err
+	}
+	if version == r.fitted && r.snapshot.Ranking != nil && len(r.snapshot.Ranking) == len(pool) {
+		return r.snapshot, nil
+	}
+	wins, comparisons, err := r.countWins(fonts, nil)
+	if err != nil {
+		return fontRankingSnapshot{}, err
+	}
+	r.snapshot = r.fit(pool, wins, comparisons)
+	r.fitted = version
+	return r.snapshot, nil
+}
+
+// fit ranks the fonts in pool by their win counts, warm-starting from the
+// previous fit's scores. The caller must hold r.mu.
+func (r *fontRanker) fit(pool []store.Font, counts map[[2]string]int, comparisons int) fontRankingSnapshot {
+	index := make(map[string]int, len(pool))
+	for i, f := range pool {
+		index[f.Name] = i
+	}
+	wins := make([][]float64, len(pool))
+	for i := range wins {
+		wins[i] = make([]float64, len(pool))
+	}
+	for pair, count := range counts {
+		i, okI := index[pair[0]]
+		j, okJ := index[pair[1]]
+		if okI && okJ {
+			wins[i][j] += float64(count)
+		}
+	}
+
+	var start []float64
+	if len(r.scores) > 0 {
+		start = make([]float64, len(pool))
+		for i, f := range pool {
+			start[i] = r.scores[f.Name]
+		}
+	}
+
+	cfg := analysis.DefaultBradleyTerryConfig()
+	fit := analysis.FitBradleyTerry(wins, start, cfg)
+
+	var strengthSum float64
+	for _, score := range fit.Scores {
+		strengthSum += math.Exp(score)
+	}
+	r.scores = make(map[string]float64, len(pool))
+	ranking := make([]fontRankingEntry, len(pool))
+	for i, f := range pool {
+		r.scores[f.Name] = fit.Scores[i]
+		entry := fontRankingEntry{
+			Font:        f.Name,
+			DisplayName: f.DisplayName,
+			Category:    f.Category,
+			Score:       fit.Scores[i],
+			StdError:    fit.StdErrors[i],
+			CI95:        [2]float64{fit.Scores[i] - 1.96*fit.StdErrors[i], fit.Scores[i] + 1.96*fit.StdErrors[i]},
+			Strength:    math.Exp(fit.Scores[i]) / strengthSum,
+		}
+		for j := range pool {
+			entry.Wins += int(wins[i][j])
+			entry.Losses += int(wins[j][i])
+		}
+		ranking[i] = entry
+	}
+	sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Score > ranking[j].Score })
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+	}
+
+	return fontRankingSnapshot{
+		Ranking:     ranking,
+		Comparisons: comparisons,
+		Iterations:  fit.Iterations,
+		Converged:   fit.Converged,
+		Prior:       cfg.Prior,
+		FittedAt:    time.Now(),
+	}
+}
+
+// handleFontComparison records one pairwise font preference
+func (s *Server) handleFontComparison(c echo.Context) error {
+	var comparison store.FontComparison
+	if err := c.Bind(&comparison); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+
+	if comparison.SessionID == 0 || comparison.FontA == "" || comparison.FontB == "" || comparison.Preferred == "" {
+		return c.JSON(400, map[string]string{"error": "session_id, font_a, font_b and preferred are required"})
+	}
+
+	// Only concrete fonts can be ranked
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+	for _, field := range []*string{&comparison.FontA, &comparison.FontB, &comparison.Preferred} {
+		f, ok := fonts.lookup(*field)
+		if !ok {
+			return c.JSON(400, map[string]string{"error": "unknown font: " + *field})
+		}
+		*field = f.Name
+	}
+	if comparison.FontA == comparison.FontB {
+		return c.JSON(400, map[string]string{"error": "font_a and font_b must differ"})
+	}
+	if comparison.Preferred != comparison.FontA && comparison.Preferred != comparison.FontB {
+		return c.JSON(400, map[string]string{"error": "preferred must be font_a or font_b"})
+	}
+
+	if comparison.Timestamp.IsZero() {
+		comparison.Timestamp = time.Now()
+	}
+
+	err = s.storeSessionData(comparison.SessionID, store.StatusReading, func(repo store.Repository, _ store.StudySession) error {
+		return repo.SessionData().AddFontComparison(&comparison)
+	})
+	if isSessionError(err) {
+		return respondSessionError(c, err)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to save font comparison: " + err.Error()})
+	}
+
+	return c.JSON(201, map[string]interface{}{
+		"success": true,
+		"id":      comparison.ID,
+	})
+}
+
+// handleAdminFontRanking returns the Bradley–Terry font leaderboard
+func (s *Server) handleAdminFontRanking(c echo.Context) error {
+	exclude, err := s.parseExcludeParam(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	var excluded *gorm.DB
+	if exclude {
+		excluded = s.excludedSessionIDs()
+	}
+
+	snapshot, err := s.ranking.current(excluded)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to rank fonts: " + err.Error()})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success":  true,
+		"excluded": exclude,
+		"data":     snapshot,
+	})
+}
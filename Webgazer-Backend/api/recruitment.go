@@ -0,0 +1,287 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Recruitment platforms with their own participant IDs
+const (
+	sourceProlific = "prolific"
+	sourceMTurk    = "mturk"
+)
+
+// mturkPreviewAssignment is the assignmentId MTurk sends while a worker only previews a HIT
+const mturkPreviewAssignment = "ASSIGNMENT_ID_NOT_AVAILABLE"
+
+// completionCodeAlphabet leaves out characters that are easily confused (0/O, 1/I/L)
+const completionCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+const completionCodeLength = 8
+
+// errAlreadyCompleted is returned when a participant who already completed
+// the study starts or completes another session
+var errAlreadyCompleted = errors.New("participant has already completed the study")
+
+// participantRequest is the body of POST /api/participant. Besides the generic
+// external_* fields it accepts the URL parameters Prolific and MTurk append to
+// the study link, so the frontend can forward them unchanged.
+type participantRequest struct {
+	Source            string `json:"source"`
+	ExternalID        string `json:"external_id"`
+	ExternalStudyID   string `json:"external_study_id"`
+	ExternalSessionID string `json:"external_session_id"`
+
+	ProlificPID       string `json:"PROLIFIC_PID"`
+	ProlificStudyID   string `json:"STUDY_ID"`
+	ProlificSessionID string `json:"SESSION_ID"`
+
+	WorkerID     string `json:"workerId"`
+	AssignmentID string `json:"assignmentId"`
+	HITID        string `json:"hitId"`
+}
+
+// participant maps the platform-specific fields onto a Participant. The source
+// is inferred from the platform fields, or else defaultSource, when it isn't given.
+func (r participantRequest) participant(defaultSource string) (store.Participant, error) {
+	p := store.Participant{
+		Source:            strings.ToLower(strings.TrimSpace(r.Source)),
+		ExternalStudyID:   strings.TrimSpace(r.ExternalStudyID),
+		ExternalSessionID: strings.TrimSpace(r.ExternalSessionID),
+	}
+	externalID := strings.TrimSpace(r.ExternalID)
+
+	switch {
+	case r.ProlificPID != "":
+		if p.Source != "" && p.Source != sourceProlific {
+			return p, errors.New("PROLIFIC_PID given for source " + p.Source)
+		}
+		p.Source = sourceProlific
+		externalID = strings.TrimSpace(r.ProlificPID)
+		p.ExternalStudyID = strings.TrimSpace(r.ProlificStudyID)
+		p.ExternalSessionID = strings.TrimSpace(r.ProlificSessionID)
+	case r.WorkerID != "":
+		if p.Source != "" && p.Source != sourceMTurk {
+			return p, errors.New("workerId given for source " + p.Source)
+		}
+		p.Source = sourceMTurk
+		externalID = strings.TrimSpace(r.WorkerID)
+		p.ExternalStudyID = strings.TrimSpace(r.HITID)
+		p.ExternalSessionID = strings.TrimSpace(r.AssignmentID)
+	case r.AssignmentID == mturkPreviewAssignment:
+		return p, errors.New("HIT is being previewed; accept it to take part")
+	}
+
+	if p.Source == "" {
+		p.Source = defaultSource
+	}
+	if (p.Source == sourceProlific || p.Source == sourceMTurk) && externalID == "" {
+		return p, errors.New("an external participant ID is required for " + p.Source)
+	}
+	if externalID != "" {
+		p.ExternalID = &externalID
+	}
+	return p, nil
+}
+
+// findExternalParticipant looks up a participant by recruitment platform ID
+func (s *Server) findExternalParticipant(source, externalID string) (store.Participant, bool, error) {
+	participant, err := s.repo.Participants().FindExternal(source, externalID)
+	if errors.Is(err, store.ErrNotFound) {
+		return participant, false, nil
+	}
+	return participant, err == nil, err
+}
+
+// respondReturningParticipant answers a repeat visit: the platform's study and
+// session IDs are updated to the latest visit, and a participant who already
+// completed the study gets their completion again instead of a new session.
+func (s *Server) respondReturningParticipant(c echo.Context, existing, visit store.Participant) error {
+	if err := s.repo.Participants().UpdateVisit(&existing, visit.ExternalStudyID, visit.ExternalSessionID); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update participant: " + err.Error()})
+	}
+
+	response := map[string]interface{}{
+		"success":           true,
+		"id":                existing.ID,
+		"source":            existing.Source,
+		"returning":         true,
+		"already_completed": false,
+	}
+	completed, err := s.participantCompletion(existing.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to look up sessions: " + err.Error()})
+	}
+	if completed != nil {
+		done, err := sessionCompletion(s.repo, *completed, store.StudyText{})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to load completion: " + err.Error()})
+		}
+		response["already_completed"] = true
+		response["completion_code"] = done.Code
+		if done.RedirectURL != "" {
+			response["redirect_url"] = done.RedirectURL
+		}
+	}
+	return c.JSON(200, response)
+}
+
+// participantCompletion returns the latest completed session of a participant, if any
+func (s *Server) participantCompletion(participantID uint) (*store.StudySession, error) {
+	session, err := s.repo.Sessions().LatestCompleted(participantID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// generateCompletionCode returns a random code for one completed session
+func generateCompletionCode() (string, error) {
+	code := make([]byte, completionCodeLength)
+	max := big.NewInt(int64(len(completionCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = completionCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// completion is what a participant needs to prove they finished the study
+type completion struct {
+	Code        string `json:"completion_code"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+// issueCompletion assigns a completed session its completion code: the study
+// text's fixed code (as Prolific expects) or, without one, a unique random code
+// (as MTurk workers paste back). The code is stored so it can be verified later.
+func issueCompletion(repo store.Repository, session *store.StudySession) (completion, error) {
+	var studyText store.StudyText
+	if session.StudyTextID != nil {
+		var err error
+		if studyText, err = repo.StudyTexts().Get(*session.StudyTextID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return completion{}, err
+		}
+	}
+
+	code := studyText.CompletionCode
+	if code == "" {
+		var err error
+		if code, err = generateCompletionCode(); err != nil {
+			return completion{}, err
+		}
+	}
+	if err := repo.Sessions().SetCompletionCode(session.ID, code); err != nil {
+		return completion{}, err
+	}
+	session.CompletionCode = code
+	return sessionCompletion(repo, *session, studyText)
+}
+
+// sessionCompletion builds the completion of an already completed session
+func sessionCompletion(repo store.Repository, session store.StudySession, studyText store.StudyText) (completion, error) {
+	result := completion{Code: session.CompletionCode}
+	if studyText.ID == 0 && session.StudyTextID != nil {
+		var err error
+		if studyText, err = repo.StudyTexts().Get(*session.StudyTextID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return result, err
+		}
+	}
+	if studyText.CompletionURL == "" {
+		return result, nil
+	}
+
+	participant, err := repo.Participants().Get(session.ParticipantID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return result, err
+	}
+	result.RedirectURL = completionRedirectURL(studyText.CompletionURL, session, participant)
+	return result, nil
+}
+
+// completionRedirectURL fills the placeholders {code}, {participant_id},
+// {external_id}, {external_session_id} and {session_id} of a study text's
+// completion URL, e.g. "https://app.prolific.com/submissions/complete?cc={code}"
+func completionRedirectURL(template string, session store.StudySession, participant store.Participant) string {
+	externalID := ""
+	if participant.ExternalID != nil {
+		externalID = *participant.ExternalID
+	}
+	return strings.NewReplacer(
+		"{code}", url.QueryEscape(session.CompletionCode),
+		"{participant_id}", strconv.FormatUint(uint64(participant.ID), 10),
+		"{external_id}", url.QueryEscape(externalID),
+		"{external_session_id}", url.QueryEscape(participant.ExternalSessionID),
+		"{session_id}", url.QueryEscape(session.SessionID),
+	).Replace(template)
+}
+
+// validateCompletionURL accepts empty or absolute http(s) URLs
+func validateCompletionURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("completion_url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// handleAdminVerifyCompletion checks a completion code a participant submitted
+// on a recruitment platform. ?code is required; ?external_id (PROLIFIC_PID or
+// workerId) ties the check to one participant, which fixed per-study codes need.
+func (s *Server) handleAdminVerifyCompletion(c echo.Context) error {
+	code := strings.ToUpper(strings.TrimSpace(c.QueryParam("code")))
+	if code == "" {
+		return c.JSON(400, map[string]string{"error": "code is required"})
+	}
+
+	sessions, err := s.repo.Sessions().ByCompletionCode(code, strings.TrimSpace(c.QueryParam("external_id")))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to verify completion code: " + err.Error()})
+	}
+
+	type verifiedSession struct {
+		ID                uint       `json:"id"`
+		SessionID         string     `json:"session_id"`
+		ParticipantID     uint       `json:"participant_id"`
+		Source            string     `json:"source"`
+		ExternalID        *string    `json:"external_id,omitempty"`
+		ExternalSessionID string     `json:"external_session_id,omitempty"`
+		CompletedAt       *time.Time `json:"completed_at"`
+	}
+	matches := make([]verifiedSession, len(sessions))
+	for i, session := range sessions {
+		matches[i] = verifiedSession{
+			ID:                session.ID,
+			SessionID:         session.SessionID,
+			ParticipantID:     session.ParticipantID,
+			Source:            session.Participant.Source,
+			ExternalID:        session.Participant.ExternalID,
+			ExternalSessionID: session.Participant.ExternalSessionID,
+			CompletedAt:       session.CompletedAt,
+		}
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success":  true,
+		"valid":    len(matches) > 0,
+		"sessions": matches,
+	})
+}
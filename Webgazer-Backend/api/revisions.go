@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fieldChange is one field that differs between two revisions. Text fields
+// also carry a word diff.
+type fieldChange struct {
+	Field string       `json:"field"`
+	From  interface{}  `json:"from"`
+	To    interface{}  `json:"to"`
+	Words []wordChange `json:"words,omitempty"`
+}
+
+// wordChange is a run of words kept, inserted or deleted between two texts
+type wordChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// diffFields compares two revisions encoded as JSON objects, skipping
+// bookkeeping fields. Fields named in textFields get a word diff.
+func diffFields(from, to interface{}, textFields ...string) ([]fieldChange, error) {
+	var a, b map[string]interface{}
+	for _, pair := range []struct {
+		value  interface{}
+		fields *map[string]interface{}
+	}{{from, &a}, {to, &b}} {
+		encoded, err := json.Marshal(pair.value)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(encoded, pair.fields); err != nil {
+			return nil, err
+		}
+	}
+
+	skip := map[string]bool{"id": true, "revision": true, "created_by": true, "created_at": true}
+	text := make(map[string]bool, len(textFields))
+	for _, field := range textFields {
+		text[field] = true
+	}
+
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		if !skip[key] {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	changes := []fieldChange{}
+	for _, key := range sorted {
+		encodedA, _ := json.Marshal(a[key])
+		encodedB, _ := json.Marshal(b[key])
+		if string(encodedA) == string(encodedB) {
+			continue
+		}
+		change := fieldChange{Field: key, From: a[key], To: b[key]}
+		if text[key] {
+			fromText, _ := a[key].(string)
+			toText, _ := b[key].(string)
+			change.Words = []wordChange{}
+			for _, chunk := range analysis.DiffWords(fromText, toText) {
+				change.Words = append(change.Words, wordChange{Op: chunk.Op, Text: chunk.Text})
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// revisionRange reads ?from and ?to revision numbers for a diff. By default
+// the latest revision is compared with the one before it; a first revision
+// is compared with itself.
+func revisionRange(c echo.Context, latest int) (int, int, error) {
+	to := latest
+	if value := c.QueryParam("to"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, errors.New("to must be a revision number")
+		}
+		to = n
+	}
+	from := to - 1
+	if from < 1 {
+		from = 1
+	}
+	if value := c.QueryParam("from"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, errors.New("from must be a revision number")
+		}
+		from = n
+	}
+	if from < 1 || to < 1 || from > latest || to > latest {
+		return 0, 0, errors.New("revisions must be between 1 and " + strconv.Itoa(latest))
+	}
+	return from, to, nil
+}
+
+// handleAdminPassageRevisions lists the revisions of a passage for
+// /api/admin/passages/:id/revisions. History stays available after the passage is deleted.
+func (s *Server) handleAdminPassageRevisions(c echo.Context) error {
+	passageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+	}
+
+	var revisions []store.PassageRevision
+	if err := s.db.Where("passage_id = ?", passageID).Order("revision ASC").Find(&revisions).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch passage revisions: " + err.Error()})
+	}
+	if len(revisions) == 0 {
+		return c.JSON(404, map[string]string{"error": "Passage not found"})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    revisions,
+	})
+}
+
+// handleAdminPassageRevisionDiff compares two revisions of a passage for
+// /api/admin/passages/:id/revisions/diff?from=&to=
+func (s *Server) handleAdminPassageRevisionDiff(c echo.Context) error {
+	passageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid passage ID"})
+	}
+
+	var revisions []store.PassageRevision
+	if err := s.db.Where("passage_id = ?", passageID).Order("revision ASC").Find(&revisions).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch passage revisions: " + err.Error()})
+	}
+	if len(revisions) == 0 {
+		return c.JSON(404, map[string]string{"error": "Passage not found"})
+	}
+	byNumber := make(map[int]store.PassageRevision, len(revisions))
+	for _, revision := range revisions {
+		byNumber[revision.Revision] = revision
+	}
+
+	from, to, err := revisionRange(c, revisions[len(revisions)-1].Revision)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	changes, err := diffFields(byNumber[from], byNumber[to], "content", "title")
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to compare revisions: " + err.Error()})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"from":    byNumber[from],
+		"to":      byNumber[to],
+		"changes": changes,
+	})
+}
+
+// quizQuestionRevisionJSON is a question revision with its choices decoded
+type quizQuestionRevisionJSON struct {
+	store.QuizQuestionRevision
+	Choices []string `json:"choices"`
+}
+
+func newQuizQuestionRevisionJSON(revision store.QuizQuestionRevision) quizQuestionRevisionJSON {
+	data := quizQuestionRevisionJSON{QuizQuestionRevision: revision}
+	json.Unmarshal([]byte(revision.Choices), &data.Choices)
+	return data
+}
+
+// handleAdminQuizQuestionRevisions lists the revisions of a quiz question for
+// /api/admin/quiz-questions/:id/revisions, with how many responses each received
+func (s *Server) handleAdminQuizQuestionRevisions(c echo.Context) error {
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid quiz question ID"})
+	}
+
+	var revisions []store.QuizQuestionRevision
+	if err := s.db.Where("quiz_question_id = ?", questionID).Order("revision ASC").Find(&revisions).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch quiz question revisions: " + err.Error()})
+	}
+	if len(revisions) == 0 {
+		return c.JSON(404, map[string]string{"error": "Quiz question not found"})
+	}
+
+	ids := make([]uint, len(revisions))
+	for i, revision := range revisions {
+		ids[i] = revision.ID
+	}
+	var counts []struct {
+		QuestionRevisionID uint
+		Count              int64
+	}
+	if err := s.db.Model(&store.QuizResponse{}).Select("question_revision_id, COUNT(*) as count").
+		Where("question_revision_id IN ?", ids).Group("question_revision_id").Scan(&counts).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count responses: " + err.Error()})
+	}
+	answered := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		answered[count.QuestionRevisionID] = count.Count
+	}
+
+	type revisionSummary struct {
+		quizQuestionRevisionJSON
+		Responses int64 `json:"responses"`
+	}
+	data := make([]revisionSummary, len(revisions))
+	for i, revision := range revisions {
+		data[i] = revisionSummary{quizQuestionRevisionJSON: newQuizQuestionRevisionJSON(revision), Responses: answered[revision.ID]}
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// handleAdminQuizQuestionRevisionDiff compares two revisions of a quiz question
+// for /api/admin/quiz-questions/:id/revisions/diff?from=&to=
+func (s *Server) handleAdminQuizQuestionRevisionDiff(c echo.Context) error {
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid quiz question ID"})
+	}
+
+	var revisions []store.QuizQuestionRevision
+	if err := s.db.Where("quiz_question_id = ?", questionID).Order("revision ASC").Find(&revisions).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch quiz question revisions: " + err.Error()})
+	}
+	if len(revisions) == 0 {
+		return c.JSON(404, map[string]string{"error": "Quiz question not found"})
+	}
+	byNumber := make(map[int]quizQuestionRevisionJSON, len(revisions))
+	for _, revision := range revisions {
+		byNumber[revision.Revision] = newQuizQuestionRevisionJSON(revision)
+	}
+
+	from, to, err := revisionRange(c, revisions[len(revisions)-1].Revision)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	changes, err := diffFields(byNumber[from], byNumber[to], "prompt")
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to compare revisions: " + err.Error()})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"from":    byNumber[from],
+		"to":      byNumber[to],
+		"changes": changes,
+	})
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+
+	"readability-backend/config"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
+)
+
+// Server is the HTTP API. Handlers are its methods, so every dependency
+// (database, storage backend, configuration) is passed in through New
+// instead of living in package variables.
+type Server struct {
+	db          *gorm.DB         // analysis, export and maintenance queries
+	repo        store.Repository // participants, sessions and study content
+	store       store.Store
+	config      config.Config
+	tokenSecret []byte      // signs admin session tokens
+	events      *eventBus   // live session events for GET /api/admin/events
+	ranking     *fontRanker // cached font leaderboard
+}
+
+// New returns a server on db, which must be opened by st and migrated
+func New(db *gorm.DB, st store.Store, cfg config.Config) *Server {
+	repo := st.Repository(db)
+	return &Server{
+		db:          db,
+		repo:        repo,
+		store:       st,
+		config:      cfg,
+		tokenSecret: newAdminTokenSecret(cfg.Admin.TokenSecret),
+		events:      &eventBus{},
+		ranking:     &fontRanker{db: db, fonts: repo.Fonts()},
+	}
+}
+
+// Handler returns the router serving every /api route, with CORS for the
+// configured frontend origins
+func (s *Server) Handler() *echo.Echo {
+	// Setup Echo router
+	e := echo.New()
+
+	// Configure CORS middleware
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: s.config.Server.AllowedOrigins,
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowHeaders: []string{"Content-Type", "Authorization"},
+	}))
+
+	// API routes
+	api := e.Group("/api")
+	{
+		api.POST("/participant", s.handleParticipant)
+		api.GET("/consent", s.handleConsent)
+		api.POST("/consent", s.handleConsent)
+		api.GET("/demographics", s.handleDemographics)
+		api.POST("/demographics", s.handleDemographics)
+		api.POST("/session", s.handleSession)
+		api.POST("/session/:id/complete", s.handleSessionComplete)
+		api.POST("/quiz-response", s.handleQuizResponse)
+		api.POST("/calibration", s.handleCalibration)
+		api.POST("/gaze-point", s.handleGazePoint)
+		api.POST("/gaze-points/batch", s.handleGazePointBatch)
+		api.POST("/aois", s.handleAOIUpload)
+		api.POST("/reading-event", s.handleReadingEvent)
+		api.POST("/accuracy", s.handleAccuracy)
+		api.GET("/study-text", s.handleStudyText)
+		api.GET("/quiz-questions", s.handleQuizQuestions)
+		api.GET("/fonts", s.handleFonts)
+		api.POST("/font-comparison", s.handleFontComparison)
+		api.GET("/health", s.handleHealth)
+		api.GET("/settings", s.handleSettings)
+
+		// Admin login is the only admin route that doesn't need a token
+		api.POST("/admin/login", s.handleAdminLogin)
+
+		// The event stream also takes a short-lived token in the URL for EventSource
+		api.GET("/admin/events", s.handleAdminEvents, s.requireEventsToken, requireRole(RoleViewer))
+
+		// Admin routes (viewer: statistics and analysis, editor: full CRUD and raw data export)
+		admin := api.Group("/admin", s.requireAdmin)
+		editor := requireRole(RoleEditor)
+		viewer := requireRole(RoleViewer)
+		{
+			admin.GET("/me", s.handleAdminMe)
+			admin.GET("/config", s.handleAdminConfig, viewer)
+			admin.POST("/study-text", s.handleAdminStudyText, editor)
+			admin.PUT("/study-text", s.handleAdminStudyText, editor)
+			admin.GET("/study-text", s.handleAdminStudyText, editor)
+			admin.POST("/study-texts/:id/clone", s.handleAdminCloneStudyText, editor)
+			admin.POST("/study-texts/:id/publish", s.handleAdminPublishStudyText, editor)
+			admin.GET("/study-texts/:id/preview", s.handleAdminPreviewStudyText, viewer)
+			admin.GET("/study-texts/:id/bundle", s.handleAdminExportBundle, viewer)
+			admin.POST("/study-texts/import", s.handleAdminImportBundle, editor)
+			admin.POST("/passage", s.handleAdminPassage, editor)
+			admin.PUT("/passage", s.handleAdminPassage, editor)
+			admin.DELETE("/passage", s.handleAdminPassage, editor)
+			admin.GET("/passage", s.handleAdminPassage, editor)
+			admin.POST("/quiz-question", s.handleAdminQuizQuestion, editor)
+			admin.PUT("/quiz-question", s.handleAdminQuizQuestion, editor)
+			admin.DELETE("/quiz-question", s.handleAdminQuizQuestion, editor)
+			admin.GET("/quiz-question", s.handleAdminQuizQuestion, editor)
+			admin.GET("/statistics", s.handleAdminStatistics, viewer)
+			admin.GET("/statistics/significance", s.handleAdminSignificance, viewer)
+			admin.GET("/font-ranking", s.handleAdminFontRanking, viewer)
+			admin.GET("/export", s.handleAdminExport, editor)
+			admin.POST("/events/token", s.handleAdminEventsToken, viewer)
+			admin.GET("/completion/verify", s.handleAdminVerifyCompletion, viewer)
+			admin.GET("/consent", s.handleAdminConsent, viewer)
+			admin.POST("/consent", s.handleAdminConsent, editor)
+			admin.PUT("/consent", s.handleAdminConsent, editor)
+			admin.GET("/demographics", s.handleAdminDemographics, viewer)
+			admin.POST("/demographics", s.handleAdminDemographics, editor)
+			admin.PUT("/demographics", s.handleAdminDemographics, editor)
+			admin.GET("/erasure", s.handleAdminErasure, viewer)
+			admin.POST("/erasure", s.handleAdminErasure, editor)
+			admin.GET("/audit", s.handleAdminAudit, viewer)
+			admin.GET("/quality", s.handleAdminQuality, viewer)
+			admin.POST("/quality", s.handleAdminQuality, editor)
+			admin.GET("/session/:id/quality", s.handleAdminSessionQuality, viewer)
+			admin.POST("/session/:id/quality", s.handleAdminSessionQuality, editor)
+			admin.PUT("/session/:id/quality", s.handleAdminSessionQuality, editor)
+			admin.GET("/session/:id/fixations", s.handleAdminSessionFixations, viewer)
+			admin.POST("/session/:id/fixations", s.handleAdminSessionFixations, editor)
+			admin.GET("/session/:id/aois", s.handleAdminSessionAOIs, viewer)
+			admin.POST("/session/:id/aois", s.handleAdminSessionAOIs, editor)
+			admin.GET("/passages/:id/metrics", s.handleAdminPassageMetrics, viewer)
+			admin.GET("/passages/:id/revisions", s.handleAdminPassageRevisions, viewer)
+			admin.GET("/passages/:id/revisions/diff", s.handleAdminPassageRevisionDiff, viewer)
+			admin.GET("/quiz-questions/:id/revisions", s.handleAdminQuizQuestionRevisions, viewer)
+			admin.GET("/quiz-questions/:id/revisions/diff", s.handleAdminQuizQuestionRevisionDiff, viewer)
+		}
+	}
+
+	return e
+}
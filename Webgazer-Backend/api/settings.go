@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// handleAdminConfig shows the configuration the server runs with, without secrets
+func (s *Server) handleAdminConfig(c echo.Context) error {
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"file":    s.config.File,
+		"config":  s.config.Redacted(),
+	})
+}
+
+// handleSettings exposes the study parameters the frontend needs
+func (s *Server) handleSettings(c echo.Context) error {
+	return c.JSON(200, map[string]interface{}{
+		"default_version":     s.config.Study.DefaultVersion,
+		"accuracy_threshold":  s.config.Study.AccuracyThreshold,
+		"max_gaze_batch_size": s.config.Ingestion.MaxGazeBatchSize,
+	})
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"errors"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// testResult wraps a statistical test so that tests without enough data report
+// why instead of failing the whole response
+type testResult struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func newTestResult(result interface{}, err error) testResult {
+	if err != nil {
+		if errors.Is(err, analysis.ErrInsufficientData) {
+			return testResult{Error: err.Error()}
+		}
+		return testResult{Error: "test failed: " + err.Error()}
+	}
+	return testResult{Result: result}
+}
+
+type tTestJSON struct {
+	N              int        `json:"n"`
+	MeanDifference float64    `json:"mean_difference_ms"`
+	SD             float64    `json:"sd_difference_ms"`
+	T              float64    `json:"t"`
+	DF             float64    `json:"df"`
+	PValue         float64    `json:"p_value"`
+	CI95           [2]float64 `json:"ci95_ms"`
+	CohensD        float64    `json:"cohens_d"`
+}
+
+type wilcoxonJSON struct {
+	N          int     `json:"n"`
+	WPlus      float64 `json:"w_plus"`
+	WMinus     float64 `json:"w_minus"`
+	Z          float64 `json:"z"`
+	PValue     float64 `json:"p_value"`
+	Exact      bool    `json:"exact"`
+	EffectSize float64 `json:"effect_size_r"`
+}
+
+// serifSansTimes returns the within-participant reading times of sessions
+// that read one serif and one sans-serif panel, as paired slices
+func (s *Server) serifSansTimes(fonts fontCatalog, exclude bool) (serif, sans []float64, err error) {
+	var sessions []store.StudySession
+	query := s.db.Select("id, font_left, font_right, time_left_ms, time_right_ms").
+		Where("time_left_ms > 0 AND time_right_ms > 0")
+	if err := s.withoutExcluded(query, "id", exclude).Find(&sessions).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, session := range sessions {
+		left, right := fonts.category(session.FontLeft), fonts.category(session.FontRight)
+		switch {
+		case left == "serif" && right == "sans-serif":
+			serif = append(serif, float64(session.TimeLeftMS))
+			sans = append(sans, float64(session.TimeRightMS))
+		case left == "sans-serif" && right == "serif":
+			serif = append(serif, float64(session.TimeRightMS))
+			sans = append(sans, float64(session.TimeLeftMS))
+		}
+	}
+	return serif, sans, nil
+}
+
+// handleAdminSignificance runs inferential tests comparing serif and sans-serif fonts
+func (s *Server) handleAdminSignificance(c echo.Context) error {
+	exclude, err := s.parseExcludeParam(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	fonts, err := loadFontCatalog(s.repo.Fonts())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load fonts: " + err.Error()})
+	}
+
+	// Reading times: paired t-test and Wilcoxon signed-rank on serif - sans differences
+	serif, sans, err := s.serifSansTimes(fonts, exclude)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load reading times: " + err.Error()})
+	}
+
+	var tTest testResult
+	if r, err := analysis.PairedTTest(serif, sans); err != nil {
+		tTest = newTestResult(nil, err)
+	} else {
+		tTest = newTestResult(tTestJSON{
+			N:              r.N,
+			MeanDifference: r.MeanDifference,
+			SD:             r.SD,
+			T:              r.T,
+			DF:             r.DF,
+			PValue:         r.PValue,
+			CI95:           r.CI95,
+			CohensD:        r.CohensD,
+		}, nil)
+	}
+
+	var wilcoxon testResult
+	if r, err := analysis.WilcoxonSignedRank(serif, sans); err != nil {
+		wilcoxon = newTestResult(nil, err)
+	} else {
+		wilcoxon = newTestResult(wilcoxonJSON{
+			N:          r.N,
+			WPlus:      r.WPlus,
+			WMinus:     r.WMinus,
+			Z:          r.Z,
+			PValue:     r.PValue,
+			Exact:      r.Exact,
+			EffectSize: r.EffectSize,
+		}, nil)
+	}
+
+	// Font preference: binomial test of serif preferences against 50%
+	var preferences []string
+	preferenceQuery := s.db.Model(&store.StudySession{}).Where("preferred_font_type IS NOT NULL AND preferred_font_type != ''")
+	if err := s.withoutExcluded(preferenceQuery, "id", exclude).
+		Pluck("preferred_font_type", &preferences).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load font preferences: " + err.Error()})
+	}
+	var serifPreferred, sansPreferred int
+	for _, p := range preferences {
+		switch fonts.category(p) {
+		case "serif":
+			serifPreferred++
+		case "sans-serif":
+			sansPreferred++
+		}
+	}
+	binomial, err := analysis.BinomialTest(serifPreferred, serifPreferred+sansPreferred, 0.5)
+	preference := newTestResult(map[string]interface{}{
+		"serif":            serifPreferred,
+		"sans":             sansPreferred,
+		"n":                binomial.N,
+		"proportion_serif": binomial.Proportion,
+		"ci95":             binomial.CI95,
+		"p_value":          binomial.PValue,
+		"cohens_h":         binomial.CohensH,
+	}, err)
+
+	// Quiz correctness by the session's preferred font type: chi-square on a 2x2 table
+	var quizRows []struct {
+		PreferredFontType string
+		IsCorrect         bool
+		Count             int64
+	}
+	quizQuery := s.db.Table("quiz_responses").
+		Select("study_sessions.preferred_font_type, quiz_responses.is_correct, COUNT(*) AS count").
+		Joins("JOIN study_sessions ON study_sessions.id = quiz_responses.session_id").
+		Where("quiz_responses.is_correct IS NOT NULL")
+	if err := s.withoutExcluded(quizQuery, "quiz_responses.session_id", exclude).
+		Group("study_sessions.preferred_font_type, quiz_responses.is_correct").
+		Scan(&quizRows).Error; err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load quiz responses: " + err.Error()})
+	}
+	// Rows are serif, sans; columns are correct, incorrect
+	table := [][]float64{{0, 0}, {0, 0}}
+	for _, r := range quizRows {
+		row := -1
+		switch fonts.category(r.PreferredFontType) {
+		case "serif":
+			row = 0
+		case "sans-serif":
+			row = 1
+		}
+		if row < 0 {
+			continue
+		}
+		if r.IsCorrect {
+			table[row][0] += float64(r.Count)
+		} else {
+			table[row][1] += float64(r.Count)
+		}
+	}
+	chi, err := analysis.ChiSquareTest(table)
+	difference, differenceCI, _ := analysis.ProportionDifference(
+		int(table[0][0]), int(table[0][0]+table[0][1]), int(table[1][0]), int(table[1][0]+table[1][1]))
+	quiz := newTestResult(map[string]interface{}{
+		"serif":                    map[string]float64{"correct": table[0][0], "incorrect": table[0][1]},
+		"sans":                     map[string]float64{"correct": table[1][0], "incorrect": table[1][1]},
+		"chi_square":               chi.ChiSquare,
+		"df":                       chi.DF,
+		"p_value":                  chi.PValue,
+		"cramers_v":                chi.CramersV,
+		"accuracy_difference":      difference,
+		"accuracy_difference_ci95": differenceCI,
+	}, err)
+
+	return c.JSON(200, map[string]interface{}{
+		"success":  true,
+		"excluded": exclude,
+		"data": map[string]interface{}{
+			"reading_times": map[string]interface{}{
+				"n":             len(serif),
+				"mean_serif_ms": analysis.Mean(serif),
+				"sd_serif_ms":   analysis.StdDev(serif),
+				"mean_sans_ms":  analysis.Mean(sans),
+				"sd_sans_ms":    analysis.StdDev(sans),
+				"paired_t_test": tTest,
+				"wilcoxon":      wilcoxon,
+			},
+			"font_preference":  preference,
+			"quiz_correctness": quiz,
+		},
+	})
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"readability-backend/store"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Study text statuses, see store.StudyTextDraft
+const (
+	studyTextDraft     = store.StudyTextDraft
+	studyTextPublished = store.StudyTextPublished
+)
+
+var (
+	ErrStudyTextNotFound  = errors.New("study text not found")
+	ErrStudyTextPublished = errors.New("study text is published; clone it into a draft to edit it")
+	errEmptyStudyText     = errors.New("study text has no passages to publish")
+
+	errPassageNotFound      = errors.New("passage not found")
+	errQuizQuestionNotFound = errors.New("quiz question not found")
+	errPassageNotInText     = errors.New("passage not found or does not belong to the study text")
+)
+
+// requireDraft checks that a study text exists and is still a draft, so its
+// passages and quiz questions may be changed. Call it inside the transaction
+// that makes the change: it loads the study text for update, so the text
+// can't be published halfway through an edit.
+func requireDraft(repo store.Repository, studyTextID uint) error {
+	studyText, err := repo.StudyTexts().GetForUpdate(studyTextID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrStudyTextNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if studyText.Status != studyTextDraft {
+		return ErrStudyTextPublished
+	}
+	return nil
+}
+
+// notFoundAs replaces store.ErrNotFound with the handler's own not-found error
+func notFoundAs(err, notFound error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// isDraftError reports whether err is requireDraft rejecting the study text
+func isDraftError(err error) bool {
+	return errors.Is(err, ErrStudyTextNotFound) || errors.Is(err, ErrStudyTextPublished)
+}
+
+// respondDraftError maps errors of requireDraft to a response
+func respondDraftError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrStudyTextNotFound):
+		return c.JSON(404, map[string]string{"error": "Study text not found"})
+	case errors.Is(err, ErrStudyTextPublished):
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+	}
+}
+
+// createDraft stores a new study text as an inactive draft. The active column
+// defaults to true, so it is cleared explicitly after the insert.
+func createDraft(repo store.Repository, studyText *store.StudyText) error {
+	studyText.Status = studyTextDraft
+	studyText.Active = false
+	studyText.PublishedAt = nil
+	if err := repo.StudyTexts().Create(studyText); err != nil {
+		return err
+	}
+	return repo.StudyTexts().SetActive(studyText.ID, false)
+}
+
+// cloneStudyText copies a study text with all its passages and quiz questions
+// into a new draft. Questions are relinked to the copies of their passages and
+// every copy starts with its own first revision.
+func cloneStudyText(repo store.Repository, source store.StudyText, version, actor string) (store.StudyText, error) {
+	sourceID := source.ID
+	clone := store.StudyText{
+		Version:        version,
+		Content:        source.Content,
+		FontLeft:       source.FontLeft,
+		FontRight:      source.FontRight,
+		CompletionCode: source.CompletionCode,
+		CompletionURL:  source.CompletionURL,
+		ClonedFromID:   &sourceID,
+	}
+	if err := createDraft(repo, &clone); err != nil {
+		return clone, err
+	}
+
+	passages, err := repo.Passages().ForStudyText(source.ID)
+	if err != nil {
+		return clone, err
+	}
+	passageIDs := make(map[uint]uint, len(passages))
+	for _, passage := range passages {
+		copied := store.Passage{
+			StudyTextID: clone.ID,
+			Order:       passage.Order,
+			Content:     passage.Content,
+			Title:       passage.Title,
+			FontLeft:    passage.FontLeft,
+			FontRight:   passage.FontRight,
+		}
+		if err := repo.Passages().Create(&copied); err != nil {
+			return clone, err
+		}
+		if err := repo.Passages().Revise(&copied, actor); err != nil {
+			return clone, err
+		}
+		passageIDs[passage.ID] = copied.ID
+	}
+
+	questions, err := repo.QuizQuestions().ForStudyText(source.ID)
+	if err != nil {
+		return clone, err
+	}
+	for _, question := range questions {
+		copied := store.QuizQuestion{
+			StudyTextID: clone.ID,
+			QuestionID:  question.QuestionID,
+			Prompt:      question.Prompt,
+			Choices:     question.Choices,
+			Answer:      question.Answer,
+			Order:       question.Order,
+		}
+		if question.PassageID != nil {
+			passageID, ok := passageIDs[*question.PassageID]
+			if !ok {
+				// The question points at a passage of another study text; keep it at study text level
+				log.Printf("Quiz question %d links to passage %d outside study text %d, cloning it unlinked", question.ID, *question.PassageID, source.ID)
+			} else {
+				copied.PassageID = &passageID
+			}
+		}
+		if err := repo.QuizQuestions().Create(&copied); err != nil {
+			return clone, err
+		}
+		if err := repo.QuizQuestions().Revise(&copied, actor); err != nil {
+			return clone, err
+		}
+	}
+
+	return clone, nil
+}
+
+// handleAdminCloneStudyText deep-copies a study text into a new draft for
+// POST /api/admin/study-texts/:id/clone. The body names the draft's version.
+func (s *Server) handleAdminCloneStudyText(c echo.Context) error {
+	studyTextID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+	}
+
+	var request struct {
+		Version string `json:"version"`
+	}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid JSON: " + err.Error()})
+	}
+	request.Version = strings.TrimSpace(request.Version)
+	if request.Version == "" {
+		return c.JSON(400, map[string]string{"error": "version is required"})
+	}
+
+	source, err := s.repo.StudyTexts().Get(uint(studyTextID))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(404, map[string]string{"error": "Study text not found"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+	}
+
+	_, err = s.repo.StudyTexts().GetByVersion(request.Version)
+	if err == nil {
+		return c.JSON(409, map[string]string{"error": "Study text with version '" + request.Version + "' already exists"})
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return c.JSON(500, map[string]string{"error": "Failed to check version: " + err.Error()})
+	}
+
+	var clone store.StudyText
+	err = s.repo.Transaction(func(repo store.Repository) error {
+		clone, err = cloneStudyText(repo, source, request.Version, adminActor(c))
+		if err != nil {
+			return err
+		}
+		return recordAudit(repo, c, auditClone, entityStudyText, clone.ID, nil, clone)
+	})
+	if err != nil {
+		if s.store.IsUniqueViolation(err) {
+			return c.JSON(409, map[string]string{"error": "Study text with version '" + request.Version + "' already exists"})
+		}
+		return c.JSON(500, map[string]string{"error": "Failed to clone study text: " + err.Error()})
+	}
+
+	return c.JSON(201, map[string]interface{}{
+		"success":        true,
+		"id":             clone.ID,
+		"version":        clone.Version,
+		"status":         clone.Status,
+		"cloned_from_id": source.ID,
+		"message":        "Study text cloned into a draft",
+	})
+}
+
+// publishStudyText freezes a draft and makes it the only active study text
+func publishStudyText(repo store.Repository, studyTextID uint, actor string) (store.StudyText, error) {
+	if err := requireDraft(repo, studyTextID); err != nil {
+		return store.StudyText{}, err
+	}
+	studyText, err := repo.StudyTexts().Get(studyTextID)
+	if err != nil {
+		return studyText, err
+	}
+	before := studyText
+
+	passages, err := repo.Passages().Count(studyText.ID)
+	if err != nil {
+		return studyText, err
+	}
+	if passages == 0 && strings.TrimSpace(studyText.Content) == "" {
+		return studyText, errEmptyStudyText
+	}
+
+	if err := repo.StudyTexts().DeactivateOthers(studyText.ID); err != nil {
+		return studyText, err
+	}
+	now := time.Now()
+	studyText.Status = studyTextPublished
+	studyText.Active = true
+	studyText.PublishedAt = &now
+	if err := repo.StudyTexts().Save(&studyText); err != nil {
+		return studyText, err
+	}
+	return studyText, writeAudit(repo, actor, auditPublish, entityStudyText, studyText.ID, before, studyText)
+}
+
+// handleAdminPublishStudyText publishes a draft for
+// POST /api/admin/study-texts/:id/publish. In one transaction the draft is
+// frozen and made the active study text, so participants switch over at once.
+func (s *Server) handleAdminPublishStudyText(c echo.Context) error {
+	studyTextID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+	}
+
+	var studyText store.StudyText
+	err = s.repo.Transaction(func(repo store.Repository) error {
+		studyText, err = publishStudyText(repo, uint(studyTextID), adminActor(c))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errEmptyStudyText) {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		return respondDraftError(c, err)
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success":      true,
+		"id":           studyText.ID,
+		"version":      studyText.Version,
+		"published_at": studyText.PublishedAt,
+		"message":      "Study text published and activated",
+	})
+}
+
+// previewPassage is a passage with the quiz questions shown after it
+type previewPassage struct {
+	store.Passage
+	QuizQuestions []previewQuizQuestion `json:"quiz_questions"`
+}
+
+// handleAdminPreviewStudyText returns any study text, drafts included, the
+// way participants would receive it, with the quiz questions of each passage,
+// for GET /api/admin/study-texts/:id/preview
+func (s *Server) handleAdminPreviewStudyText(c echo.Context) error {
+	studyTextID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid study text ID"})
+	}
+
+	studyText, err := s.repo.StudyTexts().Get(uint(studyTextID))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(404, map[string]string{"error": "Study text not found"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to load study text: " + err.Error()})
+	}
+
+	passages, err := s.repo.Passages().ForStudyText(studyText.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch passages: " + err.Error()})
+	}
+	questions, err := s.repo.QuizQuestions().ForStudyText(studyText.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to fetch quiz questions: " + err.Error()})
+	}
+
+	byPassage := make(map[uint][]store.QuizQuestion)
+	var general []store.QuizQuestion
+	for _, question := range questions {
+		if question.PassageID != nil {
+			byPassage[*question.PassageID] = append(byPassage[*question.PassageID], question)
+		} else {
+			general = append(general, question)
+		}
+	}
+
+	preview := make([]previewPassage, len(passages))
+	for i, passage := range passages {
+		preview[i] = previewPassage{Passage: passage, QuizQuestions: newPreviewQuizQuestions(byPassage[passage.ID])}
+	}
+
+	data := map[string]interface{}{
+		"id":             studyText.ID,
+		"version":        studyText.Version,
+		"status":         studyText.Status,
+		"active":         studyText.Active,
+		"font_left":      studyText.FontLeft,
+		"font_right":     studyText.FontRight,
+		"passages":       preview,
+		"quiz_questions": newPreviewQuizQuestions(general),
+	}
+	if len(passages) == 0 {
+		data["content"] = studyText.Content
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// quizQuestionJSON is a quiz question as participants receive it. The answer
+// key is left out; responses are graded on the server.
+type quizQuestionJSON struct {
+	ID         string   `json:"id"`
+	RevisionID *uint    `json:"revision_id"` // sent back as question_revision_id with the answer
+	Prompt     string   `json:"prompt"`
+	Choices    []string `json:"choices"`
+}
+
+// previewQuizQuestion is a quiz question as participants receive it, with
+// the answer key for editors checking the preview
+type previewQuizQuestion struct {
+	quizQuestionJSON
+	Answer int `json:"answer"`
+}
+
+// newQuizQuestionJSON formats a quiz question for participants. It reports
+// false if the stored choices cannot be read.
+func newQuizQuestionJSON(q store.QuizQuestion) (quizQuestionJSON, bool) {
+	var choices []string
+	if err := json.Unmarshal([]byte(q.Choices), &choices); err != nil {
+		log.Printf("Error unmarshaling choices for question %s: %v", q.QuestionID, err)
+		return quizQuestionJSON{}, false
+	}
+	return quizQuestionJSON{
+		ID:         q.QuestionID,
+		RevisionID: q.RevisionID,
+		Prompt:     q.Prompt,
+		Choices:    choices,
+	}, true
+}
+
+// newQuizQuestionsJSON formats quiz questions for participants, skipping
+// questions whose stored choices cannot be read
+func newQuizQuestionsJSON(questions []store.QuizQuestion) []quizQuestionJSON {
+	response := make([]quizQuestionJSON, 0, len(questions))
+	for _, q := range questions {
+		if question, ok := newQuizQuestionJSON(q); ok {
+			response = append(response, question)
+		}
+	}
+	return response
+}
+
+// newPreviewQuizQuestions formats quiz questions for the admin preview,
+// skipping the same questions participants wouldn't see
+func newPreviewQuizQuestions(questions []store.QuizQuestion) []previewQuizQuestion {
+	response := make([]previewQuizQuestion, 0, len(questions))
+	for _, q := range questions {
+		if question, ok := newQuizQuestionJSON(q); ok {
+			response = append(response, previewQuizQuestion{quizQuestionJSON: question, Answer: q.Answer})
+		}
+	}
+	return response
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"readability-backend/api"
+	"readability-backend/config"
+	"readability-backend/store"
+
+	"gorm.io/gorm"
+)
+
+// commands runs maintenance subcommands against the database instead of starting the server
+type commands struct {
+	db     *gorm.DB
+	config config.Config
+	server *api.Server
+}
+
+// runCommand executes a CLI subcommand
+func (cmd commands) runCommand(name string, args []string) error {
+	switch name {
+	case "regrade-quiz":
+		return cmd.runRegradeQuiz(args)
+	case "create-admin":
+		return cmd.runCreateAdmin(args)
+	case "evaluate-quality":
+		return cmd.runEvaluateQuality(args)
+	case "erase-participant":
+		return cmd.runEraseParticipant(args)
+	case "purge-metadata":
+		return cmd.runPurgeMetadata(args)
+	case "import-bundle":
+		return cmd.runImportBundle(args)
+	case "export-bundle":
+		return cmd.runExportBundle(args)
+	default:
+		return fmt.Errorf("unknown command %q (available: migrate, regrade-quiz, create-admin, evaluate-quality, erase-participant, purge-metadata, import-bundle, export-bundle)", name)
+	}
+}
+
+// runCreateAdmin creates an admin account. The password is taken from
+// -password, then ADMIN_PASSWORD, then the first line of stdin.
+func (cmd commands) runCreateAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "admin username (required)")
+	password := fs.String("password", "", "admin password (min 8 characters)")
+	role := fs.String("role", api.RoleEditor, "role: viewer or editor")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *password == "" {
+		*password = os.Getenv("ADMIN_PASSWORD")
+	}
+	if *password == "" {
+		fmt.Print("Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := cmd.server.CreateAdminUser(*username, *password, *role, "cli")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %q with role %q (id %d)\n", user.Username, user.Role, user.ID)
+	return nil
+}
+
+// runRegradeQuiz recomputes is_correct for every stored quiz response from the
+// stored answer of the question revision it was given for, replacing values
+// that were reported by the client
+func (cmd commands) runRegradeQuiz(args []string) error {
+	fs := flag.NewFlagSet("regrade-quiz", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := cmd.server.RegradeQuiz(*dryRun)
+	if err != nil {
+		return fmt.Errorf("regrade failed: %w", err)
+	}
+
+	mode := "updated"
+	if *dryRun {
+		mode = "would update"
+	}
+	fmt.Printf("Checked %d quiz responses: %s %d, skipped %d (no matching session or question)\n", result.Checked, mode, result.Changed, result.Skipped)
+	return nil
+}
+
+// runEvaluateQuality scores every completed or abandoned session with the
+// quality checks and stores the inclusion decisions, keeping manual overrides
+func (cmd commands) runEvaluateQuality(args []string) error {
+	defaults := cmd.config.Quality
+	fs := flag.NewFlagSet("evaluate-quality", flag.ContinueOnError)
+	params := defaults
+	fs.Float64Var(&params.MinCalibrationAccuracy, "min-calibration-accuracy", defaults.MinCalibrationAccuracy, "minimum calibration accuracy in percent")
+	fs.Float64Var(&params.MinSampleRate, "min-sample-rate", defaults.MinSampleRate, "minimum gaze sample rate in Hz")
+	fs.Float64Var(&params.MaxOffscreenShare, "max-offscreen-share", defaults.MaxOffscreenShare, "maximum share of off-screen gaze samples (0-1)")
+	fs.IntVar(&params.MinReadingTimeMS, "min-reading-time-ms", defaults.MinReadingTimeMS, "shortest plausible panel reading time")
+	fs.IntVar(&params.MaxReadingTimeMS, "max-reading-time-ms", defaults.MaxReadingTimeMS, "longest plausible panel reading time (0 for no limit)")
+	fs.IntVar(&params.MinQuizResponses, "min-quiz-responses", defaults.MinQuizResponses, "graded answers needed for the quiz check")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	evaluated, err := cmd.server.EvaluateSessions(params.Thresholds())
+	if err != nil {
+		return fmt.Errorf("quality evaluation failed: %w", err)
+	}
+
+	var excluded int64
+	cmd.db.Model(&store.SessionQuality{}).Where("included = ?", false).Count(&excluded)
+	fmt.Printf("Evaluated %d sessions: %d excluded\n", evaluated, excluded)
+	return nil
+}
+
+// runEraseParticipant deletes or anonymizes one participant, found by -id or
+// by -external-id (with -source when the ID is used on several platforms)
+func (cmd commands) runEraseParticipant(args []string) error {
+	fs := flag.NewFlagSet("erase-participant", flag.ContinueOnError)
+	id := fs.Uint("id", 0, "participant ID")
+	externalID := fs.String("external-id", "", "recruitment platform ID (PROLIFIC_PID or workerId)")
+	source := fs.String("source", "", "recruitment platform of -external-id, e.g. prolific or mturk")
+	mode := fs.String("mode", api.ErasureDelete, "delete or anonymize")
+	reason := fs.String("reason", "", "reason recorded in the erasure record")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	participantID := *id
+	if participantID == 0 {
+		if *externalID == "" {
+			return errors.New("-id or -external-id is required")
+		}
+		query := cmd.db.Model(&store.Participant{}).Where("external_id = ?", *externalID)
+		if *source != "" {
+			query = query.Where("source = ?", strings.ToLower(*source))
+		}
+		var ids []uint
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return api.ErrParticipantNotFound
+		}
+		if len(ids) > 1 {
+			return errors.New("external ID matches participants of several sources; give -source as well")
+		}
+		participantID = ids[0]
+	}
+
+	result, err := cmd.server.EraseParticipant(participantID, *mode, *reason, "cli")
+	if err != nil {
+		return fmt.Errorf("erasure failed: %w", err)
+	}
+
+	verb := "Deleted"
+	if *mode == api.ErasureAnonymize {
+		verb = "Anonymized"
+	}
+	fmt.Printf("%s participant %d with %d sessions (erasure record %d)\n", verb, participantID, result.Record.Sessions, result.Record.ID)
+	return nil
+}
+
+// runPurgeMetadata clears identifying metadata older than the retention period now
+func (cmd commands) runPurgeMetadata(args []string) error {
+	fs := flag.NewFlagSet("purge-metadata", flag.ContinueOnError)
+	days := fs.Int("days", cmd.config.Study.MetadataRetentionDays, "retention period in days (study.metadata_retention_days by default)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days < 0 {
+		return errors.New("-days must not be negative")
+	}
+
+	rows, err := cmd.server.PurgeMetadata(time.Duration(*days)*24*time.Hour, "cli")
+	if err != nil {
+		return fmt.Errorf("purge failed: %w", err)
+	}
+	fmt.Printf("Purged metadata older than %d days: %d sessions, %d consent records, %d participants\n",
+		*days, rows["study_sessions"], rows["consent_records"], rows["participants"])
+	return nil
+}
+
+// runImportBundle creates or updates a draft study text from a YAML or JSON
+// bundle file ("-" reads stdin) and prints the changes
+func (cmd commands) runImportBundle(args []string) error {
+	fs := flag.NewFlagSet("import-bundle", flag.ContinueOnError)
+	file := fs.String("file", "", "bundle file (.yaml, .yml or .json), or - for stdin")
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing them")
+	publish := fs.Bool("publish", false, "publish and activate the study text after importing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+
+	bundle, err := api.ParseBundle(data)
+	if err != nil {
+		return err
+	}
+	result, err := cmd.server.ImportBundle(bundle, "cli", *dryRun, *publish)
+	if errors.Is(err, api.ErrStudyTextPublished) {
+		return fmt.Errorf("study text %q is published; import the bundle under a new version", bundle.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	api.WriteBundleChanges(os.Stdout, result)
+	switch {
+	case *dryRun:
+		fmt.Printf("Dry run: nothing was written to study text %q\n", bundle.Version)
+	case result.Published:
+		fmt.Printf("Imported and published study text %q (id %d)\n", bundle.Version, result.StudyTextID)
+	default:
+		fmt.Printf("Imported draft study text %q (id %d)\n", bundle.Version, result.StudyTextID)
+	}
+	return nil
+}
+
+// runExportBundle writes a study text as a YAML or JSON bundle
+func (cmd commands) runExportBundle(args []string) error {
+	fs := flag.NewFlagSet("export-bundle", flag.ContinueOnError)
+	id := fs.Uint("id", 0, "study text ID")
+	version := fs.String("version", "", "study text version, instead of -id")
+	format := fs.String("format", "", "yaml or json (default: from the -out extension, else yaml)")
+	out := fs.String("out", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = "yaml"
+		if strings.HasSuffix(*out, ".json") {
+			*format = "json"
+		}
+	}
+
+	var studyText store.StudyText
+	query := cmd.db.Limit(1)
+	switch {
+	case *id != 0:
+		query = query.Where("id = ?", *id)
+	case *version != "":
+		query = query.Where("version = ?", *version)
+	default:
+		return errors.New("-id or -version is required")
+	}
+	found := query.Find(&studyText)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected == 0 {
+		return api.ErrStudyTextNotFound
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return cmd.server.ExportBundle(w, studyText, *format)
+}
+
+// runMigrate applies, rolls back or lists the schema migrations:
+// "migrate up [-to N]", "migrate down [-steps N | -to N]" or "migrate status"
+func runMigrate(db *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status")
+	}
+
+	switch args[0] {
+	case "status":
+		return store.WriteMigrationStatus(db, os.Stdout)
+
+	case "up":
+		fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+		to := fs.Int("to", 0, "stop after this version (default: apply all)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		done, err := store.MigrateUp(db, *to)
+		for _, m := range done {
+			fmt.Printf("Applied migration %d (%s)\n", m.Version, m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("Database is up to date")
+		}
+		return err
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		to := fs.Int("to", -1, "roll back every migration newer than this version (0 for all), instead of -steps")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		target := *to
+		if target < 0 {
+			if *steps < 1 {
+				return errors.New("-steps must be at least 1")
+			}
+			applied, err := store.AppliedMigrations(db)
+			if err != nil {
+				return err
+			}
+			versions := make([]int, 0, len(applied))
+			for version := range applied {
+				versions = append(versions, version)
+			}
+			sort.Ints(versions)
+			target = 0
+			if *steps < len(versions) {
+				target = versions[len(versions)-*steps-1]
+			}
+		}
+
+		done, err := store.MigrateDown(db, target)
+		for _, m := range done {
+			fmt.Printf("Rolled back migration %d (%s)\n", m.Version, m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+		return err
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (available: up, down, status)", args[0])
+	}
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"readability-backend/analysis"
+	"readability-backend/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is not set, if it exists
+const defaultConfigFile = "config.yaml"
+
+// Config holds the server settings. They come from a YAML file, then from
+// environment variables, which override the file; anything left out keeps
+// its default.
+type Config struct {
+	Server    ServerConfig      `yaml:"server" json:"server"`
+	Database  DatabaseConfig    `yaml:"database" json:"database"`
+	Admin     AdminConfig       `yaml:"admin" json:"admin"`
+	Study     StudyConfig       `yaml:"study" json:"study"`
+	Ingestion IngestionConfig   `yaml:"ingestion" json:"ingestion"`
+	Quality   QualityThresholds `yaml:"quality" json:"quality"` // Defaults for the quality checks
+
+	File string `yaml:"-" json:"-"` // File the configuration was read from, empty if none
+}
+
+type ServerConfig struct {
+	Port           string   `yaml:"port" json:"port"`
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"` // CORS origins of the frontend
+	TLSCertFile    string   `yaml:"tls_cert_file" json:"tls_cert_file"`     // Serve HTTPS when both files are set
+	TLSKeyFile     string   `yaml:"tls_key_file" json:"tls_key_file"`
+}
+
+type DatabaseConfig struct {
+	Driver         string `yaml:"driver" json:"driver"` // sqlite or postgres
+	DSN            string `yaml:"dsn" json:"dsn"`       // File path for sqlite (readability.db when empty), connection URL for postgres
+	MigrateOnStart bool   `yaml:"migrate_on_start" json:"migrate_on_start"`
+}
+
+type AdminConfig struct {
+	TokenSecret    string   `yaml:"token_secret" json:"-"` // Random per start when empty
+	TokenSecretSet bool     `yaml:"-" json:"token_secret_set"`
+	TokenTTL       Duration `yaml:"token_ttl" json:"token_ttl"`
+}
+
+type StudyConfig struct {
+	DefaultVersion        string   `yaml:"default_version" json:"default_version"` // Study text served when none is requested
+	DefaultSource         string   `yaml:"default_source" json:"default_source"`   // Participant source when none is given
+	AccuracyThreshold     float64  `yaml:"accuracy_threshold" json:"accuracy_threshold"`
+	SessionIdleTimeout    Duration `yaml:"session_idle_timeout" json:"session_idle_timeout"`       // 0 disables the sweeper
+	MetadataRetentionDays int      `yaml:"metadata_retention_days" json:"metadata_retention_days"` // 0 disables the purge
+}
+
+type IngestionConfig struct {
+	MaxGazeBatchSize int `yaml:"max_gaze_batch_size" json:"max_gaze_batch_size"` // Points per batch request
+	MaxBundleSizeMB  int `yaml:"max_bundle_size_mb" json:"max_bundle_size_mb"`   // Study bundle upload limit
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:           "8080",
+			AllowedOrigins: []string{"http://localhost:5173", "http://localhost:4173", "http://localhost:3000"},
+		},
+		Database: DatabaseConfig{
+			Driver:         "sqlite",
+			MigrateOnStart: true,
+		},
+		Admin: AdminConfig{
+			TokenTTL: Duration(12 * time.Hour),
+		},
+		Study: StudyConfig{
+			DefaultVersion:        "default",
+			DefaultSource:         "web",
+			AccuracyThreshold:     70,
+			SessionIdleTimeout:    Duration(defaultSessionIdleTimeout),
+			MetadataRetentionDays: int(defaultMetadataRetention / (24 * time.Hour)),
+		},
+		Ingestion: IngestionConfig{
+			MaxGazeBatchSize: 5000,
+			MaxBundleSizeMB:  10,
+		},
+		Quality: NewQualityThresholds(analysis.DefaultQualityThresholds()),
+	}
+}
+
+// Duration is a time.Duration written as a Go duration ("45m", "2h") or a
+// number of minutes
+type Duration time.Duration
+
+func parseDuration(value string) (Duration, error) {
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return Duration(time.Duration(minutes) * time.Minute), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 45m, 2h or a number of minutes)", value)
+	}
+	return Duration(d), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := parseDuration(node.Value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// configEnv lists the environment variables that override the config file
+var configEnv = []struct {
+	Name  string
+	Apply func(c *Config, value string) error
+}{
+	{"PORT", func(c *Config, v string) error { c.Server.Port = v; return nil }},
+	{"CORS_ALLOWED_ORIGINS", func(c *Config, v string) error {
+		c.Server.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.Server.AllowedOrigins = append(c.Server.AllowedOrigins, origin)
+			}
+		}
+		return nil
+	}},
+	{"TLS_CERT_FILE", func(c *Config, v string) error { c.Server.TLSCertFile = v; return nil }},
+	{"TLS_KEY_FILE", func(c *Config, v string) error { c.Server.TLSKeyFile = v; return nil }},
+	{"DB_DRIVER", func(c *Config, v string) error { c.Database.Driver = v; return nil }},
+	{"DB_DSN", func(c *Config, v string) error { c.Database.DSN = v; return nil }},
+	{"MIGRATE_ON_START", func(c *Config, v string) (err error) {
+		c.Database.MigrateOnStart, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"ADMIN_TOKEN_SECRET", func(c *Config, v string) error { c.Admin.TokenSecret = v; return nil }},
+	{"ADMIN_TOKEN_TTL", func(c *Config, v string) (err error) {
+		c.Admin.TokenTTL, err = parseDuration(v)
+		return err
+	}},
+	{"DEFAULT_STUDY_VERSION", func(c *Config, v string) error { c.Study.DefaultVersion = v; return nil }},
+	{"DEFAULT_PARTICIPANT_SOURCE", func(c *Config, v string) error { c.Study.DefaultSource = v; return nil }},
+	{"ACCURACY_THRESHOLD", func(c *Config, v string) (err error) {
+		c.Study.AccuracyThreshold, err = strconv.ParseFloat(v, 64)
+		return err
+	}},
+	{"SESSION_IDLE_TIMEOUT", func(c *Config, v string) (err error) {
+		c.Study.SessionIdleTimeout, err = parseDuration(v)
+		return err
+	}},
+	{"METADATA_RETENTION_DAYS", func(c *Config, v string) (err error) {
+		c.Study.MetadataRetentionDays, err = strconv.Atoi(v)
+		return err
+	}},
+	{"MAX_GAZE_BATCH_SIZE", func(c *Config, v string) (err error) {
+		c.Ingestion.MaxGazeBatchSize, err = strconv.Atoi(v)
+		return err
+	}},
+	{"MAX_BUNDLE_SIZE_MB", func(c *Config, v string) (err error) {
+		c.Ingestion.MaxBundleSizeMB, err = strconv.Atoi(v)
+		return err
+	}},
+}
+
+// configError lists everything wrong with the configuration
+type configError struct {
+	Problems []string
+}
+
+func (e *configError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Load reads CONFIG_FILE (or config.yaml if present), applies the
+// environment overrides and validates the result. File is set to the file it
+// read, empty if none.
+func Load() (Config, error) {
+	cfg := Default()
+
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("reading %s: %w", path, err)
+		}
+	case !explicit && errors.Is(err, fs.ErrNotExist):
+		path = ""
+	default:
+		return cfg, err
+	}
+	cfg.File = path
+
+	var problems []string
+	for _, env := range configEnv {
+		if value, ok := os.LookupEnv(env.Name); ok && value != "" {
+			if err := env.Apply(&cfg, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s=%q: %v", env.Name, value, err))
+			}
+		}
+	}
+	problems = append(problems, cfg.problems()...)
+	if len(problems) > 0 {
+		return cfg, &configError{Problems: problems}
+	}
+
+	cfg.Admin.TokenSecretSet = cfg.Admin.TokenSecret != ""
+	return cfg, nil
+}
+
+// problems lists every invalid setting
+func (c Config) problems() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("server.port %q must be a port number", c.Server.Port)
+	}
+	if len(c.Server.AllowedOrigins) == 0 {
+		add("server.allowed_origins must list at least one origin (or \"*\")")
+	}
+	for _, origin := range c.Server.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			add("server.allowed_origins: %q is not an origin like https://study.example.org", origin)
+		}
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		add("server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	for _, file := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			add("server: %v", err)
+		}
+	}
+
+	if _, err := store.New(c.Database.Driver, c.Database.DSN); err != nil {
+		add("database: %v", err)
+	}
+	if c.Admin.TokenTTL <= 0 {
+		add("admin.token_ttl must be positive")
+	}
+
+	if strings.TrimSpace(c.Study.DefaultVersion) == "" {
+		add("study.default_version must not be empty")
+	}
+	if strings.TrimSpace(c.Study.DefaultSource) == "" {
+		add("study.default_source must not be empty")
+	}
+	if c.Study.AccuracyThreshold < 0 || c.Study.AccuracyThreshold > 100 {
+		add("study.accuracy_threshold must be between 0 and 100")
+	}
+	if c.Study.SessionIdleTimeout < 0 {
+		add("study.session_idle_timeout must not be negative")
+	}
+	if c.Study.MetadataRetentionDays < 0 {
+		add("study.metadata_retention_days must not be negative")
+	}
+
+	if c.Ingestion.MaxGazeBatchSize < 1 {
+		add("ingestion.max_gaze_batch_size must be at least 1")
+	}
+	if c.Ingestion.MaxBundleSizeMB < 1 {
+		add("ingestion.max_bundle_size_mb must be at least 1")
+	}
+	if err := c.Quality.Validate(); err != nil {
+		add("quality.%v", err)
+	}
+	return problems
+}
+
+// SessionIdleTimeout is how long a session may go without data before the sweeper abandons it
+func (c Config) SessionIdleTimeout() time.Duration {
+	return time.Duration(c.Study.SessionIdleTimeout)
+}
+
+// MetadataRetention is how long identifying metadata is kept
+func (c Config) MetadataRetention() time.Duration {
+	return time.Duration(c.Study.MetadataRetentionDays) * 24 * time.Hour
+}
+
+// MaxBundleSize is the bundle upload limit in bytes
+func (c Config) MaxBundleSize() int {
+	return c.Ingestion.MaxBundleSizeMB << 20
+}
+
+// dsnPassword matches the password of a key/value postgres DSN
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Redacted returns the configuration without secrets, for display
+func (c Config) Redacted() Config {
+	if u, err := url.Parse(c.Database.DSN); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			c.Database.DSN = u.String()
+		}
+	}
+	c.Database.DSN = dsnPassword.ReplaceAllString(c.Database.DSN, "${1}xxxxx")
+	c.Admin.TokenSecret = ""
+	return c
+}
+
+// QualityThresholds is the request and stored form of analysis.QualityThresholds
+type QualityThresholds struct {
+	MinCalibrationAccuracy float64 `json:"min_calibration_accuracy" yaml:"min_calibration_accuracy"`
+	MinSampleRate          float64 `json:"min_sample_rate_hz" yaml:"min_sample_rate_hz"`
+	MaxOffscreenShare      float64 `json:"max_offscreen_share" yaml:"max_offscreen_share"`
+	MinReadingTimeMS       int     `json:"min_reading_time_ms" yaml:"min_reading_time_ms"`
+	MaxReadingTimeMS       int     `json:"max_reading_time_ms" yaml:"max_reading_time_ms"`
+	MinQuizResponses       int     `json:"min_quiz_responses" yaml:"min_quiz_responses"`
+}
+
+func NewQualityThresholds(t analysis.QualityThresholds) QualityThresholds {
+	return QualityThresholds{
+		MinCalibrationAccuracy: t.MinCalibrationAccuracy,
+		MinSampleRate:          t.MinSampleRate,
+		MaxOffscreenShare:      t.MaxOffscreenShare,
+		MinReadingTimeMS:       int(t.MinReadingTime.Milliseconds()),
+		MaxReadingTimeMS:       int(t.MaxReadingTime.Milliseconds()),
+		MinQuizResponses:       t.MinQuizResponses,
+	}
+}
+
+func (t QualityThresholds) Thresholds() analysis.QualityThresholds {
+	return analysis.QualityThresholds{
+		MinCalibrationAccuracy: t.MinCalibrationAccuracy,
+		MinSampleRate:          t.MinSampleRate,
+		MaxOffscreenShare:      t.MaxOffscreenShare,
+		MinReadingTime:         time.Duration(t.MinReadingTimeMS) * time.Millisecond,
+		MaxReadingTime:         time.Duration(t.MaxReadingTimeMS) * time.Millisecond,
+		MinQuizResponses:       t.MinQuizResponses,
+	}
+}
+
+func (t QualityThresholds) Validate() error {
+	switch {
+	case t.MinCalibrationAccuracy < 0 || t.MinCalibrationAccuracy > 100:
+		return errors.New("min_calibration_accuracy must be between 0 and 100")
+	case t.MinSampleRate < 0:
+		return errors.New("min_sample_rate_hz must not be negative")
+	case t.MaxOffscreenShare < 0 || t.MaxOffscreenShare > 1:
+		return errors.New("max_offscreen_share must be between 0 and 1")
+	case t.MinReadingTimeMS < 0 || t.MaxReadingTimeMS < 0:
+		return errors.New("reading time limits must not be negative")
+	case t.MaxReadingTimeMS > 0 && t.MaxReadingTimeMS < t.MinReadingTimeMS:
+		return errors.New("max_reading_time_ms must not be below min_reading_time_ms")
+	case t.MinQuizResponses < 0:
+		return errors.New("min_quiz_responses must not be negative")
+	}
+	return nil
+}
+
+// defaultSessionIdleTimeout is used when study.session_idle_timeout is not set
+const defaultSessionIdleTimeout = 30 * time.Minute
+
+// defaultMetadataRetention is how long user agents and platform session IDs are kept
+const defaultMetadataRetention = 90 * 24 * time.Hour
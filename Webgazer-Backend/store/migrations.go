@@ -0,0 +1,491 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered schema or data change. Up and Down run in a
+// transaction together with the schema_migrations bookkeeping; a nil Down
+// means there is nothing to undo (e.g. an idempotent backfill).
+//
+// Migrations are append-only: once released, a migration is never edited.
+// Migration 1 creates the tables from the frozen structs in schema_v1.go;
+// every later schema change is a new migration that uses the migrator
+// directly (AddColumn, DropColumn, CreateTable, ...) rather than AutoMigrate
+// on the current models, so each migration keeps doing what it did when it
+// was released.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// migrations lists every migration in version order. The first ones are
+// idempotent, so databases created before migrations were tracked adopt
+// them without losing data.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialSchema...)
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(initialSchema) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(initialSchema[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 2,
+		Name:    "backfill_session_status",
+		Up:      backfillSessionStatus,
+	},
+	{
+		Version: 3,
+		Name:    "seed_fonts",
+		Up:      seedFonts,
+		Down:    unseedFonts,
+	},
+	{
+		Version: 4,
+		Name:    "seed_default_study",
+		Up:      seedDefaultStudy,
+		Down:    unseedDefaultStudy,
+	},
+	{
+		Version: 5,
+		Name:    "seed_consent",
+		Up:      seedConsent,
+		Down:    unseedConsent,
+	},
+	{
+		Version: 6,
+		Name:    "backfill_revisions",
+		Up:      backfillRevisions,
+	},
+	{
+		Version: 7,
+		Name:    "add_fixation_run_max_gap",
+		Up:      addFixationRunMaxGap,
+		Down:    dropFixationRunMaxGap,
+	},
+	{
+		Version: 8,
+		Name:    "drop_legacy_session_columns",
+		Up:      dropLegacySessionColumns,
+		Down:    restoreLegacySessionColumns,
+	},
+	{
+		Version: 9,
+		Name:    "fix_session_id_column",
+		Up:      fixSessionIDColumn,
+	},
+}
+
+// latestMigration is the schema version this binary expects
+func latestMigration() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// AppliedMigrations returns the applied migrations keyed by version,
+// creating the schema_migrations table if needed
+func AppliedMigrations(tx *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := tx.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := tx.Order("version ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// checkUnknownMigrations refuses to work on a database migrated by a newer
+// binary, whose changes this one can neither use nor roll back
+func checkUnknownMigrations(applied map[int]SchemaMigration) error {
+	for version, row := range applied {
+		if version > latestMigration() {
+			return fmt.Errorf("database has migration %d (%s), newer than this binary's latest %d; deploy the newer binary or roll back with it",
+				version, row.Name, latestMigration())
+		}
+	}
+	return nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet
+func PendingMigrations(tx *gorm.DB) ([]Migration, error) {
+	applied, err := AppliedMigrations(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnknownMigrations(applied); err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp applies pending migrations to db up to and including version
+// target (0 for all of them) and returns the ones it applied
+func MigrateUp(db *gorm.DB, target int) ([]Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range pending {
+		if target > 0 && m.Version > target {
+			break
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown rolls back applied migrations, newest first, until only
+// versions up to target remain, and returns the ones it rolled back
+func MigrateDown(db *gorm.DB, target int) ([]Migration, error) {
+	applied, err := AppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnknownMigrations(applied); err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if m.Down != nil {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rolling back migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateOnStart applies pending migrations when the server or a command
+// starts. With enabled (database.migrate_on_start) off it refuses to start
+// instead, so migrations are only ever applied by an explicit "migrate up".
+func MigrateOnStart(db *gorm.DB, enabled bool) error {
+	if !enabled {
+		pending, err := PendingMigrations(db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations (next: %d %s); run \"migrate up\" first",
+				len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	}
+
+	done, err := MigrateUp(db, 0)
+	for _, m := range done {
+		log.Printf("Applied migration %d (%s)", m.Version, m.Name)
+	}
+	return err
+}
+
+// WriteMigrationStatus prints every known migration with the time it was applied
+func WriteMigrationStatus(db *gorm.DB, w io.Writer) error {
+	applied, err := AppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		state := "pending"
+		if row, ok := applied[m.Version]; ok {
+			state = "applied " + row.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%4d  %-26s %s\n", m.Version, m.Name, state)
+	}
+	var unknown []int
+	for version := range applied {
+		if version > latestMigration() {
+			unknown = append(unknown, version)
+		}
+	}
+	sort.Ints(unknown)
+	for _, version := range unknown {
+		row := applied[version]
+		fmt.Fprintf(w, "%4d  %-26s applied %s (unknown to this binary)\n", version, row.Name, row.AppliedAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// backfillSessionStatus gives sessions stored before statuses existed a final
+// status: completed if they have reading times or quiz answers, abandoned otherwise
+func backfillSessionStatus(tx *gorm.DB) error {
+	unset := "status IS NULL OR status = ''"
+	err := tx.Model(&StudySession{}).
+		Where(unset).
+		Where("time_left_ms > 0 OR time_right_ms > 0 OR time_ams > 0 OR time_bms > 0 OR id IN (?)",
+			tx.Model(&QuizResponse{}).Select("session_id")).
+		Updates(map[string]interface{}{"status": StatusCompleted, "completed_at": gorm.Expr("created_at")}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&StudySession{}).Where(unset).Update("status", StatusAbandoned).Error
+}
+
+// fixationRunMaxGap is the column migration 7 adds to fixation_runs
+type fixationRunMaxGap struct {
+	MaxGapMS int
+}
+
+func (fixationRunMaxGap) TableName() string { return "fixation_runs" }
+
+// addFixationRunMaxGap records the gap limit of each fixation run. Earlier
+// runs get 0, no limit, which is how they were computed.
+func addFixationRunMaxGap(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&fixationRunMaxGap{}, "MaxGapMS") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&fixationRunMaxGap{}, "MaxGapMS")
+}
+
+func dropFixationRunMaxGap(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&fixationRunMaxGap{}, "MaxGapMS")
+}
+
+// legacySessionColumns are the session summary fields superseded by the
+// calibration_data and quiz_responses tables
+var legacySessionColumns = []string{"CalibrationPoints", "QuizResponsesJSON"}
+
+// dropLegacySessionColumns removes the legacy session summary columns. Quiz
+// answers that only exist in quiz_responses_json are moved to quiz_responses
+// first. calibration_points is the only record of how many calibration clicks
+// sessions made before calibration data was linked to sessions, so it is kept
+// while any session has a value in it.
+func dropLegacySessionColumns(tx *gorm.DB) error {
+	if err := moveLegacyQuizResponses(tx); err != nil {
+		return err
+	}
+	for _, column := range legacySessionColumns {
+		if !tx.Migrator().HasColumn(&v1StudySession{}, column) {
+			continue
+		}
+		if column == "CalibrationPoints" {
+			var recorded int64
+			if err := tx.Model(&v1StudySession{}).Where("calibration_points > 0").Count(&recorded).Error; err != nil {
+				return err
+			}
+			if recorded > 0 {
+				log.Printf("Keeping study_sessions.calibration_points, which %d sessions recorded", recorded)
+				continue
+			}
+		}
+		if err := tx.Migrator().DropColumn(&v1StudySession{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreLegacySessionColumns adds the dropped legacy columns back, empty.
+// Answers moved out of quiz_responses_json stay in quiz_responses.
+func restoreLegacySessionColumns(tx *gorm.DB) error {
+	for _, column := range legacySessionColumns {
+		if tx.Migrator().HasColumn(&v1StudySession{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&v1StudySession{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyQuizAnswer is one entry of quiz_responses_json. The frontend sent the
+// chosen index as "answer", the model documented it as "answer_index".
+type legacyQuizAnswer struct {
+	QuestionID  string `json:"question_id"`
+	Answer      *int   `json:"answer"`
+	AnswerIndex *int   `json:"answer_index"`
+}
+
+// legacyQuizResponse is the quiz_responses row migration 8 writes for an answer
+// moved out of quiz_responses_json
+type legacyQuizResponse struct {
+	ID          uint
+	SessionID   uint
+	QuestionID  string
+	AnswerIndex int
+	IsCorrect   *bool
+	Timestamp   time.Time
+}
+
+func (legacyQuizResponse) TableName() string { return "quiz_responses" }
+
+// moveLegacyQuizResponses stores the answers in quiz_responses_json that have
+// no quiz_responses row for their session and question yet. They are stored
+// without is_correct, since grading needs the question revisions the api
+// package looks up; the server grades them when it starts. A session whose
+// JSON can't be read stops the migration, so no answers are lost.
+func moveLegacyQuizResponses(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&v1StudySession{}, "QuizResponsesJSON") {
+		return nil
+	}
+
+	var sessions []struct {
+		ID                uint
+		QuizResponsesJSON string
+		CreatedAt         time.Time
+		CompletedAt       *time.Time
+	}
+	err := tx.Model(&v1StudySession{}).Select("id, quiz_responses_json, created_at, completed_at").
+		Where("quiz_responses_json IS NOT NULL AND quiz_responses_json <> ''").Order("id ASC").Scan(&sessions).Error
+	if err != nil {
+		return err
+	}
+
+	moved := 0
+	for _, session := range sessions {
+		var answers []legacyQuizAnswer
+		if err := json.Unmarshal([]byte(session.QuizResponsesJSON), &answers); err != nil {
+			return fmt.Errorf("session %d: quiz_responses_json can't be read (%v); fix or clear it and migrate again", session.ID, err)
+		}
+
+		var answered []string
+		if err := tx.Model(&legacyQuizResponse{}).Where("session_id = ?", session.ID).Pluck("question_id", &answered).Error; err != nil {
+			return err
+		}
+		stored := make(map[string]bool, len(answered))
+		for _, questionID := range answered {
+			stored[questionID] = true
+		}
+
+		timestamp := session.CreatedAt
+		if session.CompletedAt != nil {
+			timestamp = *session.CompletedAt
+		}
+		for _, answer := range answers {
+			index := answer.AnswerIndex
+			if index == nil {
+				index = answer.Answer
+			}
+			if answer.QuestionID == "" || index == nil || stored[answer.QuestionID] {
+				continue
+			}
+			stored[answer.QuestionID] = true
+			response := legacyQuizResponse{SessionID: session.ID, QuestionID: answer.QuestionID, AnswerIndex: *index, Timestamp: timestamp}
+			if err := tx.Create(&response).Error; err != nil {
+				return err
+			}
+			moved++
+		}
+	}
+	if moved > 0 {
+		log.Printf("Moved %d quiz answers from quiz_responses_json to quiz_responses", moved)
+	}
+	return nil
+}
+
+// studySessionKeys are the study_sessions column and indexes migration 9 restores
+type studySessionKeys struct {
+	SessionID      string     `gorm:"uniqueIndex;not null"`
+	ParticipantID  uint       `gorm:"index"`
+	StudyTextID    *uint      `gorm:"index"`
+	ConditionIndex *int       `gorm:"index"`
+	Status         string     `gorm:"index"`
+	LastActivityAt *time.Time `gorm:"index"`
+	CompletionCode string     `gorm:"index"`
+}
+
+func (studySessionKeys) TableName() string { return "study_sessions" }
+
+// misplacedSessionConstraints were created on study_sessions by migration 1.
+// The Session fields of the per-session data models were read by GORM as
+// "has one" relations from each table to study_sessions, so session_id was
+// made to reference those tables' ids and given their integer type.
+var misplacedSessionConstraints = []string{
+	"fk_calibration_data_session",
+	"fk_accuracy_measurements_session",
+	"fk_quiz_responses_session",
+	"fk_gaze_points_session",
+	"fk_reading_events_session",
+}
+
+// fixSessionIDColumn drops the misplaced foreign keys, which stopped Postgres
+// from storing any session, and turns study_sessions.session_id back into
+// the text column it was meant to be. SQLite changes columns by copying the
+// table, which loses its indexes (as migration 8 already did), so missing
+// indexes are created again. There is nothing to undo.
+func fixSessionIDColumn(tx *gorm.DB) error {
+	for _, name := range misplacedSessionConstraints {
+		if !tx.Migrator().HasConstraint(&studySessionKeys{}, name) {
+			continue
+		}
+		if err := tx.Migrator().DropConstraint(&studySessionKeys{}, name); err != nil {
+			return err
+		}
+	}
+	if err := tx.Migrator().AlterColumn(&studySessionKeys{}, "SessionID"); err != nil {
+		return err
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(&studySessionKeys{}); err != nil {
+		return err
+	}
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if tx.Migrator().HasIndex(&studySessionKeys{}, index.Name) {
+			continue
+		}
+		if err := tx.Migrator().CreateIndex(&studySessionKeys{}, index.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,516 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Participant represents a study participant
+type Participant struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Source    string    `gorm:"index;uniqueIndex:idx_participant_external" json:"source"` // e.g., "mturk", "prolific", "internal", etc.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Recruitment platform IDs; a repeat visit with the same source and external ID reuses the participant
+	ExternalID        *string `gorm:"uniqueIndex:idx_participant_external" json:"external_id,omitempty"` // PROLIFIC_PID or MTurk workerId
+	ExternalStudyID   string  `json:"external_study_id,omitempty"`                                       // Prolific STUDY_ID or MTurk hitId
+	ExternalSessionID string  `json:"external_session_id,omitempty"`                                     // Prolific SESSION_ID or MTurk assignmentId of the latest visit
+
+	// Set when the participant's identifying data was removed on request (see api/erasure.go)
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+
+	// Relationships
+	StudySessions []StudySession `gorm:"foreignKey:ParticipantID;references:ID" json:"study_sessions,omitempty"`
+}
+
+// StudySession represents a complete study session
+type StudySession struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	SessionID      string    `gorm:"uniqueIndex;not null" json:"session_id"`
+	ParticipantID  uint      `gorm:"index" json:"participant_id"`
+	StudyTextID    *uint     `gorm:"index" json:"study_text_id,omitempty"`   // Study text shown in this session (nullable for older sessions)
+	ConditionIndex *int      `gorm:"index" json:"condition_index,omitempty"` // Counterbalancing condition assigned at creation
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Lifecycle, advanced by the server as data arrives (see api/lifecycle.go)
+	Status          string     `gorm:"index" json:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	LastActivityAt  *time.Time `gorm:"index" json:"last_activity_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CompletionCode  string     `gorm:"index" json:"completion_code,omitempty"` // Code issued on completion, verified by researchers
+
+	// Relationships
+	Participant          Participant           `gorm:"foreignKey:ParticipantID;references:ID" json:"participant,omitempty"`
+	CalibrationData      []CalibrationData     `gorm:"foreignKey:SessionID;references:ID" json:"calibration_data,omitempty"`
+	AccuracyMeasurements []AccuracyMeasurement `gorm:"foreignKey:SessionID;references:ID" json:"accuracy_measurements,omitempty"`
+	QuizResponses        []QuizResponse        `gorm:"foreignKey:SessionID;references:ID" json:"quiz_responses,omitempty"`
+	GazePoints           []GazePoint           `gorm:"foreignKey:SessionID;references:ID" json:"gaze_points,omitempty"`
+	ReadingEvents        []ReadingEvent        `gorm:"foreignKey:SessionID;references:ID" json:"reading_events,omitempty"`
+	Conditions           []SessionCondition    `gorm:"foreignKey:SessionID;references:ID" json:"conditions,omitempty"`
+
+	// Reading session data
+	FontLeft          string `json:"font_left"`           // Font name, e.g. "georgia" (legacy: "serif" or "sans")
+	FontRight         string `json:"font_right"`          // Font name, e.g. "inter" (legacy: "serif" or "sans")
+	TimeLeftMS        int    `json:"time_left_ms"`        // reading time for left side
+	TimeRightMS       int    `json:"time_right_ms"`       // reading time for right side
+	TimeAMS           int    `json:"time_a_ms"`           // reading time for box A
+	TimeBMS           int    `json:"time_b_ms"`           // reading time for box B
+	FontPreference    string `json:"font_preference"`     // "A" or "B"
+	PreferredFontType string `json:"preferred_font_type"` // Preferred font name (legacy: "serif" or "sans")
+
+	// Additional metadata
+	UserAgent    string `json:"user_agent,omitempty"`
+	ScreenWidth  int    `json:"screen_width,omitempty"`
+	ScreenHeight int    `json:"screen_height,omitempty"`
+}
+
+// BeforeCreate hook to generate session ID if not provided
+func (s *StudySession) BeforeCreate(tx *gorm.DB) error {
+	if s.SessionID == "" {
+		s.SessionID = generateSessionID()
+	}
+	if s.Status == "" {
+		s.Status = StatusCreated
+	}
+	return nil
+}
+
+// CalibrationData represents individual calibration point clicks
+type CalibrationData struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	SessionID   uint      `gorm:"index;not null" json:"session_id"`
+	PointIndex  int       `gorm:"not null" json:"point_index"`  // Which calibration point (0-based)
+	ClickNumber int       `gorm:"not null" json:"click_number"` // Which click on this point (1-5)
+	X           float64   `gorm:"not null" json:"x"`            // X coordinate of calibration point
+	Y           float64   `gorm:"not null" json:"y"`            // Y coordinate of calibration point
+	Timestamp   time.Time `gorm:"not null" json:"timestamp"`
+}
+
+// AccuracyMeasurement represents accuracy check results
+type AccuracyMeasurement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	Accuracy  float64   `gorm:"not null" json:"accuracy"` // Accuracy percentage
+	Duration  int       `gorm:"not null" json:"duration"` // Measurement duration in milliseconds
+	Passed    bool      `gorm:"not null" json:"passed"`   // Whether it passed the threshold
+	Timestamp time.Time `gorm:"not null" json:"timestamp"`
+}
+
+// QuizResponse represents an individual quiz answer
+type QuizResponse struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	SessionID          uint      `gorm:"index;not null" json:"session_id"`
+	QuestionID         string    `gorm:"not null" json:"question_id"`                 // e.g., "q1", "q2"
+	PassageID          *uint     `gorm:"index" json:"passage_id,omitempty"`           // Passage the question belongs to (optional, disambiguates question_id)
+	QuestionRevisionID *uint     `gorm:"index" json:"question_revision_id,omitempty"` // QuizQuestionRevision answered; graded against it
+	AnswerIndex        int       `gorm:"not null" json:"answer_index"`                // Selected answer index (0-based)
+	IsCorrect          *bool     `json:"is_correct,omitempty"`                        // Whether answer is correct, graded server-side (nullable)
+	ResponseTime       int       `json:"response_time,omitempty"`                     // Time to answer in milliseconds (optional)
+	Timestamp          time.Time `gorm:"not null" json:"timestamp"`
+}
+
+// GazePoint represents a single gaze tracking data point
+type GazePoint struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	X         float64   `gorm:"not null" json:"x"` // X coordinate
+	Y         float64   `gorm:"not null" json:"y"` // Y coordinate
+	Panel     string    `json:"panel,omitempty"`   // "A", "B", "left", "right", or empty
+	Phase     string    `json:"phase,omitempty"`   // "start", "middle", "end", or empty
+	Timestamp time.Time `gorm:"not null" json:"timestamp"`
+	WordAOIID *uint     `gorm:"index" json:"word_aoi_id,omitempty"` // Word the point falls on (set by AOI assignment)
+	LineAOIID *uint     `gorm:"index" json:"line_aoi_id,omitempty"` // Line the point falls on (set by AOI assignment)
+}
+
+// ReadingEvent represents reading session milestones
+type ReadingEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	EventType string    `gorm:"not null" json:"event_type"` // "start", "pause", "resume", "complete"
+	Panel     string    `gorm:"not null" json:"panel"`      // "A", "B", "left", "right"
+	Duration  int       `json:"duration,omitempty"`         // Duration in milliseconds (for complete events)
+	Timestamp time.Time `gorm:"not null" json:"timestamp"`
+}
+
+// Font is a typeface that passages can be rendered in
+type Font struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"` // e.g. "georgia", matches FontName in the frontend
+	DisplayName string    `gorm:"not null" json:"display_name"`     // e.g. "Georgia"
+	Family      string    `gorm:"not null" json:"family"`           // CSS font-family stack
+	Category    string    `gorm:"index;not null" json:"category"`   // "serif" or "sans-serif"
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StudyText represents a reading passage for the study
+type StudyText struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Version        string     `gorm:"uniqueIndex;not null" json:"version"`   // e.g., "v1", "default"
+	Content        string     `gorm:"type:text" json:"content,omitempty"`    // Legacy: single passage (deprecated, use Passages instead)
+	FontLeft       string     `gorm:"default:serif" json:"font_left"`        // Font name for left panel (legacy: "serif" or "sans")
+	FontRight      string     `gorm:"default:sans" json:"font_right"`        // Font name for right panel (legacy: "serif" or "sans")
+	Active         bool       `gorm:"default:true" json:"active"`            // Whether this is the active version
+	Status         string     `gorm:"index;default:published" json:"status"` // draft or published; only drafts can be edited (see api/studytexts.go)
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	ClonedFromID   *uint      `json:"cloned_from_id,omitempty"`  // Study text this draft was cloned from
+	CompletionCode string     `json:"completion_code,omitempty"` // Fixed code shown on completion (e.g. Prolific's); empty issues a random code per session
+	CompletionURL  string     `json:"completion_url,omitempty"`  // Redirect after completion, with placeholders like {code} (see api/recruitment.go)
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	QuizQuestions []QuizQuestion `gorm:"foreignKey:StudyTextID;references:ID" json:"quiz_questions,omitempty"`
+	Passages      []Passage      `gorm:"foreignKey:StudyTextID;references:ID;order:order ASC" json:"passages,omitempty"`
+}
+
+// Passage represents a single reading passage within a study text
+type Passage struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StudyTextID uint      `gorm:"index;not null" json:"study_text_id"`
+	Order       int       `gorm:"not null" json:"order"`                    // Display order (0, 1, 2, ...)
+	Content     string    `gorm:"type:text;not null" json:"content"`        // The passage text
+	Title       string    `json:"title,omitempty"`                          // Optional title for the passage
+	FontLeft    string    `gorm:"default:serif" json:"font_left,omitempty"` // Font name for left panel (optional, falls back to StudyText)
+	FontRight   string    `gorm:"default:sans" json:"font_right,omitempty"` // Font name for right panel (optional, falls back to StudyText)
+	Revision    int       `json:"revision"`                                 // Number of the current revision (see revisions.go)
+	RevisionID  *uint     `gorm:"index" json:"revision_id,omitempty"`       // Current PassageRevision
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	StudyText     StudyText      `gorm:"foreignKey:StudyTextID;references:ID" json:"study_text,omitempty"`
+	QuizQuestions []QuizQuestion `gorm:"foreignKey:PassageID;references:ID" json:"quiz_questions,omitempty"`
+}
+
+// PassageRevision is an immutable copy of a passage's content. Every edit of a
+// passage adds a revision; sessions reference the revision they were shown.
+type PassageRevision struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PassageID   uint      `gorm:"uniqueIndex:idx_passage_revision;not null" json:"passage_id"`
+	Revision    int       `gorm:"uniqueIndex:idx_passage_revision;not null" json:"revision"` // 1, 2, ... per passage
+	StudyTextID uint      `gorm:"index;not null" json:"study_text_id"`
+	Order       int       `json:"order"`
+	Content     string    `gorm:"type:text;not null" json:"content"`
+	Title       string    `json:"title,omitempty"`
+	FontLeft    string    `json:"font_left,omitempty"`
+	FontRight   string    `json:"font_right,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"` // Admin username, or "cli" / "backfill"
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BeforeUpdate keeps revisions from being changed
+func (r *PassageRevision) BeforeUpdate(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// BeforeDelete keeps revisions from being removed
+func (r *PassageRevision) BeforeDelete(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// QuizQuestion represents a quiz question for a study text or passage
+type QuizQuestion struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StudyTextID uint      `gorm:"index;not null" json:"study_text_id"`
+	PassageID   *uint     `gorm:"index" json:"passage_id,omitempty"` // Optional: link to specific passage (nullable for backward compatibility)
+	QuestionID  string    `gorm:"not null" json:"question_id"`       // e.g., "q1", "q2"
+	Prompt      string    `gorm:"type:text;not null" json:"prompt"`
+	Choices     string    `gorm:"type:text;not null" json:"choices"`  // JSON array of choices
+	Answer      int       `gorm:"not null" json:"answer"`             // Index of correct answer (0-based)
+	Order       int       `gorm:"default:0" json:"order"`             // Display order
+	Revision    int       `json:"revision"`                           // Number of the current revision (see revisions.go)
+	RevisionID  *uint     `gorm:"index" json:"revision_id,omitempty"` // Current QuizQuestionRevision
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	StudyText StudyText `gorm:"foreignKey:StudyTextID;references:ID" json:"study_text,omitempty"`
+	Passage   *Passage  `gorm:"foreignKey:PassageID;references:ID" json:"passage,omitempty"`
+}
+
+// QuizQuestionRevision is an immutable copy of a quiz question. Every edit adds
+// a revision; quiz responses reference and are graded against the revision answered.
+type QuizQuestionRevision struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	QuizQuestionID uint      `gorm:"uniqueIndex:idx_quiz_question_revision;not null" json:"quiz_question_id"`
+	Revision       int       `gorm:"uniqueIndex:idx_quiz_question_revision;not null" json:"revision"` // 1, 2, ... per question
+	StudyTextID    uint      `gorm:"index;not null" json:"study_text_id"`
+	PassageID      *uint     `json:"passage_id,omitempty"`
+	QuestionID     string    `gorm:"not null" json:"question_id"`
+	Prompt         string    `gorm:"type:text;not null" json:"prompt"`
+	Choices        string    `gorm:"type:text;not null" json:"choices"` // JSON array of choices
+	Answer         int       `json:"answer"`
+	Order          int       `json:"order"`
+	CreatedBy      string    `json:"created_by,omitempty"` // Admin username, or "cli" / "backfill"
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BeforeUpdate keeps revisions from being changed
+func (r *QuizQuestionRevision) BeforeUpdate(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// BeforeDelete keeps revisions from being removed
+func (r *QuizQuestionRevision) BeforeDelete(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// AdminUser is an account that can access the /api/admin routes
+type AdminUser struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Username     string     `gorm:"uniqueIndex;not null" json:"username"`
+	PasswordHash string     `gorm:"not null" json:"-"`                   // bcrypt hash
+	Role         string     `gorm:"not null;default:viewer" json:"role"` // "viewer" or "editor"
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// FixationRun records one fixation/saccade detection pass over a session's gaze points
+type FixationRun struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	SessionID           uint      `gorm:"index;not null" json:"session_id"`
+	Algorithm           string    `gorm:"not null" json:"algorithm"`      // "ivt" or "idt"
+	VelocityThreshold   float64   `json:"velocity_threshold,omitempty"`   // px/s (I-VT only)
+	DispersionThreshold float64   `json:"dispersion_threshold,omitempty"` // px (I-DT only)
+	MinFixationMS       int       `json:"min_fixation_ms"`                // Minimum fixation duration
+	MaxGapMS            int       `json:"max_gap_ms"`                     // Longest sample gap within a fixation; 0 for no limit
+	SampleCount         int       `json:"sample_count"`                   // Gaze points analyzed
+	CreatedAt           time.Time `json:"created_at"`
+
+	// Relationships
+	Fixations []Fixation `gorm:"foreignKey:RunID;references:ID" json:"fixations,omitempty"`
+	Saccades  []Saccade  `gorm:"foreignKey:RunID;references:ID" json:"saccades,omitempty"`
+}
+
+// Fixation is a detected fixation within a session's gaze data
+type Fixation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RunID       uint      `gorm:"index;not null" json:"run_id"`
+	SessionID   uint      `gorm:"index;not null" json:"session_id"`
+	StartTime   time.Time `gorm:"not null" json:"start_time"`
+	EndTime     time.Time `gorm:"not null" json:"end_time"`
+	DurationMS  int       `gorm:"not null" json:"duration_ms"`
+	X           float64   `gorm:"not null" json:"x"` // Centroid X
+	Y           float64   `gorm:"not null" json:"y"` // Centroid Y
+	Panel       string    `json:"panel,omitempty"`   // Most common panel among the fixation's samples
+	SampleCount int       `json:"sample_count"`
+	WordAOIID   *uint     `gorm:"index" json:"word_aoi_id,omitempty"` // Word the fixation falls on (set by AOI assignment)
+	LineAOIID   *uint     `gorm:"index" json:"line_aoi_id,omitempty"` // Line the fixation falls on (set by AOI assignment)
+}
+
+// Saccade is a detected movement between two consecutive fixations
+type Saccade struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RunID      uint      `gorm:"index;not null" json:"run_id"`
+	SessionID  uint      `gorm:"index;not null" json:"session_id"`
+	StartTime  time.Time `gorm:"not null" json:"start_time"`
+	EndTime    time.Time `gorm:"not null" json:"end_time"`
+	DurationMS int       `gorm:"not null" json:"duration_ms"`
+	StartX     float64   `json:"start_x"`
+	StartY     float64   `json:"start_y"`
+	EndX       float64   `json:"end_x"`
+	EndY       float64   `json:"end_y"`
+	Amplitude  float64   `json:"amplitude"` // Pixels between fixation centroids
+	Velocity   float64   `json:"velocity"`  // Mean velocity in px/s
+	FromPanel  string    `json:"from_panel,omitempty"`
+	ToPanel    string    `json:"to_panel,omitempty"`
+}
+
+// AOI is the on-screen bounding box of a word or line of a passage, as rendered
+// in one panel of one session
+type AOI struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	PassageID uint      `gorm:"index;not null" json:"passage_id"`
+	Panel     string    `gorm:"not null" json:"panel"` // "A", "B", "left", "right"
+	Font      string    `json:"font,omitempty"`        // Font the passage was rendered in, e.g. "georgia"
+	Kind      string    `gorm:"not null" json:"kind"`  // "word" or "line"
+	Index     int       `gorm:"not null" json:"index"` // Word index in the passage, or line index
+	LineIndex int       `json:"line_index"`            // Line a word sits on (equals Index for lines)
+	Text      string    `json:"text,omitempty"`        // The word, or the line's text
+	X         float64   `gorm:"not null" json:"x"`     // Left edge in viewport pixels
+	Y         float64   `gorm:"not null" json:"y"`     // Top edge in viewport pixels
+	Width     float64   `gorm:"not null" json:"width"`
+	Height    float64   `gorm:"not null" json:"height"`
+	ShownAt   time.Time `gorm:"not null" json:"shown_at"` // When this layout appeared on screen
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionCondition is the counterbalanced presentation of one passage in a session:
+// when it is shown and which font appears on each side
+type SessionCondition struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	SessionID         uint      `gorm:"index;not null" json:"session_id"`
+	PassageID         uint      `gorm:"index;not null" json:"passage_id"`
+	PassageRevisionID *uint     `gorm:"index" json:"passage_revision_id,omitempty"` // PassageRevision shown in this session
+	Position          int       `gorm:"not null" json:"position"`                   // 0-based order in which the passage is shown
+	FontLeft          string    `gorm:"not null" json:"font_left"`
+	FontRight         string    `gorm:"not null" json:"font_right"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// FontComparison is one pairwise font preference made by a participant
+type FontComparison struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID uint      `gorm:"index;not null" json:"session_id"`
+	PassageID *uint     `gorm:"index" json:"passage_id,omitempty"` // Passage shown during the comparison (optional)
+	FontA     string    `gorm:"not null" json:"font_a"`            // Font name shown in panel A
+	FontB     string    `gorm:"not null" json:"font_b"`            // Font name shown in panel B
+	Preferred string    `gorm:"not null" json:"preferred"`         // Font name the participant chose (FontA or FontB)
+	Round     int       `json:"round,omitempty"`                   // Tournament round, as reported by the client
+	Bracket   string    `json:"bracket,omitempty"`                 // Tournament bracket, as reported by the client
+	TimeAMS   int       `json:"time_a_ms,omitempty"`               // Reading time for panel A
+	TimeBMS   int       `json:"time_b_ms,omitempty"`               // Reading time for panel B
+	Timestamp time.Time `gorm:"not null" json:"timestamp"`
+}
+
+// SessionQuality is the data quality evaluation of a session and the resulting
+// decision on whether it is included in analyses
+type SessionQuality struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	SessionID    uint      `gorm:"uniqueIndex;not null" json:"session_id"`
+	Score        float64   `json:"score"`              // Share of applicable checks that passed (0-1)
+	AutoIncluded bool      `json:"auto_included"`      // Decision from the quality checks
+	Override     *bool     `json:"override,omitempty"` // Manual decision by an admin, takes precedence when set
+	OverrideNote string    `json:"override_note,omitempty"`
+	Included     bool      `gorm:"index" json:"included"` // Effective decision: Override if set, otherwise AutoIncluded
+	Reasons      string    `gorm:"type:text" json:"-"`    // JSON array of failed check reasons
+	Checks       string    `gorm:"type:text" json:"-"`    // JSON array of check results
+	Thresholds   string    `gorm:"type:text" json:"-"`    // JSON thresholds the checks were run with
+	EvaluatedAt  time.Time `gorm:"not null" json:"evaluated_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ConsentDocument is one version of the informed consent text shown to participants.
+// Exactly one version is active; accepted versions are never edited, a new version is added instead.
+type ConsentDocument struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Version   string    `gorm:"uniqueIndex;not null" json:"version"`
+	Title     string    `json:"title"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	Active    bool      `gorm:"index" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConsentRecord is a participant's acceptance of a consent document version
+type ConsentRecord struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ParticipantID     uint      `gorm:"uniqueIndex:idx_consent_participant_document;not null" json:"participant_id"`
+	ConsentDocumentID uint      `gorm:"uniqueIndex:idx_consent_participant_document;not null" json:"consent_document_id"`
+	Version           string    `gorm:"not null" json:"version"` // Copied from the document for readable exports
+	AcceptedAt        time.Time `gorm:"not null" json:"accepted_at"`
+	UserAgent         string    `json:"user_agent,omitempty"`
+}
+
+// DemographicsSchema is one version of the demographics questionnaire.
+// Like consent documents, one version is active and answered versions are not edited.
+type DemographicsSchema struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Version   string    `gorm:"uniqueIndex;not null" json:"version"`
+	Fields    string    `gorm:"type:text;not null" json:"-"` // JSON array of questionnaire fields (see api/demographics.go)
+	Active    bool      `gorm:"index" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DemographicsResponse holds a participant's answers to the demographics questionnaire
+type DemographicsResponse struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	ParticipantID        uint      `gorm:"uniqueIndex;not null" json:"participant_id"` // Latest answers replace earlier ones
+	DemographicsSchemaID uint      `gorm:"index;not null" json:"demographics_schema_id"`
+	Version              string    `gorm:"not null" json:"version"`
+	Answers              string    `gorm:"type:text;not null" json:"-"` // JSON object keyed by field key
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// ErasureRecord is the audit trail of a participant erasure or a retention purge.
+// It records what was removed, never the removed data itself.
+type ErasureRecord struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Action        string    `gorm:"index;not null" json:"action"`          // "delete", "anonymize" or "retention"
+	ParticipantID *uint     `gorm:"index" json:"participant_id,omitempty"` // Empty for retention purges
+	Sessions      int       `json:"sessions"`                              // Sessions deleted or anonymized
+	Rows          string    `gorm:"type:text" json:"-"`                    // JSON object of affected rows per table
+	Reason        string    `json:"reason,omitempty"`
+	RequestedBy   string    `gorm:"not null" json:"requested_by"` // Admin username, or "cli" / "retention"
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AuditEntry records one admin write: who changed which entity, and its JSON
+// before and after the change. Entries are never updated or deleted.
+type AuditEntry struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Actor     string    `gorm:"index;not null" json:"actor"` // Admin username, or "cli"
+	Action    string    `gorm:"index;not null" json:"action"`
+	Entity    string    `gorm:"index:idx_audit_entity;not null" json:"entity"`
+	EntityID  uint      `gorm:"index:idx_audit_entity" json:"entity_id,omitempty"` // 0 for actions on many rows
+	Before    string    `gorm:"type:text" json:"-"`                                // JSON, empty for creations
+	After     string    `gorm:"type:text" json:"-"`                                // JSON, empty for deletions
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// BeforeUpdate keeps audit entries from being changed
+func (e *AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// BeforeDelete keeps audit entries from being removed
+func (e *AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// Session statuses, in the order a session normally moves through them
+const (
+	StatusCreated         = "created"
+	StatusCalibrated      = "calibrated"       // calibration clicks are arriving
+	StatusAccuracyChecked = "accuracy_checked" // an accuracy measurement was taken
+	StatusReading         = "reading"          // gaze points, layouts, reading events and font comparisons are arriving
+	StatusQuiz            = "quiz"             // quiz answers are arriving
+	StatusCompleted       = "completed"
+	StatusAbandoned       = "abandoned" // set by the idle sweeper
+)
+
+// Study text statuses. Drafts can be edited and previewed but are never shown
+// to participants; published texts are frozen and only their active flag changes.
+const (
+	StudyTextDraft     = "draft"
+	StudyTextPublished = "published"
+)
+
+func generateSessionID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		// Fallback to timestamp-based ID if crypto/rand fails
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
+}
+
+var ErrRevisionImmutable = errors.New("revisions are immutable; edit the passage or question to add a new revision")
+
+var ErrAuditAppendOnly = errors.New("audit entries are append-only")
+
+// Demographics field types
+const (
+	FieldChoice = "choice" // one of Options
+	FieldNumber = "number" // between Min and Max when set
+	FieldText   = "text"   // free text up to MaxLength characters
+)
+
+// DemographicsField is one question of a demographics questionnaire, stored as JSON
+type DemographicsField struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by repositories when the requested record doesn't exist
+var ErrNotFound = errors.New("record not found")
+
+// Repository stores and loads the entities of the participant flow and the
+// study content, so the handlers working with them don't build queries
+// themselves. Each backend returns its repository from Store.Repository.
+type Repository interface {
+	Participants() ParticipantRepository
+	Sessions() SessionRepository
+	SessionData() SessionDataRepository
+	StudyTexts() StudyTextRepository
+	Passages() PassageRepository
+	QuizQuestions() QuizQuestionRepository
+	Fonts() FontRepository
+	Consent() ConsentRepository
+	Demographics() DemographicsRepository
+	Audit() AuditRepository
+
+	// Transaction runs fn with a repository whose calls all belong to one
+	// transaction, which commits if fn returns nil and rolls back otherwise
+	Transaction(fn func(Repository) error) error
+}
+
+// ParticipantRepository stores participants
+type ParticipantRepository interface {
+	Create(participant *Participant) error
+	Get(id uint) (Participant, error)
+	// FindExternal looks a participant up by recruitment platform and the platform's participant ID
+	FindExternal(source, externalID string) (Participant, error)
+	// UpdateVisit records the platform's study and session IDs of a repeat
+	// visit; empty values leave the stored ones alone
+	UpdateVisit(participant *Participant, externalStudyID, externalSessionID string) error
+}
+
+// SessionRepository stores study sessions, their counterbalanced conditions
+// and their lifecycle status
+type SessionRepository interface {
+	Create(session *StudySession) error
+	AddConditions(conditions []SessionCondition) error
+	Get(id uint) (StudySession, error)
+	// MoveStatus records activity at time at on a session that is still in
+	// status from and moves it to status to, stamping status_changed_at (and
+	// completed_at for StatusCompleted) when the two differ. It reports false
+	// if the session was no longer in status from.
+	MoveStatus(id uint, from, to string, at time.Time) (bool, error)
+	// SaveSummary stores the summary fields the client collected; zero values are skipped
+	SaveSummary(id uint, summary StudySession) error
+	SetCompletionCode(id uint, code string) error
+	// LatestCompleted returns the participant's most recently completed session
+	LatestCompleted(participantID uint) (StudySession, error)
+	// CountCompleted counts the participant's completed sessions other than exceptID
+	CountCompleted(participantID, exceptID uint) (int64, error)
+	// ByCompletionCode lists the completed sessions with a completion code,
+	// compared case-insensitively, oldest first with their participants. A
+	// non-empty externalID restricts them to that platform participant.
+	ByCompletionCode(code, externalID string) ([]StudySession, error)
+	// ConditionUsage counts the sessions of a study text per condition index
+	ConditionUsage(studyTextID uint) (map[int]int64, error)
+	// Idle lists the ID and status of unfinished sessions without activity since cutoff
+	Idle(cutoff time.Time) ([]StudySession, error)
+	// Abandon marks an idle session abandoned at time at, unless it became
+	// active since cutoff or changed status in the meantime
+	Abandon(session StudySession, cutoff, at time.Time) (bool, error)
+}
+
+// SessionDataRepository stores the data a session collects
+type SessionDataRepository interface {
+	AddQuizResponse(response *QuizResponse) error
+	AddCalibration(calibration *CalibrationData) error
+	AddGazePoints(points []GazePoint) error
+	AddReadingEvent(event *ReadingEvent) error
+	AddAccuracy(accuracy *AccuracyMeasurement) error
+	AddFontComparison(comparison *FontComparison) error
+	// ReplaceAOIs swaps the AOIs of one passage in one panel of a session for aois
+	ReplaceAOIs(sessionID, passageID uint, panel string, aois []AOI) error
+}
+
+// StudyTextRepository stores study texts
+type StudyTextRepository interface {
+	Create(studyText *StudyText) error
+	Save(studyText *StudyText) error
+	Get(id uint) (StudyText, error)
+	// GetForUpdate loads a study text inside a transaction and keeps other
+	// transactions from changing it until this one ends
+	GetForUpdate(id uint) (StudyText, error)
+	GetByVersion(version string) (StudyText, error)
+	// Active returns the study text participants are shown by default
+	Active() (StudyText, error)
+	// List returns every study text, newest first
+	List() ([]StudyText, error)
+	SetActive(id uint, active bool) error
+	// DeactivateOthers clears the active flag of every study text but id
+	DeactivateOthers(id uint) error
+}
+
+// PassageRepository stores passages and their revisions
+type PassageRepository interface {
+	Create(passage *Passage) error
+	Save(passage *Passage) error
+	Delete(passage *Passage) error
+	Get(id uint) (Passage, error)
+	// GetForUpdate loads a passage like StudyTextRepository.GetForUpdate
+	GetForUpdate(id uint) (Passage, error)
+	// GetInStudyText loads a passage only if it belongs to the study text
+	GetInStudyText(id, studyTextID uint) (Passage, error)
+	// ForStudyText lists the passages of a study text in display order
+	ForStudyText(studyTextID uint) ([]Passage, error)
+	Count(studyTextID uint) (int64, error)
+	// NextPosition returns the display order after the study text's last passage
+	NextPosition(studyTextID uint) (int, error)
+	// Revise records the passage's current content as a revision, see RevisePassage
+	Revise(passage *Passage, actor string) error
+}
+
+// QuizQuestionRepository stores quiz questions and their revisions
+type QuizQuestionRepository interface {
+	Create(question *QuizQuestion) error
+	Save(question *QuizQuestion) error
+	Delete(question *QuizQuestion) error
+	Get(id uint) (QuizQuestion, error)
+	// GetForUpdate loads a question like StudyTextRepository.GetForUpdate
+	GetForUpdate(id uint) (QuizQuestion, error)
+	// ForStudyText lists every question of a study text, passage questions included, in display order
+	ForStudyText(studyTextID uint) ([]QuizQuestion, error)
+	// ForPassage lists the questions shown after a passage in display order
+	ForPassage(passageID uint) ([]QuizQuestion, error)
+	// PublishedForStudyText lists the questions of a published study text
+	// that aren't tied to a passage, in display order
+	PublishedForStudyText(studyTextID uint) ([]QuizQuestion, error)
+	// PublishedForPassage lists the questions of a passage of a published study text
+	PublishedForPassage(passageID uint) ([]QuizQuestion, error)
+	// Find looks a question up by its question_id within a study text.
+	// Question IDs are only unique per passage, so with passageID the
+	// passage's question is returned; without it study-text-level questions
+	// come first.
+	Find(studyTextID uint, questionID string, passageID *uint) (QuizQuestion, error)
+	// RevisionAt returns the question's latest revision created at or before
+	// at, or its first revision if all of them are newer
+	RevisionAt(quizQuestionID uint, at time.Time) (QuizQuestionRevision, error)
+	// Revise records the question's current content as a revision, see ReviseQuizQuestion
+	Revise(question *QuizQuestion, actor string) error
+}
+
+// FontRepository lists the fonts of the study's pool
+type FontRepository interface {
+	// List returns every font by category and name
+	List() ([]Font, error)
+}
+
+// ConsentRepository stores the consent participants give
+type ConsentRepository interface {
+	// ActiveDocument returns the consent document participants must accept
+	ActiveDocument() (ConsentDocument, error)
+	GetDocument(id uint) (ConsentDocument, error)
+	GetDocumentByVersion(version string) (ConsentDocument, error)
+	HasAccepted(participantID, documentID uint) (bool, error)
+	// GetRecord returns the participant's acceptance of a consent document
+	GetRecord(participantID, documentID uint) (ConsentRecord, error)
+	AddRecord(record *ConsentRecord) error
+}
+
+// DemographicsRepository stores the participants' demographics answers
+type DemographicsRepository interface {
+	// ActiveSchema returns the questionnaire participants are asked to fill in
+	ActiveSchema() (DemographicsSchema, error)
+	// GetResponse returns the participant's answers
+	GetResponse(participantID uint) (DemographicsResponse, error)
+	// SaveResponse inserts the answers, or replaces them if they have an ID
+	SaveResponse(response *DemographicsResponse) error
+}
+
+// AuditRepository appends to the audit log
+type AuditRepository interface {
+	Add(entry *AuditEntry) error
+}
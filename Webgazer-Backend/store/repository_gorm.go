@@ -0,0 +1,469 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormRepository is the Repository of both backends. Rows read for update are
+// only locked where the database supports it: PostgreSQL takes row locks,
+// while SQLite has a single writer and needs none.
+type gormRepository struct {
+	db       *gorm.DB
+	rowLocks bool
+}
+
+func (r gormRepository) Participants() ParticipantRepository   { return gormParticipants(r) }
+func (r gormRepository) Sessions() SessionRepository           { return gormSessions(r) }
+func (r gormRepository) SessionData() SessionDataRepository    { return gormSessionData(r) }
+func (r gormRepository) StudyTexts() StudyTextRepository       { return gormStudyTexts(r) }
+func (r gormRepository) Passages() PassageRepository           { return gormPassages(r) }
+func (r gormRepository) QuizQuestions() QuizQuestionRepository { return gormQuizQuestions(r) }
+func (r gormRepository) Fonts() FontRepository                 { return gormFonts(r) }
+func (r gormRepository) Consent() ConsentRepository            { return gormConsent(r) }
+func (r gormRepository) Demographics() DemographicsRepository  { return gormDemographics(r) }
+func (r gormRepository) Audit() AuditRepository                { return gormAudit(r) }
+
+func (r gormRepository) Transaction(fn func(Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(gormRepository{db: tx, rowLocks: r.rowLocks})
+	})
+}
+
+// forUpdate locks the rows read through the returned handle until the transaction ends
+func (r gormRepository) forUpdate() *gorm.DB {
+	if r.rowLocks {
+		return r.db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return r.db
+}
+
+// findOne loads the first row query matches into dest, or returns ErrNotFound
+func findOne(query *gorm.DB, dest interface{}, conds ...interface{}) error {
+	found := query.Limit(1).Find(dest, conds...)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type gormParticipants gormRepository
+
+func (r gormParticipants) Create(participant *Participant) error {
+	return r.db.Create(participant).Error
+}
+
+func (r gormParticipants) Get(id uint) (Participant, error) {
+	var participant Participant
+	return participant, findOne(r.db, &participant, id)
+}
+
+func (r gormParticipants) FindExternal(source, externalID string) (Participant, error) {
+	var participant Participant
+	return participant, findOne(r.db.Where("source = ? AND external_id = ?", source, externalID), &participant)
+}
+
+func (r gormParticipants) UpdateVisit(participant *Participant, externalStudyID, externalSessionID string) error {
+	updates := map[string]interface{}{}
+	if externalStudyID != "" {
+		updates["external_study_id"] = externalStudyID
+	}
+	if externalSessionID != "" {
+		updates["external_session_id"] = externalSessionID
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.db.Model(participant).Updates(updates).Error
+}
+
+type gormSessions gormRepository
+
+func (r gormSessions) Create(session *StudySession) error {
+	return r.db.Create(session).Error
+}
+
+func (r gormSessions) AddConditions(conditions []SessionCondition) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+	return r.db.Create(&conditions).Error
+}
+
+func (r gormSessions) Get(id uint) (StudySession, error) {
+	var session StudySession
+	return session, findOne(r.db, &session, id)
+}
+
+func (r gormSessions) MoveStatus(id uint, from, to string, at time.Time) (bool, error) {
+	updates := map[string]interface{}{"last_activity_at": at}
+	if from != to {
+		updates["status"] = to
+		updates["status_changed_at"] = at
+		if to == StatusCompleted {
+			updates["completed_at"] = at
+		}
+	}
+	result := r.db.Model(&StudySession{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r gormSessions) SaveSummary(id uint, summary StudySession) error {
+	return r.db.Model(&StudySession{ID: id}).Updates(StudySession{
+		FontLeft:          summary.FontLeft,
+		FontRight:         summary.FontRight,
+		TimeLeftMS:        summary.TimeLeftMS,
+		TimeRightMS:       summary.TimeRightMS,
+		TimeAMS:           summary.TimeAMS,
+		TimeBMS:           summary.TimeBMS,
+		FontPreference:    summary.FontPreference,
+		PreferredFontType: summary.PreferredFontType,
+	}).Error
+}
+
+func (r gormSessions) SetCompletionCode(id uint, code string) error {
+	return r.db.Model(&StudySession{ID: id}).Update("completion_code", code).Error
+}
+
+func (r gormSessions) LatestCompleted(participantID uint) (StudySession, error) {
+	var session StudySession
+	query := r.db.Where("participant_id = ? AND status = ?", participantID, StatusCompleted).Order("completed_at DESC")
+	return session, findOne(query, &session)
+}
+
+func (r gormSessions) CountCompleted(participantID, exceptID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&StudySession{}).
+		Where("participant_id = ? AND status = ? AND id <> ?", participantID, StatusCompleted, exceptID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r gormSessions) ByCompletionCode(code, externalID string) ([]StudySession, error) {
+	query := r.db.Where("UPPER(completion_code) = ? AND status = ?", strings.ToUpper(code), StatusCompleted)
+	if externalID != "" {
+		query = query.Where("participant_id IN (?)", r.db.Model(&Participant{}).Select("id").Where("external_id = ?", externalID))
+	}
+	var sessions []StudySession
+	err := query.Preload("Participant").Order("completed_at ASC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r gormSessions) ConditionUsage(studyTextID uint) (map[int]int64, error) {
+	var usage []struct {
+		ConditionIndex int
+		Count          int64
+	}
+	err := r.db.Model(&StudySession{}).Select("condition_index, COUNT(*) AS count").
+		Where("study_text_id = ? AND condition_index IS NOT NULL", studyTextID).
+		Group("condition_index").Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int64, len(usage))
+	for _, u := range usage {
+		counts[u.ConditionIndex] = u.Count
+	}
+	return counts, nil
+}
+
+// idle selects the unfinished sessions without activity since cutoff
+func (r gormSessions) idle(cutoff time.Time) *gorm.DB {
+	return r.db.Model(&StudySession{}).
+		Where("status NOT IN ?", []string{StatusCompleted, StatusAbandoned}).
+		Where("COALESCE(last_activity_at, created_at) < ?", cutoff)
+}
+
+func (r gormSessions) Idle(cutoff time.Time) ([]StudySession, error) {
+	var sessions []StudySession
+	err := r.idle(cutoff).Select("id, status").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r gormSessions) Abandon(session StudySession, cutoff, at time.Time) (bool, error) {
+	result := r.idle(cutoff).Where("id = ? AND status = ?", session.ID, session.Status).Updates(map[string]interface{}{
+		"status":            StatusAbandoned,
+		"status_changed_at": at,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+type gormSessionData gormRepository
+
+func (r gormSessionData) AddQuizResponse(response *QuizResponse) error {
+	return r.db.Create(response).Error
+}
+
+func (r gormSessionData) AddCalibration(calibration *CalibrationData) error {
+	return r.db.Create(calibration).Error
+}
+
+func (r gormSessionData) AddGazePoints(points []GazePoint) error {
+	return r.db.CreateInBatches(&points, 500).Error
+}
+
+func (r gormSessionData) AddReadingEvent(event *ReadingEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r gormSessionData) AddAccuracy(accuracy *AccuracyMeasurement) error {
+	return r.db.Create(accuracy).Error
+}
+
+func (r gormSessionData) AddFontComparison(comparison *FontComparison) error {
+	return r.db.Create(comparison).Error
+}
+
+func (r gormSessionData) ReplaceAOIs(sessionID, passageID uint, panel string, aois []AOI) error {
+	err := r.db.Where("session_id = ? AND passage_id = ? AND panel = ?", sessionID, passageID, panel).Delete(&AOI{}).Error
+	if err != nil || len(aois) == 0 {
+		return err
+	}
+	return r.db.CreateInBatches(&aois, 500).Error
+}
+
+type gormStudyTexts gormRepository
+
+func (r gormStudyTexts) Create(studyText *StudyText) error {
+	return r.db.Create(studyText).Error
+}
+
+func (r gormStudyTexts) Save(studyText *StudyText) error {
+	return r.db.Save(studyText).Error
+}
+
+func (r gormStudyTexts) Get(id uint) (StudyText, error) {
+	var studyText StudyText
+	return studyText, findOne(r.db, &studyText, id)
+}
+
+func (r gormStudyTexts) GetForUpdate(id uint) (StudyText, error) {
+	var studyText StudyText
+	return studyText, findOne(gormRepository(r).forUpdate(), &studyText, id)
+}
+
+func (r gormStudyTexts) GetByVersion(version string) (StudyText, error) {
+	var studyText StudyText
+	return studyText, findOne(r.db.Where("version = ?", version), &studyText)
+}
+
+func (r gormStudyTexts) Active() (StudyText, error) {
+	var studyText StudyText
+	return studyText, findOne(r.db.Where("active = ?", true).Order("id ASC"), &studyText)
+}
+
+func (r gormStudyTexts) List() ([]StudyText, error) {
+	var studyTexts []StudyText
+	err := r.db.Order("created_at DESC").Find(&studyTexts).Error
+	return studyTexts, err
+}
+
+func (r gormStudyTexts) SetActive(id uint, active bool) error {
+	return r.db.Model(&StudyText{ID: id}).Update("active", active).Error
+}
+
+func (r gormStudyTexts) DeactivateOthers(id uint) error {
+	return r.db.Model(&StudyText{}).Where("active = ? AND id != ?", true, id).Update("active", false).Error
+}
+
+type gormPassages gormRepository
+
+func (r gormPassages) Create(passage *Passage) error {
+	return r.db.Create(passage).Error
+}
+
+func (r gormPassages) Save(passage *Passage) error {
+	return r.db.Save(passage).Error
+}
+
+func (r gormPassages) Delete(passage *Passage) error {
+	return r.db.Delete(passage).Error
+}
+
+func (r gormPassages) Get(id uint) (Passage, error) {
+	var passage Passage
+	return passage, findOne(r.db, &passage, id)
+}
+
+func (r gormPassages) GetForUpdate(id uint) (Passage, error) {
+	var passage Passage
+	return passage, findOne(gormRepository(r).forUpdate(), &passage, id)
+}
+
+func (r gormPassages) GetInStudyText(id, studyTextID uint) (Passage, error) {
+	var passage Passage
+	return passage, findOne(r.db.Where("id = ? AND study_text_id = ?", id, studyTextID), &passage)
+}
+
+func (r gormPassages) ForStudyText(studyTextID uint) ([]Passage, error) {
+	var passages []Passage
+	err := r.db.Where("study_text_id = ?", studyTextID).Order(ByPosition).Order("id ASC").Find(&passages).Error
+	return passages, err
+}
+
+func (r gormPassages) Count(studyTextID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Passage{}).Where("study_text_id = ?", studyTextID).Count(&count).Error
+	return count, err
+}
+
+func (r gormPassages) NextPosition(studyTextID uint) (int, error) {
+	var last int
+	err := r.db.Model(&Passage{}).Where("study_text_id = ?", studyTextID).
+		Select("COALESCE(MAX(?), -1)", PositionColumn).Scan(&last).Error
+	return last + 1, err
+}
+
+func (r gormPassages) Revise(passage *Passage, actor string) error {
+	return RevisePassage(r.db, passage, actor)
+}
+
+type gormQuizQuestions gormRepository
+
+func (r gormQuizQuestions) Create(question *QuizQuestion) error {
+	return r.db.Create(question).Error
+}
+
+func (r gormQuizQuestions) Save(question *QuizQuestion) error {
+	return r.db.Save(question).Error
+}
+
+func (r gormQuizQuestions) Delete(question *QuizQuestion) error {
+	return r.db.Delete(question).Error
+}
+
+func (r gormQuizQuestions) Get(id uint) (QuizQuestion, error) {
+	var question QuizQuestion
+	return question, findOne(r.db, &question, id)
+}
+
+func (r gormQuizQuestions) GetForUpdate(id uint) (QuizQuestion, error) {
+	var question QuizQuestion
+	return question, findOne(gormRepository(r).forUpdate(), &question, id)
+}
+
+func (r gormQuizQuestions) ForStudyText(studyTextID uint) ([]QuizQuestion, error) {
+	var questions []QuizQuestion
+	err := r.db.Where("study_text_id = ?", studyTextID).Order(ByPosition).Order("id ASC").Find(&questions).Error
+	return questions, err
+}
+
+func (r gormQuizQuestions) ForPassage(passageID uint) ([]QuizQuestion, error) {
+	var questions []QuizQuestion
+	err := r.db.Where("passage_id = ?", passageID).Order(ByPosition).Order("id ASC").Find(&questions).Error
+	return questions, err
+}
+
+// published selects the questions of published study texts in display order
+func (r gormQuizQuestions) published() *gorm.DB {
+	published := r.db.Model(&StudyText{}).Select("id").Where("status = ?", StudyTextPublished)
+	return r.db.Where("study_text_id IN (?)", published).Order(ByPosition).Order("id ASC")
+}
+
+func (r gormQuizQuestions) PublishedForStudyText(studyTextID uint) ([]QuizQuestion, error) {
+	var questions []QuizQuestion
+	err := r.published().Where("study_text_id = ? AND passage_id IS NULL", studyTextID).Find(&questions).Error
+	return questions, err
+}
+
+func (r gormQuizQuestions) PublishedForPassage(passageID uint) ([]QuizQuestion, error) {
+	var questions []QuizQuestion
+	err := r.published().Where("passage_id = ?", passageID).Find(&questions).Error
+	return questions, err
+}
+
+func (r gormQuizQuestions) Find(studyTextID uint, questionID string, passageID *uint) (QuizQuestion, error) {
+	query := r.db.Where("study_text_id = ? AND question_id = ?", studyTextID, questionID)
+	if passageID != nil && *passageID > 0 {
+		query = query.Where("passage_id = ?", *passageID)
+	} else {
+		query = query.Order("passage_id IS NOT NULL").Order("id ASC")
+	}
+	var question QuizQuestion
+	return question, findOne(query, &question)
+}
+
+func (r gormQuizQuestions) RevisionAt(quizQuestionID uint, at time.Time) (QuizQuestionRevision, error) {
+	var revision QuizQuestionRevision
+	err := findOne(r.db.Where("quiz_question_id = ? AND created_at <= ?", quizQuestionID, at).Order("revision DESC"), &revision)
+	if errors.Is(err, ErrNotFound) {
+		err = findOne(r.db.Where("quiz_question_id = ?", quizQuestionID).Order("revision ASC"), &revision)
+	}
+	return revision, err
+}
+
+func (r gormQuizQuestions) Revise(question *QuizQuestion, actor string) error {
+	return ReviseQuizQuestion(r.db, question, actor)
+}
+
+type gormFonts gormRepository
+
+func (r gormFonts) List() ([]Font, error) {
+	var fonts []Font
+	err := r.db.Order("category ASC, name ASC").Find(&fonts).Error
+	return fonts, err
+}
+
+type gormConsent gormRepository
+
+func (r gormConsent) ActiveDocument() (ConsentDocument, error) {
+	var document ConsentDocument
+	return document, findOne(r.db.Where("active = ?", true).Order("id DESC"), &document)
+}
+
+func (r gormConsent) GetDocument(id uint) (ConsentDocument, error) {
+	var document ConsentDocument
+	return document, findOne(r.db, &document, id)
+}
+
+func (r gormConsent) GetDocumentByVersion(version string) (ConsentDocument, error) {
+	var document ConsentDocument
+	return document, findOne(r.db.Where("version = ?", version), &document)
+}
+
+func (r gormConsent) HasAccepted(participantID, documentID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&ConsentRecord{}).
+		Where("participant_id = ? AND consent_document_id = ?", participantID, documentID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormConsent) GetRecord(participantID, documentID uint) (ConsentRecord, error) {
+	var record ConsentRecord
+	query := r.db.Where("participant_id = ? AND consent_document_id = ?", participantID, documentID)
+	return record, findOne(query, &record)
+}
+
+func (r gormConsent) AddRecord(record *ConsentRecord) error {
+	return r.db.Create(record).Error
+}
+
+type gormDemographics gormRepository
+
+func (r gormDemographics) ActiveSchema() (DemographicsSchema, error) {
+	var schema DemographicsSchema
+	return schema, findOne(r.db.Where("active = ?", true).Order("id DESC"), &schema)
+}
+
+func (r gormDemographics) GetResponse(participantID uint) (DemographicsResponse, error) {
+	var response DemographicsResponse
+	return response, findOne(r.db.Where("participant_id = ?", participantID), &response)
+}
+
+func (r gormDemographics) SaveResponse(response *DemographicsResponse) error {
+	return r.db.Save(response).Error
+}
+
+type gormAudit gormRepository
+
+func (r gormAudit) Add(entry *AuditEntry) error {
+	return r.db.Create(entry).Error
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"gorm.io/gorm"
+)
+
+// newPassageRevision copies the content of a passage into an unsaved revision
+func newPassageRevision(passage Passage) PassageRevision {
+	return PassageRevision{
+		PassageID:   passage.ID,
+		StudyTextID: passage.StudyTextID,
+		Order:       passage.Order,
+		Content:     passage.Content,
+		Title:       passage.Title,
+		FontLeft:    passage.FontLeft,
+		FontRight:   passage.FontRight,
+	}
+}
+
+// sameContent reports whether two revisions hold the same content
+func (r PassageRevision) sameContent(other PassageRevision) bool {
+	return r.StudyTextID == other.StudyTextID && r.Order == other.Order && r.Content == other.Content &&
+		r.Title == other.Title && r.FontLeft == other.FontLeft && r.FontRight == other.FontRight
+}
+
+// RevisePassage adds a revision for a passage that was just created or saved,
+// unless its content matches the current revision, and points the passage at it
+func RevisePassage(tx *gorm.DB, passage *Passage, actor string) error {
+	revision := newPassageRevision(*passage)
+
+	var latest PassageRevision
+	found := tx.Where("passage_id = ?", passage.ID).Order("revision DESC").Limit(1).Find(&latest)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected > 0 && latest.sameContent(revision) {
+		revision = latest
+	} else {
+		revision.Revision = latest.Revision + 1
+		revision.CreatedBy = actor
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+	}
+
+	passage.Revision = revision.Revision
+	passage.RevisionID = &revision.ID
+	return tx.Model(passage).UpdateColumns(map[string]interface{}{
+		"revision":    revision.Revision,
+		"revision_id": revision.ID,
+	}).Error
+}
+
+// NewQuizQuestionRevision copies a quiz question into an unsaved revision
+func NewQuizQuestionRevision(question QuizQuestion) QuizQuestionRevision {
+	return QuizQuestionRevision{
+		QuizQuestionID: question.ID,
+		StudyTextID:    question.StudyTextID,
+		PassageID:      question.PassageID,
+		QuestionID:     question.QuestionID,
+		Prompt:         question.Prompt,
+		Choices:        question.Choices,
+		Answer:         question.Answer,
+		Order:          question.Order,
+	}
+}
+
+// sameContent reports whether two revisions hold the same question
+func (r QuizQuestionRevision) sameContent(other QuizQuestionRevision) bool {
+	samePassage := (r.PassageID == nil && other.PassageID == nil) ||
+		(r.PassageID != nil && other.PassageID != nil && *r.PassageID == *other.PassageID)
+	return samePassage && r.StudyTextID == other.StudyTextID && r.QuestionID == other.QuestionID &&
+		r.Prompt == other.Prompt && r.Choices == other.Choices && r.Answer == other.Answer && r.Order == other.Order
+}
+
+// ReviseQuizQuestion adds a revision for a question that was just created or
+// saved, unless it matches the current revision, and points the question at it
+func ReviseQuizQuestion(tx *gorm.DB, question *QuizQuestion, actor string) error {
+	revision := NewQuizQuestionRevision(*question)
+
+	var latest QuizQuestionRevision
+	found := tx.Where("quiz_question_id = ?", question.ID).Order("revision DESC").Limit(1).Find(&latest)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected > 0 && latest.sameContent(revision) {
+		revision = latest
+	} else {
+		revision.Revision = latest.Revision + 1
+		revision.CreatedBy = actor
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+	}
+
+	question.Revision = revision.Revision
+	question.RevisionID = &revision.ID
+	return tx.Model(question).UpdateColumns(map[string]interface{}{
+		"revision":    revision.Revision,
+		"revision_id": revision.ID,
+	}).Error
+}
+
+// backfillRevisions gives passages and questions stored before revisions
+// existed their first revision
+func backfillRevisions(tx *gorm.DB) error {
+	var passages []v1Passage
+	if err := tx.Where("revision_id IS NULL").Find(&passages).Error; err != nil {
+		return err
+	}
+	for i := range passages {
+		if err := recordPassageRevision(tx, &passages[i], "backfill"); err != nil {
+			return err
+		}
+	}
+
+	var questions []v1QuizQuestion
+	if err := tx.Where("revision_id IS NULL").Find(&questions).Error; err != nil {
+		return err
+	}
+	for i := range questions {
+		if err := recordQuizQuestionRevision(tx, &questions[i], "backfill"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordPassageRevision is RevisePassage for the migrations, on the frozen
+// models they were written against
+func recordPassageRevision(tx *gorm.DB, passage *v1Passage, actor string) error {
+	revision := v1PassageRevision{
+		PassageID:   passage.ID,
+		StudyTextID: passage.StudyTextID,
+		Order:       passage.Order,
+		Content:     passage.Content,
+		Title:       passage.Title,
+		FontLeft:    passage.FontLeft,
+		FontRight:   passage.FontRight,
+	}
+
+	var latest v1PassageRevision
+	found := tx.Where("passage_id = ?", passage.ID).Order("revision DESC").Limit(1).Find(&latest)
+	if found.Error != nil {
+		return found.Error
+	}
+	same := latest.StudyTextID == revision.StudyTextID && latest.Order == revision.Order &&
+		latest.Content == revision.Content && latest.Title == revision.Title &&
+		latest.FontLeft == revision.FontLeft && latest.FontRight == revision.FontRight
+	if found.RowsAffected > 0 && same {
+		revision = latest
+	} else {
+		revision.Revision = latest.Revision + 1
+		revision.CreatedBy = actor
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+	}
+
+	passage.Revision = revision.Revision
+	passage.RevisionID = &revision.ID
+	return tx.Model(passage).UpdateColumns(map[string]interface{}{
+		"revision":    revision.Revision,
+		"revision_id": revision.ID,
+	}).Error
+}
+
+// recordQuizQuestionRevision is ReviseQuizQuestion for the migrations, on the
+// frozen models they were written against
+func recordQuizQuestionRevision(tx *gorm.DB, question *v1QuizQuestion, actor string) error {
+	revision := v1QuizQuestionRevision{
+		QuizQuestionID: question.ID,
+		StudyTextID:    question.StudyTextID,
+		PassageID:      question.PassageID,
+		QuestionID:     question.QuestionID,
+		Prompt:         question.Prompt,
+		Choices:        question.Choices,
+		Answer:         question.Answer,
+		Order:          question.Order,
+	}
+
+	var latest v1QuizQuestionRevision
+	found := tx.Where("quiz_question_id = ?", question.ID).Order("revision DESC").Limit(1).Find(&latest)
+	if found.Error != nil {
+		return found.Error
+	}
+	samePassage := (latest.PassageID == nil && revision.PassageID == nil) ||
+		(latest.PassageID != nil && revision.PassageID != nil && *latest.PassageID == *revision.PassageID)
+	same := samePassage && latest.StudyTextID == revision.StudyTextID && latest.QuestionID == revision.QuestionID &&
+		latest.Prompt == revision.Prompt && latest.Choices == revision.Choices &&
+		latest.Answer == revision.Answer && latest.Order == revision.Order
+	if found.RowsAffected > 0 && same {
+		revision = latest
+	} else {
+		revision.Revision = latest.Revision + 1
+		revision.CreatedBy = actor
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+	}
+
+	question.Revision = revision.Revision
+	question.RevisionID = &revision.ID
+	return tx.Model(question).UpdateColumns(map[string]interface{}{
+		"revision":    revision.Revision,
+		"revision_id": revision.ID,
+	}).Error
+}
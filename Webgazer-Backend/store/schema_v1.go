@@ -0,0 +1,430 @@
+package store
+
+import "time"
+
+// The structs below freeze the schema created by migration 1 (initial_schema).
+// They are copies of the models as they were when migrations were introduced,
+// so migration 1 creates the same tables however the models change later.
+// Never edit them; change the schema with a new migration instead.
+
+// initialSchema lists the tables of migration 1 in creation order; they are
+// dropped in reverse order
+var initialSchema = []interface{}{
+	&v1Participant{},
+	&v1StudySession{},
+	&v1CalibrationData{},
+	&v1AccuracyMeasurement{},
+	&v1QuizResponse{},
+	&v1GazePoint{},
+	&v1ReadingEvent{},
+	&v1StudyText{},
+	&v1Passage{},
+	&v1QuizQuestion{},
+	&v1AdminUser{},
+	&v1FixationRun{},
+	&v1Fixation{},
+	&v1Saccade{},
+	&v1AOI{},
+	&v1SessionCondition{},
+	&v1Font{},
+	&v1FontComparison{},
+	&v1SessionQuality{},
+	&v1ConsentDocument{},
+	&v1ConsentRecord{},
+	&v1DemographicsSchema{},
+	&v1DemographicsResponse{},
+	&v1ErasureRecord{},
+	&v1AuditEntry{},
+	&v1PassageRevision{},
+	&v1QuizQuestionRevision{},
+}
+
+type v1Participant struct {
+	ID                uint   `gorm:"primaryKey"`
+	Source            string `gorm:"index;uniqueIndex:idx_participant_external"`
+	CreatedAt         time.Time
+	ExternalID        *string `gorm:"uniqueIndex:idx_participant_external"`
+	ExternalStudyID   string
+	ExternalSessionID string
+	AnonymizedAt      *time.Time
+	StudySessions     []v1StudySession `gorm:"foreignKey:ParticipantID;references:ID"`
+}
+
+type v1StudySession struct {
+	ID                   uint   `gorm:"primaryKey"`
+	SessionID            string `gorm:"uniqueIndex;not null"`
+	ParticipantID        uint   `gorm:"index"`
+	StudyTextID          *uint  `gorm:"index"`
+	ConditionIndex       *int   `gorm:"index"`
+	CreatedAt            time.Time
+	Status               string `gorm:"index"`
+	StatusChangedAt      *time.Time
+	LastActivityAt       *time.Time `gorm:"index"`
+	CompletedAt          *time.Time
+	CompletionCode       string                  `gorm:"index"`
+	Participant          v1Participant           `gorm:"foreignKey:ParticipantID;references:ID"`
+	CalibrationData      []v1CalibrationData     `gorm:"foreignKey:SessionID;references:ID"`
+	AccuracyMeasurements []v1AccuracyMeasurement `gorm:"foreignKey:SessionID;references:ID"`
+	QuizResponses        []v1QuizResponse        `gorm:"foreignKey:SessionID;references:ID"`
+	GazePoints           []v1GazePoint           `gorm:"foreignKey:SessionID;references:ID"`
+	ReadingEvents        []v1ReadingEvent        `gorm:"foreignKey:SessionID;references:ID"`
+	Conditions           []v1SessionCondition    `gorm:"foreignKey:SessionID;references:ID"`
+	CalibrationPoints    int
+	FontLeft             string
+	FontRight            string
+	TimeLeftMS           int
+	TimeRightMS          int
+	TimeAMS              int
+	TimeBMS              int
+	FontPreference       string
+	PreferredFontType    string
+	QuizResponsesJSON    string
+	UserAgent            string
+	ScreenWidth          int
+	ScreenHeight         int
+}
+
+type v1CalibrationData struct {
+	ID          uint           `gorm:"primaryKey"`
+	SessionID   uint           `gorm:"index;not null"`
+	PointIndex  int            `gorm:"not null"`
+	ClickNumber int            `gorm:"not null"`
+	X           float64        `gorm:"not null"`
+	Y           float64        `gorm:"not null"`
+	Timestamp   time.Time      `gorm:"not null"`
+	Session     v1StudySession `gorm:"foreignKey:SessionID;references:ID"`
+}
+
+type v1AccuracyMeasurement struct {
+	ID        uint           `gorm:"primaryKey"`
+	SessionID uint           `gorm:"index;not null"`
+	Accuracy  float64        `gorm:"not null"`
+	Duration  int            `gorm:"not null"`
+	Passed    bool           `gorm:"not null"`
+	Timestamp time.Time      `gorm:"not null"`
+	Session   v1StudySession `gorm:"foreignKey:SessionID;references:ID"`
+}
+
+type v1QuizResponse struct {
+	ID                 uint   `gorm:"primaryKey"`
+	SessionID          uint   `gorm:"index;not null"`
+	QuestionID         string `gorm:"not null"`
+	PassageID          *uint  `gorm:"index"`
+	QuestionRevisionID *uint  `gorm:"index"`
+	AnswerIndex        int    `gorm:"not null"`
+	IsCorrect          *bool
+	ResponseTime       int
+	Timestamp          time.Time      `gorm:"not null"`
+	Session            v1StudySession `gorm:"foreignKey:SessionID;references:ID"`
+}
+
+type v1GazePoint struct {
+	ID        uint    `gorm:"primaryKey"`
+	SessionID uint    `gorm:"index;not null"`
+	X         float64 `gorm:"not null"`
+	Y         float64 `gorm:"not null"`
+	Panel     string
+	Phase     string
+	Timestamp time.Time      `gorm:"not null"`
+	WordAOIID *uint          `gorm:"index"`
+	LineAOIID *uint          `gorm:"index"`
+	Session   v1StudySession `gorm:"foreignKey:SessionID;references:ID"`
+}
+
+type v1ReadingEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index;not null"`
+	EventType string `gorm:"not null"`
+	Panel     string `gorm:"not null"`
+	Duration  int
+	Timestamp time.Time      `gorm:"not null"`
+	Session   v1StudySession `gorm:"foreignKey:SessionID;references:ID"`
+}
+
+type v1StudyText struct {
+	ID             uint   `gorm:"primaryKey"`
+	Version        string `gorm:"uniqueIndex;not null"`
+	Content        string `gorm:"type:text"`
+	FontLeft       string `gorm:"default:serif"`
+	FontRight      string `gorm:"default:sans"`
+	Active         bool   `gorm:"default:true"`
+	Status         string `gorm:"index;default:published"`
+	PublishedAt    *time.Time
+	ClonedFromID   *uint
+	CompletionCode string
+	CompletionURL  string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	QuizQuestions  []v1QuizQuestion `gorm:"foreignKey:StudyTextID;references:ID"`
+	Passages       []v1Passage      `gorm:"foreignKey:StudyTextID;references:ID;order:order ASC"`
+}
+
+type v1Passage struct {
+	ID            uint   `gorm:"primaryKey"`
+	StudyTextID   uint   `gorm:"index;not null"`
+	Order         int    `gorm:"not null"`
+	Content       string `gorm:"type:text;not null"`
+	Title         string
+	FontLeft      string `gorm:"default:serif"`
+	FontRight     string `gorm:"default:sans"`
+	Revision      int
+	RevisionID    *uint `gorm:"index"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	StudyText     v1StudyText      `gorm:"foreignKey:StudyTextID;references:ID"`
+	QuizQuestions []v1QuizQuestion `gorm:"foreignKey:PassageID;references:ID"`
+}
+
+type v1QuizQuestion struct {
+	ID          uint   `gorm:"primaryKey"`
+	StudyTextID uint   `gorm:"index;not null"`
+	PassageID   *uint  `gorm:"index"`
+	QuestionID  string `gorm:"not null"`
+	Prompt      string `gorm:"type:text;not null"`
+	Choices     string `gorm:"type:text;not null"`
+	Answer      int    `gorm:"not null"`
+	Order       int    `gorm:"default:0"`
+	Revision    int
+	RevisionID  *uint `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	StudyText   v1StudyText `gorm:"foreignKey:StudyTextID;references:ID"`
+	Passage     *v1Passage  `gorm:"foreignKey:PassageID;references:ID"`
+}
+
+type v1AdminUser struct {
+	ID           uint   `gorm:"primaryKey"`
+	Username     string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	Role         string `gorm:"not null;default:viewer"`
+	LastLoginAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type v1FixationRun struct {
+	ID                  uint   `gorm:"primaryKey"`
+	SessionID           uint   `gorm:"index;not null"`
+	Algorithm           string `gorm:"not null"`
+	VelocityThreshold   float64
+	DispersionThreshold float64
+	MinFixationMS       int
+	SampleCount         int
+	CreatedAt           time.Time
+	Fixations           []v1Fixation `gorm:"foreignKey:RunID;references:ID"`
+	Saccades            []v1Saccade  `gorm:"foreignKey:RunID;references:ID"`
+}
+
+type v1Fixation struct {
+	ID          uint      `gorm:"primaryKey"`
+	RunID       uint      `gorm:"index;not null"`
+	SessionID   uint      `gorm:"index;not null"`
+	StartTime   time.Time `gorm:"not null"`
+	EndTime     time.Time `gorm:"not null"`
+	DurationMS  int       `gorm:"not null"`
+	X           float64   `gorm:"not null"`
+	Y           float64   `gorm:"not null"`
+	Panel       string
+	SampleCount int
+	WordAOIID   *uint `gorm:"index"`
+	LineAOIID   *uint `gorm:"index"`
+}
+
+type v1Saccade struct {
+	ID         uint      `gorm:"primaryKey"`
+	RunID      uint      `gorm:"index;not null"`
+	SessionID  uint      `gorm:"index;not null"`
+	StartTime  time.Time `gorm:"not null"`
+	EndTime    time.Time `gorm:"not null"`
+	DurationMS int       `gorm:"not null"`
+	StartX     float64
+	StartY     float64
+	EndX       float64
+	EndY       float64
+	Amplitude  float64
+	Velocity   float64
+	FromPanel  string
+	ToPanel    string
+}
+
+type v1AOI struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index;not null"`
+	PassageID uint   `gorm:"index;not null"`
+	Panel     string `gorm:"not null"`
+	Font      string
+	Kind      string `gorm:"not null"`
+	Index     int    `gorm:"not null"`
+	LineIndex int
+	Text      string
+	X         float64   `gorm:"not null"`
+	Y         float64   `gorm:"not null"`
+	Width     float64   `gorm:"not null"`
+	Height    float64   `gorm:"not null"`
+	ShownAt   time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type v1SessionCondition struct {
+	ID                uint   `gorm:"primaryKey"`
+	SessionID         uint   `gorm:"index;not null"`
+	PassageID         uint   `gorm:"index;not null"`
+	PassageRevisionID *uint  `gorm:"index"`
+	Position          int    `gorm:"not null"`
+	FontLeft          string `gorm:"not null"`
+	FontRight         string `gorm:"not null"`
+	CreatedAt         time.Time
+}
+
+type v1Font struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex;not null"`
+	DisplayName string `gorm:"not null"`
+	Family      string `gorm:"not null"`
+	Category    string `gorm:"index;not null"`
+	CreatedAt   time.Time
+}
+
+type v1FontComparison struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index;not null"`
+	PassageID *uint  `gorm:"index"`
+	FontA     string `gorm:"not null"`
+	FontB     string `gorm:"not null"`
+	Preferred string `gorm:"not null"`
+	Round     int
+	Bracket   string
+	TimeAMS   int
+	TimeBMS   int
+	Timestamp time.Time `gorm:"not null"`
+}
+
+type v1SessionQuality struct {
+	ID           uint `gorm:"primaryKey"`
+	SessionID    uint `gorm:"uniqueIndex;not null"`
+	Score        float64
+	AutoIncluded bool
+	Override     *bool
+	OverrideNote string
+	Included     bool      `gorm:"index"`
+	Reasons      string    `gorm:"type:text"`
+	Checks       string    `gorm:"type:text"`
+	Thresholds   string    `gorm:"type:text"`
+	EvaluatedAt  time.Time `gorm:"not null"`
+	UpdatedAt    time.Time
+}
+
+type v1ConsentDocument struct {
+	ID        uint   `gorm:"primaryKey"`
+	Version   string `gorm:"uniqueIndex;not null"`
+	Title     string
+	Body      string `gorm:"type:text;not null"`
+	Active    bool   `gorm:"index"`
+	CreatedAt time.Time
+}
+
+type v1ConsentRecord struct {
+	ID                uint      `gorm:"primaryKey"`
+	ParticipantID     uint      `gorm:"uniqueIndex:idx_consent_participant_document;not null"`
+	ConsentDocumentID uint      `gorm:"uniqueIndex:idx_consent_participant_document;not null"`
+	Version           string    `gorm:"not null"`
+	AcceptedAt        time.Time `gorm:"not null"`
+	UserAgent         string
+}
+
+type v1DemographicsSchema struct {
+	ID        uint   `gorm:"primaryKey"`
+	Version   string `gorm:"uniqueIndex;not null"`
+	Fields    string `gorm:"type:text;not null"`
+	Active    bool   `gorm:"index"`
+	CreatedAt time.Time
+}
+
+type v1DemographicsResponse struct {
+	ID                   uint   `gorm:"primaryKey"`
+	ParticipantID        uint   `gorm:"uniqueIndex;not null"`
+	DemographicsSchemaID uint   `gorm:"index;not null"`
+	Version              string `gorm:"not null"`
+	Answers              string `gorm:"type:text;not null"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+type v1ErasureRecord struct {
+	ID            uint   `gorm:"primaryKey"`
+	Action        string `gorm:"index;not null"`
+	ParticipantID *uint  `gorm:"index"`
+	Sessions      int
+	Rows          string `gorm:"type:text"`
+	Reason        string
+	RequestedBy   string `gorm:"not null"`
+	CreatedAt     time.Time
+}
+
+type v1AuditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	Actor     string    `gorm:"index;not null"`
+	Action    string    `gorm:"index;not null"`
+	Entity    string    `gorm:"index:idx_audit_entity;not null"`
+	EntityID  uint      `gorm:"index:idx_audit_entity"`
+	Before    string    `gorm:"type:text"`
+	After     string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+type v1PassageRevision struct {
+	ID          uint `gorm:"primaryKey"`
+	PassageID   uint `gorm:"uniqueIndex:idx_passage_revision;not null"`
+	Revision    int  `gorm:"uniqueIndex:idx_passage_revision;not null"`
+	StudyTextID uint `gorm:"index;not null"`
+	Order       int
+	Content     string `gorm:"type:text;not null"`
+	Title       string
+	FontLeft    string
+	FontRight   string
+	CreatedBy   string
+	CreatedAt   time.Time
+}
+
+type v1QuizQuestionRevision struct {
+	ID             uint `gorm:"primaryKey"`
+	QuizQuestionID uint `gorm:"uniqueIndex:idx_quiz_question_revision;not null"`
+	Revision       int  `gorm:"uniqueIndex:idx_quiz_question_revision;not null"`
+	StudyTextID    uint `gorm:"index;not null"`
+	PassageID      *uint
+	QuestionID     string `gorm:"not null"`
+	Prompt         string `gorm:"type:text;not null"`
+	Choices        string `gorm:"type:text;not null"`
+	Answer         int
+	Order          int
+	CreatedBy      string
+	CreatedAt      time.Time
+}
+
+func (v1Participant) TableName() string          { return "participants" }
+func (v1StudySession) TableName() string         { return "study_sessions" }
+func (v1CalibrationData) TableName() string      { return "calibration_data" }
+func (v1AccuracyMeasurement) TableName() string  { return "accuracy_measurements" }
+func (v1QuizResponse) TableName() string         { return "quiz_responses" }
+func (v1GazePoint) TableName() string            { return "gaze_points" }
+func (v1ReadingEvent) TableName() string         { return "reading_events" }
+func (v1StudyText) TableName() string            { return "study_texts" }
+func (v1Passage) TableName() string              { return "passages" }
+func (v1QuizQuestion) TableName() string         { return "quiz_questions" }
+func (v1AdminUser) TableName() string            { return "admin_users" }
+func (v1FixationRun) TableName() string          { return "fixation_runs" }
+func (v1Fixation) TableName() string             { return "fixations" }
+func (v1Saccade) TableName() string              { return "saccades" }
+func (v1AOI) TableName() string                  { return "aois" }
+func (v1SessionCondition) TableName() string     { return "session_conditions" }
+func (v1Font) TableName() string                 { return "fonts" }
+func (v1FontComparison) TableName() string       { return "font_comparisons" }
+func (v1SessionQuality) TableName() string       { return "session_qualities" }
+func (v1ConsentDocument) TableName() string      { return "consent_documents" }
+func (v1ConsentRecord) TableName() string        { return "consent_records" }
+func (v1DemographicsSchema) TableName() string   { return "demographics_schemas" }
+func (v1DemographicsResponse) TableName() string { return "demographics_responses" }
+func (v1ErasureRecord) TableName() string        { return "erasure_records" }
+func (v1AuditEntry) TableName() string           { return "audit_entries" }
+func (v1PassageRevision) TableName() string      { return "passage_revisions" }
+func (v1QuizQuestionRevision) TableName() string { return "quiz_question_revisions" }
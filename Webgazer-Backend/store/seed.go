@@ -0,0 +1,342 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Like migration 1, the seed migrations work on the frozen models in
+// schema_v1.go, so they keep doing the same whatever the models look like today.
+
+// seedFonts adds any fonts from the default pool that are missing
+func seedFonts(tx *gorm.DB) error {
+	for _, font := range defaultFonts {
+		if err := tx.Where("name = ?", font.Name).FirstOrCreate(&font).Error; err != nil {
+			return fmt.Errorf("seeding font %s: %w", font.Name, err)
+		}
+	}
+	return nil
+}
+
+// unseedFonts removes the default fonts unless a study text or passage still uses one
+func unseedFonts(tx *gorm.DB) error {
+	names := make([]string, len(defaultFonts))
+	for i, font := range defaultFonts {
+		names[i] = font.Name
+	}
+	for _, table := range []string{"study_texts", "passages"} {
+		var count int64
+		err := tx.Table(table).Where("font_left IN ? OR font_right IN ?", names, names).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%d %s still use the default fonts", count, table)
+		}
+	}
+	return tx.Where("name IN ?", names).Delete(&v1Font{}).Error
+}
+
+// seedConsent adds a first consent document and demographics questionnaire
+// when there are none yet; researchers replace them with their own versions
+func seedConsent(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&v1ConsentDocument{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		document := v1ConsentDocument{
+			Version: "v1",
+			Title:   "Consent to take part in a readability study",
+			Body: "You are invited to take part in a study on how fonts affect reading. " +
+				"You will calibrate a webcam-based eye tracker, read short passages and answer questions about them. " +
+				"Your webcam image is processed in your browser and never uploaded; only estimated gaze positions, " +
+				"reading times, answers and the questionnaire you fill in are stored, without your name. " +
+				"Taking part is voluntary and you may stop at any time without giving a reason. " +
+				"By continuing you confirm that you are at least 18 years old and agree to take part.",
+			Active: true,
+		}
+		if err := tx.Create(&document).Error; err != nil {
+			return fmt.Errorf("creating consent document: %w", err)
+		}
+	}
+
+	if err := tx.Model(&v1DemographicsSchema{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		var fields bytes.Buffer
+		if err := json.Compact(&fields, []byte(defaultDemographicsFields)); err != nil {
+			return err
+		}
+		schema := v1DemographicsSchema{Version: "v1", Fields: fields.String(), Active: true}
+		if err := tx.Create(&schema).Error; err != nil {
+			return fmt.Errorf("creating demographics questionnaire: %w", err)
+		}
+	}
+	return nil
+}
+
+// unseedConsent removes the seeded consent document and questionnaire unless
+// participants have already agreed to or answered them
+func unseedConsent(tx *gorm.DB) error {
+	var document v1ConsentDocument
+	found := tx.Where("version = ?", "v1").Limit(1).Find(&document)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected > 0 {
+		var count int64
+		if err := tx.Model(&v1ConsentRecord{}).Where("consent_document_id = ?", document.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("consent document v1 has %d consent records", count)
+		}
+		if err := tx.Delete(&document).Error; err != nil {
+			return err
+		}
+	}
+
+	var schema v1DemographicsSchema
+	found = tx.Where("version = ?", "v1").Limit(1).Find(&schema)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected > 0 {
+		var count int64
+		if err := tx.Model(&v1DemographicsResponse{}).Where("demographics_schema_id = ?", schema.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("demographics questionnaire v1 has %d responses", count)
+		}
+		if err := tx.Delete(&schema).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedDefaultStudy populates an empty database with the default study text,
+// its passages and quiz questions
+func seedDefaultStudy(tx *gorm.DB) error {
+	// Databases that already have study texts keep them
+	var count int64
+	if err := tx.Model(&v1StudyText{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// Create study text
+	studyText := v1StudyText{
+		Version:   "default",
+		FontLeft:  "georgia",
+		FontRight: "inter",
+		Active:    true,
+	}
+
+	if err := tx.Create(&studyText).Error; err != nil {
+		return fmt.Errorf("creating study text: %w", err)
+	}
+
+	// Create multiple passages for the study text with different font combinations
+	passages := []v1Passage{
+		{
+			StudyTextID: studyText.ID,
+			Order:       0,
+			Title:       "Passage 1: Introduction to Reading",
+			Content:     `Reading is a complex cognitive process that involves decoding symbols to derive meaning. This process requires the coordination of multiple brain regions working together to transform written text into comprehensible information. The human brain processes visual information through the eyes, sending signals to various neural networks that interpret and understand the text.`,
+			FontLeft:    "georgia",
+			FontRight:   "inter",
+		},
+		{
+			StudyTextID: studyText.ID,
+			Order:       1,
+			Title:       "Passage 2: Typography and Readability",
+			Content:     `Typography plays a crucial role in how we perceive and understand written content. Different font styles can significantly impact reading speed, comprehension, and overall user experience. Serif fonts, with their decorative strokes, are often associated with traditional print media, while sans-serif fonts offer a cleaner, more modern appearance.`,
+			FontLeft:    "open-sans",
+			FontRight:   "merriweather",
+		},
+		{
+			StudyTextID: studyText.ID,
+			Order:       2,
+			Title:       "Passage 3: Reading Research",
+			Content:     `Researchers have conducted extensive studies to understand how different typographic choices affect reading performance. These studies examine factors such as font size, line spacing, letter spacing, and font style. The goal is to identify optimal typography settings that maximize readability and comprehension for various audiences and contexts.`,
+			FontLeft:    "times-new-roman",
+			FontRight:   "roboto",
+		},
+		{
+			StudyTextID: studyText.ID,
+			Order:       3,
+			Title:       "Passage 4: Digital Reading",
+			Content:     `The shift from print to digital media has introduced new challenges and opportunities in typography. Screen readability differs from print, requiring careful consideration of font rendering, display resolution, and viewing conditions. Designers must balance aesthetic appeal with functional readability to create effective digital reading experiences.`,
+			FontLeft:    "inter",
+			FontRight:   "georgia",
+		},
+		{
+			StudyTextID: studyText.ID,
+			Order:       4,
+			Title:       "Passage 5: Accessibility in Design",
+			Content:     `Accessibility is a fundamental principle in modern design, ensuring that content is readable and understandable for people with diverse abilities and needs. This includes considerations for visual impairments, cognitive differences, and various reading contexts. Good typography choices can make content more accessible to a wider audience.`,
+			FontLeft:    "merriweather",
+			FontRight:   "open-sans",
+		},
+		{
+			StudyTextID: studyText.ID,
+			Order:       5,
+			Title:       "Passage 6: The Future of Reading",
+			Content:     `As technology continues to evolve, so too will our understanding of reading and typography. Emerging technologies like e-ink displays, variable fonts, and adaptive interfaces offer new possibilities for optimizing reading experiences. The future of typography lies in creating flexible, responsive designs that adapt to individual preferences and reading contexts.`,
+			FontLeft:    "roboto",
+			FontRight:   "times-new-roman",
+		},
+	}
+
+	for i := range passages {
+		if err := tx.Create(&passages[i]).Error; err != nil {
+			return fmt.Errorf("creating passage %d: %w", passages[i].Order, err)
+		}
+		if err := recordPassageRevision(tx, &passages[i], "seed"); err != nil {
+			return err
+		}
+	}
+
+	// Create quiz questions
+	questions := []v1QuizQuestion{
+		{
+			StudyTextID: studyText.ID,
+			QuestionID:  "q1",
+			Prompt:      "What is the purpose of this passage?",
+			Choices:     `["To teach advanced speed-reading","To test font readability and comprehension","To explain eye-tracking algorithms","To measure typing accuracy"]`,
+			Answer:      1,
+			Order:       1,
+		},
+		{
+			StudyTextID: studyText.ID,
+			QuestionID:  "q2",
+			Prompt:      "How should you read the passage?",
+			Choices:     `["As quickly as possible without understanding","Only the first sentence","At a natural pace focusing on understanding","Backwards to test attention"]`,
+			Answer:      2,
+			Order:       2,
+		},
+		{
+			StudyTextID: studyText.ID,
+			QuestionID:  "q3",
+			Prompt:      "According to the passage, what does reading involve?",
+			Choices:     `["Only recognizing letters","Decoding symbols to derive meaning","Memorizing text word-for-word","Counting words per minute"]`,
+			Answer:      1,
+			Order:       3,
+		},
+		{
+			StudyTextID: studyText.ID,
+			QuestionID:  "q4",
+			Prompt:      "What should you avoid when reading this passage?",
+			Choices:     `["Reading at a natural pace","Focusing on understanding","Skimming through the content","Decoding the symbols"]`,
+			Answer:      2,
+			Order:       4,
+		},
+		{
+			StudyTextID: studyText.ID,
+			QuestionID:  "q5",
+			Prompt:      "What is described as a \"complex cognitive process\"?",
+			Choices:     `["Writing","Reading","Speaking","Listening"]`,
+			Answer:      1,
+			Order:       5,
+		},
+	}
+
+	for i := range questions {
+		if err := tx.Create(&questions[i]).Error; err != nil {
+			return fmt.Errorf("creating quiz question %s: %w", questions[i].QuestionID, err)
+		}
+		if err := recordQuizQuestionRevision(tx, &questions[i], "seed"); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Initial study data seeded successfully")
+	return nil
+}
+
+// unseedDefaultStudy removes the default study text with its passages,
+// questions and their revisions, unless sessions have used it
+func unseedDefaultStudy(tx *gorm.DB) error {
+	var studyText v1StudyText
+	found := tx.Where("version = ?", "default").Limit(1).Find(&studyText)
+	if found.Error != nil || found.RowsAffected == 0 {
+		return found.Error
+	}
+
+	var count int64
+	if err := tx.Model(&v1StudySession{}).Where("study_text_id = ?", studyText.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("study text %q has %d sessions", studyText.Version, count)
+	}
+
+	// Revisions refuse deletes through their models, so they are removed with plain SQL
+	statements := []string{
+		"UPDATE study_texts SET cloned_from_id = NULL WHERE cloned_from_id = ?",
+		"DELETE FROM quiz_questions WHERE study_text_id = ?",
+		"DELETE FROM quiz_question_revisions WHERE study_text_id = ?",
+		"DELETE FROM passages WHERE study_text_id = ?",
+		"DELETE FROM passage_revisions WHERE study_text_id = ?",
+		"DELETE FROM study_texts WHERE id = ?",
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement, studyText.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultDemographicsFields are the usual covariates of readability studies,
+// in the format of DemographicsField
+const defaultDemographicsFields = `[
+	{
+		"key": "age_band",
+		"label": "What is your age?",
+		"type": "choice",
+		"required": true,
+		"options": ["18-24", "25-34", "35-44", "45-54", "55-64", "65 or older", "Prefer not to say"]
+	},
+	{
+		"key": "native_language",
+		"label": "What is your native language?",
+		"type": "text",
+		"required": true,
+		"max_length": 100
+	},
+	{
+		"key": "vision_correction",
+		"label": "Are you wearing glasses or contact lenses right now?",
+		"type": "choice",
+		"required": true,
+		"options": ["No", "Glasses", "Contact lenses", "Prefer not to say"]
+	},
+	{
+		"key": "dyslexia",
+		"label": "Have you been diagnosed with dyslexia, or do you believe you have it?",
+		"type": "choice",
+		"required": true,
+		"options": ["Yes", "No", "Prefer not to say"]
+	}
+]`
+
+// defaultFonts mirrors FONT_POOL in the frontend's fonts.ts
+var defaultFonts = []v1Font{
+	{Name: "georgia", DisplayName: "Georgia", Family: "Georgia, serif", Category: "serif"},
+	{Name: "times-new-roman", DisplayName: "Times New Roman", Family: `"Times New Roman", Times, serif`, Category: "serif"},
+	{Name: "merriweather", DisplayName: "Merriweather", Family: `"Merriweather", Georgia, serif`, Category: "serif"},
+	{Name: "inter", DisplayName: "Inter", Family: `"Inter", -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif`, Category: "sans-serif"},
+	{Name: "open-sans", DisplayName: "Open Sans", Family: `"Open Sans", -apple-system, BlinkMacSystemFont, sans-serif`, Category: "sans-serif"},
+	{Name: "roboto", DisplayName: "Roboto", Family: `"Roboto", -apple-system, BlinkMacSystemFont, sans-serif`, Category: "sans-serif"},
+}
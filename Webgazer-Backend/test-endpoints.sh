@@ -24,6 +24,20 @@ RED='\033[0;31m'
 YELLOW='\033[1;33m'
 NC='\033[0m' # No Color
 
+# Admin endpoints require a token; log in with ADMIN_USERNAME/ADMIN_PASSWORD
+# (create an account with: go run . create-admin -username admin -role editor)
+if [ -z "$ADMIN_TOKEN" ] && [ -n "$ADMIN_USERNAME" ]; then
+    ADMIN_TOKEN=$(curl -s -X POST \
+        -H "Content-Type: application/json" \
+        -d "{\"username\":\"${ADMIN_USERNAME}\",\"password\":\"${ADMIN_PASSWORD}\"}" \
+        "${BASE_URL}/api/admin/login" | jq -r '.token // empty' 2>/dev/null)
+fi
+if [ -z "$ADMIN_TOKEN" ]; then
+    echo -e "${YELLOW}⚠ Warning: No admin token. Set ADMIN_USERNAME and ADMIN_PASSWORD to test admin endpoints.${NC}"
+    echo ""
+fi
+AUTH_HEADER="Authorization: Bearer ${ADMIN_TOKEN}"
+
 # Test function
 test_endpoint() {
     local name=$1
@@ -35,10 +49,11 @@ test_endpoint() {
     echo "  Endpoint: ${method} ${endpoint}"
     
     if [ "$method" = "GET" ]; then
-        response=$(curl -s -w "\n%{http_code}" "${BASE_URL}${endpoint}")
+        response=$(curl -s -w "\n%{http_code}" -H "${AUTH_HEADER}" "${BASE_URL}${endpoint}")
     else
         response=$(curl -s -w "\n%{http_code}" -X "${method}" \
             -H "Content-Type: application/json" \
+            -H "${AUTH_HEADER}" \
             -d "${data}" \
             "${BASE_URL}${endpoint}")
     fi
@@ -71,6 +86,7 @@ test_endpoint "Admin: List Study Texts" "GET" "/api/admin/study-text" ""
 ADMIN_STUDY_TEXT_DATA='{"version":"test","content":"Test passage for endpoint testing","font_left":"serif","font_right":"sans","active":false}'
 ADMIN_STUDY_TEXT_RESPONSE=$(curl -s -X POST \
     -H "Content-Type: application/json" \
+    -H "${AUTH_HEADER}" \
     -d "$ADMIN_STUDY_TEXT_DATA" \
     "${BASE_URL}/api/admin/study-text")
 ADMIN_STUDY_TEXT_ID=$(echo "$ADMIN_STUDY_TEXT_RESPONSE" | jq -r '.id' 2>/dev/null)
@@ -92,6 +108,9 @@ ADMIN_STUDY_TEXT_UPDATE_DATA="{
 }"
 test_endpoint "Admin: Update Study Text" "PUT" "/api/admin/study-text" "$ADMIN_STUDY_TEXT_UPDATE_DATA"
 
+# Test 2d: Admin - Preview the (draft) Study Text as participants would see it
+test_endpoint "Admin: Preview Study Text" "GET" "/api/admin/study-texts/${ADMIN_STUDY_TEXT_ID}/preview" ""
+
 # Test 3: Fetch Quiz Questions
 test_endpoint "Fetch Quiz Questions" "GET" "/api/quiz-questions" ""
 test_endpoint "Fetch Quiz Questions (with study_text_id)" "GET" "/api/quiz-questions?study_text_id=1" ""
@@ -113,10 +132,30 @@ fi
 echo "  Using Participant ID: $PARTICIPANT_ID"
 echo ""
 
+# Test 4a: Consent and Demographics (consent is required before creating a session)
+test_endpoint "Get Consent Document" "GET" "/api/consent" ""
+CONSENT_VERSION=$(curl -s "${BASE_URL}/api/consent" | jq -r '.version' 2>/dev/null)
+CONSENT_DATA="{
+    \"participant_id\": $PARTICIPANT_ID,
+    \"version\": \"$CONSENT_VERSION\",
+    \"accepted\": true
+}"
+test_endpoint "Accept Consent" "POST" "/api/consent" "$CONSENT_DATA"
+test_endpoint "Get Demographics Questionnaire" "GET" "/api/demographics" ""
+DEMOGRAPHICS_DATA="{
+    \"participant_id\": $PARTICIPANT_ID,
+    \"answers\": {
+        \"age_band\": \"25-34\",
+        \"native_language\": \"English\",
+        \"vision_correction\": \"No\",
+        \"dyslexia\": \"No\"
+    }
+}"
+test_endpoint "Submit Demographics" "POST" "/api/demographics" "$DEMOGRAPHICS_DATA"
+
 # Test 5: Create Study Session
 SESSION_DATA="{
     \"participant_id\": $PARTICIPANT_ID,
-    \"calibration_points\": 25,
     \"font_left\": \"serif\",
     \"font_right\": \"sans\",
     \"time_left_ms\": 5000,
@@ -143,17 +182,7 @@ fi
 echo "  Using Session ID: $SESSION_ID"
 echo ""
 
-# Test 6: Submit Quiz Response
-QUIZ_RESPONSE_DATA="{
-    \"session_id\": $SESSION_ID,
-    \"question_id\": \"q1\",
-    \"answer_index\": 1,
-    \"is_correct\": true,
-    \"response_time\": 3000
-}"
-test_endpoint "Submit Quiz Response" "POST" "/api/quiz-response" "$QUIZ_RESPONSE_DATA"
-
-# Test 7: Submit Calibration Data
+# Test 6: Submit Calibration Data (data must follow the session lifecycle order)
 CALIBRATION_DATA="{
     \"session_id\": $SESSION_ID,
     \"point_index\": 0,
@@ -163,7 +192,7 @@ CALIBRATION_DATA="{
 }"
 test_endpoint "Submit Calibration Data" "POST" "/api/calibration" "$CALIBRATION_DATA"
 
-# Test 8: Submit Accuracy Measurement
+# Test 7: Submit Accuracy Measurement
 ACCURACY_DATA="{
     \"session_id\": $SESSION_ID,
     \"accuracy\": 85.5,
@@ -172,7 +201,7 @@ ACCURACY_DATA="{
 }"
 test_endpoint "Submit Accuracy Measurement" "POST" "/api/accuracy" "$ACCURACY_DATA"
 
-# Test 9: Submit Gaze Point
+# Test 8: Submit Gaze Point
 GAZE_DATA="{
     \"session_id\": $SESSION_ID,
     \"x\": 500.2,
@@ -182,7 +211,7 @@ GAZE_DATA="{
 }"
 test_endpoint "Submit Gaze Point" "POST" "/api/gaze-point" "$GAZE_DATA"
 
-# Test 9a: Submit Multiple Gaze Points (testing different phases)
+# Test 8a: Submit Multiple Gaze Points (testing different phases)
 GAZE_DATA_B="{
     \"session_id\": $SESSION_ID,
     \"x\": 1200.5,
@@ -201,7 +230,18 @@ GAZE_DATA_WAITING="{
 }"
 test_endpoint "Submit Gaze Point (Waiting Phase)" "POST" "/api/gaze-point" "$GAZE_DATA_WAITING"
 
-# Test 10: Submit Reading Event
+# Test 8b: Submit Gaze Points in a Batch
+GAZE_BATCH_DATA="{
+    \"session_id\": $SESSION_ID,
+    \"points\": [
+        {\"x\": 510.0, \"y\": 305.0, \"panel\": \"A\", \"phase\": \"reading_A\"},
+        {\"x\": 520.4, \"y\": 310.1, \"panel\": \"A\", \"phase\": \"reading_A\"},
+        {\"x\": 1210.7, \"y\": 402.3, \"panel\": \"B\", \"phase\": \"reading_B\"}
+    ]
+}"
+test_endpoint "Submit Gaze Point Batch" "POST" "/api/gaze-points/batch" "$GAZE_BATCH_DATA"
+
+# Test 9: Submit Reading Event
 READING_EVENT_DATA="{
     \"session_id\": $SESSION_ID,
     \"event_type\": \"start\",
@@ -210,6 +250,23 @@ READING_EVENT_DATA="{
 }"
 test_endpoint "Submit Reading Event" "POST" "/api/reading-event" "$READING_EVENT_DATA"
 
+# Test 10: Submit Quiz Response
+QUIZ_RESPONSE_DATA="{
+    \"session_id\": $SESSION_ID,
+    \"question_id\": \"q1\",
+    \"answer_index\": 1,
+    \"response_time\": 3000
+}"
+test_endpoint "Submit Quiz Response" "POST" "/api/quiz-response" "$QUIZ_RESPONSE_DATA"
+
+# Test 10a: Complete the Session
+COMPLETE_DATA="{
+    \"time_left_ms\": 5000,
+    \"time_right_ms\": 4500,
+    \"font_preference\": \"A\"
+}"
+test_endpoint "Complete Study Session" "POST" "/api/session/${SESSION_ID}/complete" "$COMPLETE_DATA"
+
 # Test 11: Admin - Get Quiz Question
 test_endpoint "Admin: Get Quiz Question" "GET" "/api/admin/quiz-question?id=1" ""
 test_endpoint "Admin: Get Quiz Questions by study_text_id" "GET" "/api/admin/quiz-question?study_text_id=1" ""
@@ -225,6 +282,7 @@ ADMIN_QUIZ_DATA='{
 }'
 ADMIN_QUIZ_RESPONSE=$(curl -s -X POST \
     -H "Content-Type: application/json" \
+    -H "${AUTH_HEADER}" \
     -d "$ADMIN_QUIZ_DATA" \
     "${BASE_URL}/api/admin/quiz-question")
 ADMIN_QUIZ_ID=$(echo "$ADMIN_QUIZ_RESPONSE" | jq -r '.id' 2>/dev/null)
@@ -258,6 +316,7 @@ ADMIN_QUIZ_DELETE_DATA='{
 }'
 DELETE_QUIZ_RESPONSE=$(curl -s -X POST \
     -H "Content-Type: application/json" \
+    -H "${AUTH_HEADER}" \
     -d "$ADMIN_QUIZ_DELETE_DATA" \
     "${BASE_URL}/api/admin/quiz-question")
 DELETE_QUIZ_ID=$(echo "$DELETE_QUIZ_RESPONSE" | jq -r '.id' 2>/dev/null)
@@ -281,6 +340,7 @@ ADMIN_PASSAGE_DATA="{
 }"
 PASSAGE_RESPONSE=$(curl -s -X POST \
     -H "Content-Type: application/json" \
+    -H "${AUTH_HEADER}" \
     -d "$ADMIN_PASSAGE_DATA" \
     "${BASE_URL}/api/admin/passage")
 PASSAGE_ID=$(echo "$PASSAGE_RESPONSE" | jq -r '.id' 2>/dev/null)
@@ -322,6 +382,7 @@ ADMIN_PASSAGE_DELETE_DATA="{
 }"
 DELETE_PASSAGE_RESPONSE=$(curl -s -X POST \
     -H "Content-Type: application/json" \
+    -H "${AUTH_HEADER}" \
     -d "$ADMIN_PASSAGE_DELETE_DATA" \
     "${BASE_URL}/api/admin/passage")
 DELETE_PASSAGE_ID=$(echo "$DELETE_PASSAGE_RESPONSE" | jq -r '.id' 2>/dev/null)
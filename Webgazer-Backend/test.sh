@@ -27,13 +27,25 @@ echo "Participant ID: $PARTICIPANT_ID"
 echo ""
 echo ""
 
+# Test 2b: Accept the Consent Document (required before a session can be created)
+echo "2b. Accepting Consent..."
+CONSENT_VERSION=$(curl -s "$BASE_URL/api/consent" | jq -r '.version')
+curl -s -X POST "$BASE_URL/api/consent" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"participant_id\": $PARTICIPANT_ID,
+    \"version\": \"$CONSENT_VERSION\",
+    \"accepted\": true
+  }" | jq .
+echo ""
+echo ""
+
 # Test 3: Create a Study Session
 echo "3. Creating a Study Session..."
 SESSION_RESPONSE=$(curl -s -X POST "$BASE_URL/api/session" \
   -H "Content-Type: application/json" \
   -d "{
     \"participant_id\": $PARTICIPANT_ID,
-    \"calibration_points\": 25,
     \"font_left\": \"serif\",
     \"font_right\": \"sans\",
     \"time_left_ms\": 5000,
@@ -79,22 +91,8 @@ curl -s -X POST "$BASE_URL/api/accuracy" \
 echo ""
 echo ""
 
-# Test 6: Add Quiz Response
-echo "6. Adding Quiz Response..."
-curl -s -X POST "$BASE_URL/api/quiz-response" \
-  -H "Content-Type: application/json" \
-  -d "{
-    \"session_id\": $SESSION_ID,
-    \"question_id\": \"q1\",
-    \"answer_index\": 1,
-    \"is_correct\": true,
-    \"response_time\": 3000
-  }" | jq .
-echo ""
-echo ""
-
-# Test 7: Add Gaze Point
-echo "7. Adding Gaze Point..."
+# Test 6: Add Gaze Point
+echo "6. Adding Gaze Point..."
 curl -s -X POST "$BASE_URL/api/gaze-point" \
   -H "Content-Type: application/json" \
   -d "{
@@ -107,8 +105,8 @@ curl -s -X POST "$BASE_URL/api/gaze-point" \
 echo ""
 echo ""
 
-# Test 8: Add Reading Event
-echo "8. Adding Reading Event..."
+# Test 7: Add Reading Event
+echo "7. Adding Reading Event..."
 curl -s -X POST "$BASE_URL/api/reading-event" \
   -H "Content-Type: application/json" \
   -d "{
@@ -120,6 +118,27 @@ curl -s -X POST "$BASE_URL/api/reading-event" \
 echo ""
 echo ""
 
+# Test 8: Add Quiz Response
+echo "8. Adding Quiz Response..."
+curl -s -X POST "$BASE_URL/api/quiz-response" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"session_id\": $SESSION_ID,
+    \"question_id\": \"q1\",
+    \"answer_index\": 1,
+    \"response_time\": 3000
+  }" | jq .
+echo ""
+echo ""
+
+# Test 9: Complete the Session
+echo "9. Completing Session..."
+curl -s -X POST "$BASE_URL/api/session/$SESSION_ID/complete" \
+  -H "Content-Type: application/json" \
+  -d '{"time_left_ms": 5000, "time_right_ms": 4500, "font_preference": "A"}' | jq .
+echo ""
+echo ""
+
 echo "✅ All tests completed!"
 echo ""
 echo "To view the data, check the SQLite database:"
@@ -32,19 +32,15 @@ echo "📋 Study Sessions (last 5):"
 sqlite3 -header -column "$DB_FILE" "SELECT id, session_id, participant_id, font_preference, preferred_font_type, time_ams, time_bms, created_at FROM study_sessions ORDER BY created_at DESC LIMIT 5;"
 echo ""
 
-echo "📋 Quiz Responses (from study_sessions JSON field):"
-sqlite3 -header -column "$DB_FILE" "SELECT id, session_id, quiz_responses_json FROM study_sessions WHERE quiz_responses_json IS NOT NULL AND quiz_responses_json != '' ORDER BY created_at DESC LIMIT 5;"
-echo ""
-
-echo "📋 Individual Quiz Responses Table (currently empty - not implemented yet):"
-sqlite3 -header -column "$DB_FILE" "SELECT COUNT(*) as count FROM quiz_responses;"
+echo "📋 Quiz Responses (last 5):"
+sqlite3 -header -column "$DB_FILE" "SELECT id, session_id, question_id, answer_index, is_correct FROM quiz_responses ORDER BY timestamp DESC LIMIT 5;"
 echo ""
 
 echo "📊 Statistics:"
 echo "Total Participants: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM participants;")"
 echo "Total Sessions: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM study_sessions;")"
-echo "Sessions with Quiz Data (JSON): $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM study_sessions WHERE quiz_responses_json IS NOT NULL AND quiz_responses_json != '';")"
-echo "Individual Quiz Responses Table: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM quiz_responses;")"
+echo "Sessions with Quiz Data: $(sqlite3 "$DB_FILE" "SELECT COUNT(DISTINCT session_id) FROM quiz_responses;")"
+echo "Total Quiz Responses: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM quiz_responses;")"
 echo "Total Calibration Data: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM calibration_data;")"
 echo "Total Gaze Points: $(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM gaze_points;")"
 echo ""
@@ -61,10 +57,6 @@ echo "Gaze Points by Panel:"
 sqlite3 -header -column "$DB_FILE" "SELECT panel, COUNT(*) as count FROM gaze_points WHERE panel IS NOT NULL AND panel != '' GROUP BY panel ORDER BY count DESC;"
 echo ""
 
-echo "💡 Note: Quiz responses are stored as JSON in study_sessions.quiz_responses_json"
-echo "💡 Individual quiz responses should also be in the quiz_responses table"
-echo "   (if the frontend successfully submitted them)"
-echo ""
 echo "💡 To explore interactively: sqlite3 $DB_FILE"
 echo "💡 Or use DB Browser for SQLite: https://sqlitebrowser.org/"
 
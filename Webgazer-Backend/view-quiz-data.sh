@@ -1,6 +1,6 @@
 #!/bin/bash
 
-# View quiz responses from JSON field
+# View quiz responses per session
 DB_FILE="readability.db"
 
 if [ ! -f "$DB_FILE" ]; then
@@ -8,22 +8,19 @@ if [ ! -f "$DB_FILE" ]; then
     exit 1
 fi
 
-echo "📋 Quiz Responses (from JSON in study_sessions)"
-echo "================================================"
+echo "📋 Quiz Responses (from quiz_responses)"
+echo "======================================="
 echo ""
 
 sqlite3 -header -column "$DB_FILE" <<EOF
 SELECT 
-    id as session_id,
-    session_id as session_uuid,
-    quiz_responses_json
-FROM study_sessions 
-WHERE quiz_responses_json IS NOT NULL 
-  AND quiz_responses_json != ''
-ORDER BY created_at DESC;
+    s.id as session_id,
+    s.session_id as session_uuid,
+    q.question_id,
+    q.answer_index,
+    q.is_correct,
+    q.response_time
+FROM quiz_responses q
+JOIN study_sessions s ON s.id = q.session_id
+ORDER BY s.created_at DESC, q.timestamp ASC;
 EOF
-
-echo ""
-echo "💡 To see formatted JSON, use DB Browser for SQLite or run:"
-echo "   sqlite3 readability.db \"SELECT quiz_responses_json FROM study_sessions WHERE id = 1;\" | python3 -m json.tool"
-
@@ -0,0 +1,69 @@
+/**
+ * Measures the on-screen boxes of each word and line of rendered text so gaze
+ * points can be mapped to areas of interest (AOIs) on the backend.
+ */
+
+export interface AOIBox {
+	index: number;
+	line: number;
+	text: string;
+	x: number;
+	y: number;
+	width: number;
+	height: number;
+}
+
+export interface TextLayout {
+	words: AOIBox[];
+	lines: AOIBox[];
+}
+
+/**
+ * Measure every word in the element's text nodes, in viewport pixels.
+ * Words whose tops are within half a line height of each other share a line.
+ */
+export function measureTextLayout(element: HTMLElement): TextLayout {
+	const words: AOIBox[] = [];
+	const walker = document.createTreeWalker(element, NodeFilter.SHOW_TEXT);
+	const range = document.createRange();
+
+	let node: Node | null;
+	while ((node = walker.nextNode())) {
+		const content = node.textContent ?? '';
+		for (const match of content.matchAll(/\S+/g)) {
+			range.setStart(node, match.index!);
+			range.setEnd(node, match.index! + match[0].length);
+			const rect = range.getBoundingClientRect();
+			if (rect.width === 0 || rect.height === 0) continue;
+			words.push({
+				index: words.length,
+				line: 0,
+				text: match[0],
+				x: rect.left,
+				y: rect.top,
+				width: rect.width,
+				height: rect.height
+			});
+		}
+	}
+	range.detach();
+
+	const lines: AOIBox[] = [];
+	for (const word of words) {
+		const current = lines[lines.length - 1];
+		if (current && Math.abs(word.y - current.y) < current.height / 2) {
+			const right = Math.max(current.x + current.width, word.x + word.width);
+			const bottom = Math.max(current.y + current.height, word.y + word.height);
+			current.x = Math.min(current.x, word.x);
+			current.y = Math.min(current.y, word.y);
+			current.width = right - current.x;
+			current.height = bottom - current.y;
+			current.text += ' ' + word.text;
+		} else {
+			lines.push({ ...word, index: lines.length, line: lines.length });
+		}
+		word.line = lines.length - 1;
+	}
+
+	return { words, lines };
+}
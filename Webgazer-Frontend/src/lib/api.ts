@@ -2,6 +2,8 @@
  * API client for Readability Study Backend
  */
 
+import type { TextLayout } from './aoi';
+
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080';
 
 export interface Passage {
@@ -25,15 +27,15 @@ export interface StudyTextResponse {
 
 export interface QuizQuestionResponse {
 	id: string;
+	revision_id?: number; // revision shown; sent back so the answer is graded against it
 	prompt: string;
 	choices: string[];
-	answer: number;
 }
 
 export interface StudySessionData {
 	participant_id?: number;
 	session_id?: string;
-	calibration_points?: number;
+	study_text_id?: number;
 	font_left?: string;
 	font_right?: string;
 	time_left_ms?: number;
@@ -42,7 +44,6 @@ export interface StudySessionData {
 	time_b_ms?: number;
 	font_preference?: string;
 	preferred_font_type?: string;
-	quiz_responses_json?: string;
 	user_agent?: string;
 	screen_width?: number;
 	screen_height?: number;
@@ -51,20 +52,87 @@ export interface StudySessionData {
 export interface QuizResponseData {
 	session_id: number;
 	question_id: string;
+	question_revision_id?: number;
+	passage_id?: number;
 	answer_index: number;
-	is_correct?: boolean;
 	response_time?: number;
 }
 
+export interface SessionCondition {
+	passage_id: number;
+	position: number;
+	font_left: string;
+	font_right: string;
+}
+
 export interface ApiResponse {
 	success: boolean;
 	session_id?: string;
 	id?: number;
+	condition_index?: number;
+	conditions?: SessionCondition[];
 	error?: string;
 }
 
+/** URL parameters Prolific and MTurk append to the study link */
+const RECRUITMENT_PARAMS = ['PROLIFIC_PID', 'STUDY_ID', 'SESSION_ID', 'workerId', 'assignmentId', 'hitId'];
+
+/** assignmentId MTurk sends while a worker only previews the HIT */
+export const MTURK_PREVIEW_ASSIGNMENT = 'ASSIGNMENT_ID_NOT_AVAILABLE';
+
+/**
+ * Remember the recruitment platform parameters from the study link so the
+ * participant is registered with their Prolific or MTurk IDs
+ */
+export function captureRecruitmentParams(search: string): void {
+	const params = new URLSearchParams(search);
+	const captured: Record<string, string> = {};
+	for (const key of RECRUITMENT_PARAMS) {
+		const value = params.get(key);
+		if (value) {
+			captured[key] = value;
+		}
+	}
+	if (Object.keys(captured).length === 0) {
+		return;
+	}
+
+	// A link for another platform participant must not reuse the stored participant
+	if (sessionStorage.getItem('recruitment_params') !== JSON.stringify(captured)) {
+		sessionStorage.removeItem('participant_id');
+		sessionStorage.removeItem('completion_code');
+		sessionStorage.removeItem('completion_redirect_url');
+		sessionStorage.removeItem('already_completed');
+	}
+	sessionStorage.setItem('recruitment_params', JSON.stringify(captured));
+}
+
+function recruitmentParams(): Record<string, string> {
+	try {
+		return JSON.parse(sessionStorage.getItem('recruitment_params') || '{}');
+	} catch {
+		return {};
+	}
+}
+
+/**
+ * Remember the completion code (and platform redirect) issued for the session
+ */
+function storeCompletion(result: { completion_code?: string; redirect_url?: string }): void {
+	if (result.completion_code) {
+		sessionStorage.setItem('completion_code', result.completion_code);
+	}
+	if (result.redirect_url) {
+		sessionStorage.setItem('completion_redirect_url', result.redirect_url);
+	} else {
+		sessionStorage.removeItem('completion_redirect_url');
+	}
+}
+
 /**
- * Create or get a participant
+ * Create or get a participant. Participants from Prolific or MTurk who come
+ * back are recognised by the backend; if they already completed the study,
+ * 'already_completed' and their completion code are stored in sessionStorage.
  */
 export async function createParticipant(source: string = 'web'): Promise<number> {
 	// Check if participant ID already exists in sessionStorage
@@ -79,7 +147,7 @@ export async function createParticipant(source: string = 'web'): Promise<number>
 			headers: {
 				'Content-Type': 'application/json'
 			},
-			body: JSON.stringify({ source })
+			body: JSON.stringify({ source, ...recruitmentParams() })
 		});
 
 		if (!response.ok) {
@@ -88,6 +156,10 @@ export async function createParticipant(source: string = 'web'): Promise<number>
 
 		const data = await response.json();
 		const participantId = data.id;
+		if (data.already_completed) {
+			sessionStorage.setItem('already_completed', 'true');
+			storeCompletion(data);
+		}
 
 		// Store in sessionStorage for reuse
 		sessionStorage.setItem('participant_id', String(participantId));
@@ -145,6 +217,10 @@ export async function submitStudySession(data: StudySessionData): Promise<ApiRes
 		if (result.id) {
 			sessionStorage.setItem('session_db_id', String(result.id));
 		}
+		// Counterbalanced passage order and sides assigned by the backend
+		if (result.conditions) {
+			sessionStorage.setItem('session_conditions', JSON.stringify(result.conditions));
+		}
 
 		return result;
 	} catch (error) {
@@ -156,6 +232,44 @@ export async function submitStudySession(data: StudySessionData): Promise<ApiRes
 	}
 }
 
+/**
+ * Create the study session before calibration, unless one is already running.
+ * The backend only accepts calibration, gaze and quiz data for an existing
+ * session, in the order the study runs. Returns the session's database ID.
+ */
+export async function startStudySession(): Promise<number | null> {
+	const existing = parseInt(sessionStorage.getItem('session_db_id') || '0', 10);
+	if (existing) {
+		return existing;
+	}
+
+	const result = await submitStudySession({
+		participant_id: parseInt(sessionStorage.getItem('participant_id') || '0', 10) || undefined,
+		study_text_id: parseInt(sessionStorage.getItem('study_text_id') || '0', 10) || undefined
+	});
+	return result.success && result.id ? result.id : null;
+}
+
+/**
+ * Get the database ID of the running session, if any
+ */
+export function getSessionDbId(): number | null {
+	return parseInt(sessionStorage.getItem('session_db_id') || '0', 10) || null;
+}
+
+/**
+ * Get the counterbalanced conditions assigned to the current session, if any
+ */
+export function getSessionConditions(): SessionCondition[] {
+	const stored = sessionStorage.getItem('session_conditions');
+	if (!stored) return [];
+	try {
+		return JSON.parse(stored) as SessionCondition[];
+	} catch {
+		return [];
+	}
+}
+
 /**
  * Submit individual quiz responses
  */
@@ -259,6 +373,100 @@ export async function submitGazePoint(data: {
 	}
 }
 
+/**
+ * Submit a batch of gaze points for one session in a single request
+ */
+export async function submitGazePointsBatch(
+	sessionId: number,
+	points: Array<{
+		x: number;
+		y: number;
+		panel?: string;
+		phase?: string;
+		timestamp?: string;
+	}>
+): Promise<boolean> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/gaze-points/batch`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify({ session_id: sessionId, points })
+		});
+
+		return response.ok;
+	} catch (error) {
+		console.error('Error submitting gaze point batch:', error);
+		return false;
+	}
+}
+
+/**
+ * Upload the word and line boxes of a passage as rendered in one panel
+ */
+export async function submitAOIs(
+	sessionId: number,
+	passageId: number,
+	panel: string,
+	font: string,
+	layout: TextLayout,
+	shownAt: Date = new Date()
+): Promise<boolean> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/aois`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify({
+				session_id: sessionId,
+				passage_id: passageId,
+				panel,
+				font,
+				shown_at: shownAt.toISOString(),
+				words: layout.words,
+				lines: layout.lines
+			})
+		});
+
+		return response.ok;
+	} catch (error) {
+		console.error('Error submitting AOIs:', error);
+		return false;
+	}
+}
+
+/**
+ * Record the outcome of one pairwise font comparison
+ */
+export async function submitFontComparison(data: {
+	session_id: number;
+	passage_id?: number;
+	font_a: string;
+	font_b: string;
+	preferred: string;
+	round: number;
+	bracket: string;
+	time_a_ms: number;
+	time_b_ms: number;
+}): Promise<boolean> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/font-comparison`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify(data)
+		});
+
+		return response.ok;
+	} catch (error) {
+		console.error('Error submitting font comparison:', error);
+		return false;
+	}
+}
+
 /**
  * Submit reading event
  */
@@ -284,6 +492,30 @@ export async function submitReadingEvent(data: {
 	}
 }
 
+export interface StudySettings {
+	default_version: string;
+	accuracy_threshold: number;
+	max_gaze_batch_size: number;
+}
+
+/**
+ * Fetch the study parameters configured on the server
+ */
+export async function fetchSettings(): Promise<StudySettings | null> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/settings`);
+
+		if (!response.ok) {
+			throw new Error(`Failed to fetch settings: ${response.statusText}`);
+		}
+
+		return await response.json();
+	} catch (error) {
+		console.error('Error fetching settings:', error);
+		return null;
+	}
+}
+
 /**
  * Fetch study text from backend
  */
@@ -345,6 +577,130 @@ export async function fetchQuizQuestions(
 	}
 }
 
+export interface ConsentDocumentResponse {
+	id: number;
+	version: string;
+	title: string;
+	body: string;
+}
+
+export interface DemographicsField {
+	key: string;
+	label: string;
+	type: 'choice' | 'number' | 'text';
+	required: boolean;
+	options?: string[];
+	min?: number;
+	max?: number;
+	max_length?: number;
+}
+
+export interface DemographicsSchemaResponse {
+	id: number;
+	version: string;
+	fields: DemographicsField[];
+}
+
+export interface DemographicsFieldError {
+	field?: string;
+	error: string;
+}
+
+/**
+ * Fetch the consent document participants must accept.
+ * Returns null when the study asks for no consent.
+ */
+export async function fetchConsent(): Promise<ConsentDocumentResponse | null> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/consent`);
+
+		if (!response.ok) {
+			throw new Error(`Failed to fetch consent document: ${response.statusText}`);
+		}
+
+		return await response.json();
+	} catch (error) {
+		console.error('Error fetching consent document:', error);
+		return null;
+	}
+}
+
+/**
+ * Record that the participant accepted a consent document version
+ */
+export async function submitConsent(participantId: number, version: string): Promise<boolean> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/consent`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify({ participant_id: participantId, version, accepted: true })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Failed to submit consent: ${response.statusText}`);
+		}
+
+		sessionStorage.setItem('consent_version', version);
+		return true;
+	} catch (error) {
+		console.error('Error submitting consent:', error);
+		return false;
+	}
+}
+
+/**
+ * Fetch the active demographics questionnaire.
+ * Returns null when the study has none.
+ */
+export async function fetchDemographics(): Promise<DemographicsSchemaResponse | null> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/demographics`);
+
+		if (!response.ok) {
+			throw new Error(`Failed to fetch demographics questionnaire: ${response.statusText}`);
+		}
+
+		return await response.json();
+	} catch (error) {
+		console.error('Error fetching demographics questionnaire:', error);
+		return null;
+	}
+}
+
+/**
+ * Submit the participant's demographics answers. Returns the per-field
+ * errors the backend reported, or an empty list on success.
+ */
+export async function submitDemographics(
+	participantId: number,
+	answers: Record<string, string | number>
+): Promise<DemographicsFieldError[]> {
+	try {
+		const response = await fetch(`${API_BASE_URL}/api/demographics`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify({ participant_id: participantId, answers })
+		});
+
+		if (!response.ok) {
+			const data = await response.json().catch(() => ({}));
+			if (data.errors) {
+				return data.errors;
+			}
+			throw new Error(data.error || `Failed to submit demographics: ${response.statusText}`);
+		}
+
+		return [];
+	} catch (error) {
+		console.error('Error submitting demographics:', error);
+		return [{ error: error instanceof Error ? error.message : String(error) }];
+	}
+}
+
 // ============================================================================
 // Admin API Functions
 // ============================================================================
@@ -356,6 +712,11 @@ export interface AdminStudyText {
 	font_left?: string;
 	font_right?: string;
 	active: boolean;
+	status: 'draft' | 'published'; // only drafts can be edited
+	published_at?: string;
+	cloned_from_id?: number;
+	completion_code?: string; // fixed code for every participant, e.g. Prolific's
+	completion_url?: string; // redirect after completion, placeholders like {code}
 	created_at?: string;
 	updated_at?: string;
 }
@@ -368,6 +729,7 @@ export interface AdminPassage {
 	title?: string;
 	font_left?: string;
 	font_right?: string;
+	revision?: number;
 }
 
 export interface AdminQuizQuestion {
@@ -379,6 +741,7 @@ export interface AdminQuizQuestion {
 	choices: string[];
 	answer: number;
 	order: number;
+	revision: number;
 }
 
 export interface AdminApiResponse<T = any> {
@@ -387,12 +750,55 @@ export interface AdminApiResponse<T = any> {
 	error?: string;
 }
 
+/**
+ * Admin: Stored session token, if logged in
+ */
+export function getAdminToken(): string | null {
+	return sessionStorage.getItem('admin_token');
+}
+
+function adminHeaders(extra: Record<string, string> = {}): Record<string, string> {
+	const token = getAdminToken();
+	return token ? { ...extra, Authorization: `Bearer ${token}` } : extra;
+}
+
+/**
+ * Admin: Log in and store the session token
+ */
+export async function adminLogin(
+	username: string,
+	password: string
+): Promise<{ username: string; role: string }> {
+	const response = await fetch(`${API_BASE_URL}/api/admin/login`, {
+		method: 'POST',
+		headers: {
+			'Content-Type': 'application/json'
+		},
+		body: JSON.stringify({ username, password })
+	});
+	const result = await response.json();
+	if (!response.ok || !result.token) {
+		throw new Error(result.error || 'Login failed');
+	}
+	sessionStorage.setItem('admin_token', result.token);
+	sessionStorage.setItem('admin_role', result.role);
+	return { username: result.username, role: result.role };
+}
+
+/**
+ * Admin: Forget the stored session token
+ */
+export function adminLogout(): void {
+	sessionStorage.removeItem('admin_token');
+	sessionStorage.removeItem('admin_role');
+}
+
 /**
  * Admin: List all study texts
  */
 export async function adminListStudyTexts(): Promise<AdminStudyText[]> {
 	try {
-		const response = await fetch(`${API_BASE_URL}/api/admin/study-text`);
+		const response = await fetch(`${API_BASE_URL}/api/admin/study-text`, { headers: adminHeaders() });
 		if (!response.ok) {
 			throw new Error(`Failed to fetch study texts: ${response.statusText}`);
 		}
@@ -407,20 +813,133 @@ export async function adminListStudyTexts(): Promise<AdminStudyText[]> {
 	}
 }
 
+// A quiz question in the admin preview, with its answer key
+export type PreviewQuizQuestion = QuizQuestionResponse & { answer: number };
+
+export interface StudyTextPreview {
+	id: number;
+	version: string;
+	status: 'draft' | 'published';
+	active: boolean;
+	font_left: string;
+	font_right: string;
+	content?: string;
+	passages: (AdminPassage & { quiz_questions: PreviewQuizQuestion[] })[];
+	quiz_questions: PreviewQuizQuestion[];
+}
+
+/**
+ * Admin: Copy a study text with its passages and quiz questions into a new draft
+ */
+export async function adminCloneStudyText(id: number, version: string): Promise<number> {
+	const response = await fetch(`${API_BASE_URL}/api/admin/study-texts/${id}/clone`, {
+		method: 'POST',
+		headers: adminHeaders({ 'Content-Type': 'application/json' }),
+		body: JSON.stringify({ version })
+	});
+	const result = await response.json();
+	if (!response.ok || !result.success) {
+		throw new Error(result.error || 'Failed to clone study text');
+	}
+	return result.id;
+}
+
+/**
+ * Admin: Publish a draft study text and make it the active one
+ */
+export async function adminPublishStudyText(id: number): Promise<void> {
+	const response = await fetch(`${API_BASE_URL}/api/admin/study-texts/${id}/publish`, {
+		method: 'POST',
+		headers: adminHeaders()
+	});
+	const result = await response.json();
+	if (!response.ok || !result.success) {
+		throw new Error(result.error || 'Failed to publish study text');
+	}
+}
+
+/**
+ * Admin: Fetch a study text, drafts included, as participants would receive it
+ */
+export async function adminPreviewStudyText(id: number): Promise<StudyTextPreview> {
+	const response = await fetch(`${API_BASE_URL}/api/admin/study-texts/${id}/preview`, {
+		headers: adminHeaders()
+	});
+	const result: AdminApiResponse<StudyTextPreview> = await response.json();
+	if (!response.ok || !result.success || !result.data) {
+		throw new Error(result.error || 'Failed to preview study text');
+	}
+	return result.data;
+}
+
+export interface BundleImportResult {
+	success: boolean;
+	dry_run: boolean;
+	study_text_id: number;
+	version: string;
+	created: boolean;
+	published: boolean;
+	changes: {
+		action: 'create' | 'update' | 'delete';
+		entity: string;
+		key: string;
+		id?: number;
+		changes?: { field: string; from: unknown; to: unknown }[];
+	}[];
+	unchanged: number;
+}
+
 /**
- * Admin: Create a new study text
+ * Admin: Import a YAML or JSON study bundle; a dry run only reports the changes
+ */
+export async function adminImportBundle(
+	bundle: string,
+	options: { dryRun?: boolean; publish?: boolean } = {}
+): Promise<BundleImportResult> {
+	const params = new URLSearchParams();
+	if (options.dryRun) params.append('dry_run', 'true');
+	if (options.publish) params.append('publish', 'true');
+	const response = await fetch(`${API_BASE_URL}/api/admin/study-texts/import?${params.toString()}`, {
+		method: 'POST',
+		headers: adminHeaders({ 'Content-Type': 'application/yaml' }),
+		body: bundle
+	});
+	const result = await response.json();
+	if (!response.ok || !result.success) {
+		const problems: string[] = result.problems || [];
+		throw new Error([result.error || 'Failed to import bundle', ...problems].join('\n'));
+	}
+	return result;
+}
+
+/**
+ * Admin: Export a study text as a YAML or JSON bundle
+ */
+export async function adminExportBundle(id: number, format: 'yaml' | 'json' = 'yaml'): Promise<string> {
+	const response = await fetch(`${API_BASE_URL}/api/admin/study-texts/${id}/bundle?format=${format}`, {
+		headers: adminHeaders()
+	});
+	if (!response.ok) {
+		throw new Error(`Failed to export bundle: ${response.statusText}`);
+	}
+	return response.text();
+}
+
+/**
+ * Admin: Create a new study text (as a draft)
  */
 export async function adminCreateStudyText(data: {
 	version?: string;
 	content: string;
 	font_left?: string;
 	font_right?: string;
-	active?: boolean;
+	completion_code?: string;
+	completion_url?: string;
 }): Promise<AdminStudyText> {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/study-text`, {
 			method: 'POST',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -456,11 +975,13 @@ export async function adminUpdateStudyText(data: {
 	font_left?: string;
 	font_right?: string;
 	active?: boolean;
+	completion_code?: string;
+	completion_url?: string;
 }): Promise<AdminStudyText> {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/study-text`, {
 			method: 'PUT',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -491,7 +1012,7 @@ export async function adminListPassages(studyTextId?: number): Promise<AdminPass
 		if (studyTextId) {
 			url += `?study_text_id=${studyTextId}`;
 		}
-		const response = await fetch(url);
+		const response = await fetch(url, { headers: adminHeaders() });
 		if (!response.ok) {
 			throw new Error(`Failed to fetch passages: ${response.statusText}`);
 		}
@@ -511,7 +1032,9 @@ export async function adminListPassages(studyTextId?: number): Promise<AdminPass
  */
 export async function adminGetPassage(id: number): Promise<AdminPassage | null> {
 	try {
-		const response = await fetch(`${API_BASE_URL}/api/admin/passage?id=${id}`);
+		const response = await fetch(`${API_BASE_URL}/api/admin/passage?id=${id}`, {
+			headers: adminHeaders()
+		});
 		if (!response.ok) {
 			throw new Error(`Failed to fetch passage: ${response.statusText}`);
 		}
@@ -540,7 +1063,7 @@ export async function adminCreatePassage(data: {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/passage`, {
 			method: 'POST',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -577,7 +1100,7 @@ export async function adminUpdatePassage(data: {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/passage`, {
 			method: 'PUT',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -604,7 +1127,8 @@ export async function adminUpdatePassage(data: {
 export async function adminDeletePassage(id: number): Promise<boolean> {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/passage?id=${id}`, {
-			method: 'DELETE'
+			method: 'DELETE',
+			headers: adminHeaders()
 		});
 		if (!response.ok) {
 			const errorText = await response.text();
@@ -636,7 +1160,7 @@ export async function adminListQuizQuestions(
 		if (params.toString()) {
 			url += `?${params.toString()}`;
 		}
-		const response = await fetch(url);
+		const response = await fetch(url, { headers: adminHeaders() });
 		if (!response.ok) {
 			throw new Error(`Failed to fetch quiz questions: ${response.statusText}`);
 		}
@@ -656,7 +1180,9 @@ export async function adminListQuizQuestions(
  */
 export async function adminGetQuizQuestion(id: number): Promise<AdminQuizQuestion | null> {
 	try {
-		const response = await fetch(`${API_BASE_URL}/api/admin/quiz-question?id=${id}`);
+		const response = await fetch(`${API_BASE_URL}/api/admin/quiz-question?id=${id}`, {
+			headers: adminHeaders()
+		});
 		if (!response.ok) {
 			throw new Error(`Failed to fetch quiz question: ${response.statusText}`);
 		}
@@ -686,7 +1212,7 @@ export async function adminCreateQuizQuestion(data: {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/quiz-question`, {
 			method: 'POST',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -724,7 +1250,7 @@ export async function adminUpdateQuizQuestion(data: {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/quiz-question`, {
 			method: 'PUT',
-			headers: { 'Content-Type': 'application/json' },
+			headers: adminHeaders({ 'Content-Type': 'application/json' }),
 			body: JSON.stringify(data)
 		});
 		if (!response.ok) {
@@ -751,7 +1277,8 @@ export async function adminUpdateQuizQuestion(data: {
 export async function adminDeleteQuizQuestion(id: number): Promise<boolean> {
 	try {
 		const response = await fetch(`${API_BASE_URL}/api/admin/quiz-question?id=${id}`, {
-			method: 'DELETE'
+			method: 'DELETE',
+			headers: adminHeaders()
 		});
 		if (!response.ok) {
 			const errorText = await response.text();
@@ -775,11 +1302,14 @@ export interface Statistics {
 	};
 	sessions: {
 		total: number;
+		by_status: Record<string, number>; // created, calibrated, ..., completed, abandoned
 	};
 	font_preferences: {
 		serif: number;
 		sans: number;
 		total: number;
+		by_font: Record<string, number>;
+		by_category: Record<string, number>;
 	};
 	quiz_performance: {
 		total_responses: number;
@@ -798,6 +1328,8 @@ export interface Statistics {
 		average_serif_ms: number;
 		average_sans_ms: number;
 		total_sessions: number;
+		by_font: Record<string, number>;
+		by_category: Record<string, number>;
 	};
 	accuracy_measurements: {
 		total: number;
@@ -813,11 +1345,16 @@ export interface Statistics {
 	calibration_data: {
 		total: number;
 	};
+	exclusions: {
+		applied: boolean;
+		excluded_sessions: number;
+	};
 }
 
-export async function adminGetStatistics(): Promise<Statistics> {
+export async function adminGetStatistics(excludeFlagged: boolean = false): Promise<Statistics> {
 	try {
-		const response = await fetch(`${API_BASE_URL}/api/admin/statistics`);
+		const query = excludeFlagged ? '?exclude=true' : '';
+		const response = await fetch(`${API_BASE_URL}/api/admin/statistics${query}`, { headers: adminHeaders() });
 		if (!response.ok) {
 			throw new Error(`Failed to fetch statistics: ${response.statusText}`);
 		}
@@ -833,15 +1370,53 @@ export async function adminGetStatistics(): Promise<Statistics> {
 }
 
 /**
- * Collect all session data from sessionStorage and submit
+ * Submit the quiz answers for the current passage. After the last passage the
+ * session summary collected in sessionStorage is sent and the session is
+ * marked completed. revisionIds maps question IDs to the revision shown.
  */
-export async function submitCompleteSession(quizAnswers: Record<string, number>): Promise<boolean> {
+export async function submitCompleteSession(
+	quizAnswers: Record<string, number>,
+	final: boolean = true,
+	revisionIds: Record<string, number> = {}
+): Promise<boolean> {
 	try {
-		// Collect data from sessionStorage
-		const sessionData: StudySessionData = {
-			participant_id: parseInt(sessionStorage.getItem('participant_id') || '0', 10) || undefined,
-			calibration_points:
-				parseInt(sessionStorage.getItem('calibration_points') || '0', 10) || undefined,
+		const sessionDbId = getSessionDbId();
+		if (!sessionDbId) {
+			console.error('No study session to submit quiz responses for');
+			return false;
+		}
+
+		// Submit each quiz response individually; correctness is graded by the backend
+		const passageId = sessionStorage.getItem('current_passage_id');
+		const quizSubmissionPromises = [];
+		for (const [questionId, answerIndex] of Object.entries(quizAnswers)) {
+			quizSubmissionPromises.push(
+				submitQuizResponse({
+					session_id: sessionDbId,
+					question_id: questionId,
+					question_revision_id: revisionIds[questionId],
+					passage_id: passageId ? parseInt(passageId, 10) : undefined,
+					answer_index: answerIndex
+				}).catch((error) => {
+					console.error(`Failed to submit quiz response for ${questionId}:`, error);
+					return false;
+				})
+			);
+		}
+
+		// Wait for all quiz responses to be submitted (but don't fail if some fail)
+		const results = await Promise.all(quizSubmissionPromises);
+		const successCount = results.filter((r) => r === true).length;
+		console.log(
+			`Submitted ${successCount}/${quizSubmissionPromises.length} quiz responses individually`
+		);
+
+		if (!final) {
+			return true;
+		}
+
+		// Collect the session summary from sessionStorage
+		const summary: StudySessionData = {
 			font_left: sessionStorage.getItem('font_left') || undefined,
 			font_right: sessionStorage.getItem('font_right') || undefined,
 			time_left_ms: parseInt(sessionStorage.getItem('time_left_ms') || '0', 10) || undefined,
@@ -852,59 +1427,21 @@ export async function submitCompleteSession(quizAnswers: Record<string, number>)
 			preferred_font_type: sessionStorage.getItem('font_preferred_type') || undefined
 		};
 
-		// Convert quiz answers to JSON
-		const quizResponses = Object.entries(quizAnswers).map(([questionId, answerIndex]) => ({
-			question_id: questionId,
-			answer: answerIndex
-		}));
-		sessionData.quiz_responses_json = JSON.stringify(quizResponses);
-
-		// Submit to backend
-		const result = await submitStudySession(sessionData);
-
-		if (result.success && result.id) {
-			// Also submit individual quiz responses to the quiz_responses table
-			const sessionDbId = result.id;
-
-			// Fetch quiz questions from API to get correct answers
-			// Use passage-specific questions if available, otherwise use study text questions
-			const studyTextId = sessionStorage.getItem('study_text_id');
-			const passageId = sessionStorage.getItem('current_passage_id');
-			const quizQuestions = await fetchQuizQuestions(
-				studyTextId ? parseInt(studyTextId, 10) : undefined,
-				passageId ? parseInt(passageId, 10) : undefined
-			);
-
-			// Submit each quiz response individually
-			const quizSubmissionPromises = [];
-			for (const [questionId, answerIndex] of Object.entries(quizAnswers)) {
-				const question = quizQuestions.find((q) => q.id === questionId);
-				const isCorrect = question ? answerIndex === question.answer : undefined;
-
-				quizSubmissionPromises.push(
-					submitQuizResponse({
-						session_id: sessionDbId,
-						question_id: questionId,
-						answer_index: answerIndex,
-						is_correct: isCorrect
-					}).catch((error) => {
-						console.error(`Failed to submit quiz response for ${questionId}:`, error);
-						return false;
-					})
-				);
-			}
-
-			// Wait for all quiz responses to be submitted (but don't fail if some fail)
-			const results = await Promise.all(quizSubmissionPromises);
-			const successCount = results.filter((r) => r === true).length;
-			console.log(
-				`Submitted ${successCount}/${quizSubmissionPromises.length} quiz responses individually`
-			);
-
-			return true;
+		const response = await fetch(`${API_BASE_URL}/api/session/${sessionDbId}/complete`, {
+			method: 'POST',
+			headers: {
+				'Content-Type': 'application/json'
+			},
+			body: JSON.stringify(summary)
+		});
+		if (!response.ok) {
+			const errorText = await response.text();
+			console.error(`Failed to complete session: ${response.status} ${errorText}`);
+			return false;
 		}
+		storeCompletion(await response.json());
 
-		return false;
+		return true;
 	} catch (error) {
 		console.error('Error submitting complete session:', error);
 		return false;